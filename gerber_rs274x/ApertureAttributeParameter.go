@@ -0,0 +1,43 @@
+package gerber_rs274x
+
+import (
+	"fmt"
+)
+
+// A TA parameter, which adds an attribute (e.g. .AperFunction) to the aperture dictionary.  Apertures defined after it carry the attribute
+type ApertureAttributeParameter struct {
+	paramCode ParameterCode
+	name string
+	values []string
+}
+
+func (apertureAttribute *ApertureAttributeParameter) DataBlockPlaceholder() {
+
+}
+
+func (apertureAttribute *ApertureAttributeParameter) Accept(visitor Visitor) error {
+	return visitor.VisitApertureAttribute(apertureAttribute)
+}
+
+func (apertureAttribute *ApertureAttributeParameter) ProcessDataBlockBoundsCheck(imageBounds *ImageBounds, gfxState *GraphicsState) error {
+	// The attribute was attached to the apertures defined after it when the file was parsed
+	return nil
+}
+
+func (apertureAttribute *ApertureAttributeParameter) ProcessDataBlockRender(renderer Renderer, gfxState *GraphicsState) error {
+	// The attribute was attached to the apertures defined after it when the file was parsed
+	return nil
+}
+
+func (apertureAttribute *ApertureAttributeParameter) String() string {
+	return fmt.Sprintf("{TA, Name: %s, Values: %v}", apertureAttribute.name, apertureAttribute.values)
+}
+
+// The name of the attribute, including the leading "." for standard attributes
+func (apertureAttribute *ApertureAttributeParameter) GetName() string {
+	return apertureAttribute.name
+}
+
+func (apertureAttribute *ApertureAttributeParameter) GetValues() []string {
+	return apertureAttribute.values
+}
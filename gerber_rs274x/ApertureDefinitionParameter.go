@@ -2,7 +2,6 @@ package gerber_rs274x
 
 import (
 	"fmt"
-	cairo "github.com/ungerik/go-cairo"
 )
 
 type ApertureDefinitionParameter struct {
@@ -16,6 +15,10 @@ func (apertureDefinition *ApertureDefinitionParameter) DataBlockPlaceholder() {
 
 }
 
+func (apertureDefinition *ApertureDefinitionParameter) Accept(visitor Visitor) error {
+	return visitor.VisitApertureDefinition(apertureDefinition)
+}
+
 func (apertureDefinition *ApertureDefinitionParameter) ProcessDataBlockBoundsCheck(imageBounds *ImageBounds, gfxState *GraphicsState) error {
 	// Remember this aperture in the graphics state for later use
 	gfxState.apertures[apertureDefinition.apertureNumber] = apertureDefinition.aperture
@@ -23,7 +26,7 @@ func (apertureDefinition *ApertureDefinitionParameter) ProcessDataBlockBoundsChe
 	return nil
 }
 
-func (apertureDefinition *ApertureDefinitionParameter) ProcessDataBlockSurface(surface *cairo.Surface, gfxState *GraphicsState) error {
+func (apertureDefinition *ApertureDefinitionParameter) ProcessDataBlockRender(renderer Renderer, gfxState *GraphicsState) error {
 	// Remember this aperture in the graphics state for later use
 	gfxState.apertures[apertureDefinition.apertureNumber] = apertureDefinition.aperture
 	
@@ -54,4 +57,16 @@ func (adParam *ApertureDefinitionParameter) String() string {
 	}
 
 	return fmt.Sprintf("{AD, D-Code: %d, Type: %s, Aperture: %s}", adParam.apertureNumber, apertureType, adParam.aperture)
-}
\ No newline at end of file
+}
+
+func (adParam *ApertureDefinitionParameter) GetApertureNumber() int {
+	return adParam.apertureNumber
+}
+
+func (adParam *ApertureDefinitionParameter) GetApertureType() ApertureType {
+	return adParam.apertureType
+}
+
+func (adParam *ApertureDefinitionParameter) GetAperture() Aperture {
+	return adParam.aperture
+}
@@ -2,9 +2,9 @@ package gerber_rs274x
 
 import (
 	"fmt"
+	"math"
 	"strconv"
 	"strings"
-	cairo "github.com/ungerik/go-cairo"
 )
 
 type ApertureMacroParameter struct {
@@ -21,7 +21,7 @@ type AperturePrimitive interface {
 	ApertureMacroDataBlock
 	AperturePrimitivePlaceholder()
 	GetPrimitiveBounds(env *ExpressionEnvironment) (xMin float64, xMax float64, yMin float64, yMax float64)
-	DrawPrimitiveToSurface(surface *cairo.Surface, env *ExpressionEnvironment) error
+	DrawPrimitive(renderer Renderer, env *ExpressionEnvironment) error
 }
 
 type ApertureMacroVariableDefinition struct {
@@ -33,13 +33,17 @@ type ApertureMacroComment struct {
 	comment string
 }
 
+func (apertureMacro *ApertureMacroParameter) Accept(visitor Visitor) error {
+	return visitor.VisitApertureMacro(apertureMacro)
+}
+
 func (apertureMacro *ApertureMacroParameter) ProcessDataBlockBoundsCheck(imageBounds *ImageBounds, gfxState *GraphicsState) error {
 	// Save the macro in the graphics state for use during bounds checking
 	gfxState.apertureMacros[apertureMacro.macroName] = apertureMacro.dataBlocks
 	return nil
 }
 
-func (apertureMacro *ApertureMacroParameter) ProcessDataBlockSurface(surface *cairo.Surface, gfxState *GraphicsState) error {
+func (apertureMacro *ApertureMacroParameter) ProcessDataBlockRender(renderer Renderer, gfxState *GraphicsState) error {
 	// Save the macro in the graphics state for use during rendering
 	gfxState.apertureMacros[apertureMacro.macroName] = apertureMacro.dataBlocks
 	return nil
@@ -502,4 +506,88 @@ func parseThermalPrimitive(modifiers []string) (AperturePrimitive, error) {
 	}
 	
 	return &ThermalPrimitive{centerX, centerY, outerDiameter, innerDiameter, gapThickness, rotation},nil
-}
\ No newline at end of file
+}
+
+func (apertureMacro *ApertureMacroParameter) GetMacroName() string {
+	return apertureMacro.macroName
+}
+
+// The comments, variable definitions and primitives that make up the macro, in order
+func (apertureMacro *ApertureMacroParameter) GetDataBlocks() []ApertureMacroDataBlock {
+	return apertureMacro.dataBlocks
+}
+
+func (variableDefinition *ApertureMacroVariableDefinition) GetVariableNumber() int {
+	return variableDefinition.variableNumber
+}
+
+func (variableDefinition *ApertureMacroVariableDefinition) GetValue() ApertureMacroExpression {
+	return variableDefinition.value
+}
+
+func (comment *ApertureMacroComment) GetComment() string {
+	return comment.comment
+}
+
+// Primitives with an exposure modifier, which turns the primitive off (0) or on (1)
+type exposedPrimitive interface {
+	AperturePrimitive
+	GetExposure() ApertureMacroExpression
+}
+
+// Whether the primitive adds to the aperture.  Primitives with exposure off erase what the primitives before them drew,
+// and primitives without an exposure modifier are always on
+func primitiveExposureOn(primitive AperturePrimitive, env *ExpressionEnvironment) bool {
+	if exposed,ok := primitive.(exposedPrimitive); ok {
+		return exposed.GetExposure().EvaluateExpression(env) != 0.0
+	}
+
+	return true
+}
+
+// Rotates the point counter-clockwise around the origin of the macro by the angle (in degrees)
+func rotatePrimitivePoint(x float64, y float64, rotation float64) (float64, float64) {
+	if rotation == 0.0 {
+		return x,y
+	}
+
+	sin,cos := math.Sincos(rotation * (math.Pi / 180.0))
+	return (x * cos) - (y * sin),(x * sin) + (y * cos)
+}
+
+// The bounds of the outline through the points once it has been rotated around the origin of the macro by the angle (in degrees)
+func primitiveOutlineBounds(xs []float64, ys []float64, rotation float64) (xMin float64, xMax float64, yMin float64, yMax float64) {
+	if len(xs) == 0 {
+		return 0.0,0.0,0.0,0.0
+	}
+
+	xMin,xMax = math.Inf(1),math.Inf(-1)
+	yMin,yMax = math.Inf(1),math.Inf(-1)
+	for i := range xs {
+		x,y := rotatePrimitivePoint(xs[i], ys[i], rotation)
+		xMin = math.Min(xMin, x)
+		xMax = math.Max(xMax, x)
+		yMin = math.Min(yMin, y)
+		yMax = math.Max(yMax, y)
+	}
+
+	return xMin,xMax,yMin,yMax
+}
+
+// Fills the outline through the points, rotated around the origin of the macro by the angle (in degrees)
+func fillPrimitiveOutline(renderer Renderer, xs []float64, ys []float64, rotation float64) {
+	if len(xs) == 0 {
+		return
+	}
+
+	renderer.PushTransform(0.0, 0.0, rotation * (math.Pi / 180.0))
+
+	renderer.MoveTo(xs[0], ys[0])
+	for i := 1; i < len(xs); i++ {
+		renderer.LineTo(xs[i], ys[i])
+	}
+	renderer.ClosePath()
+	renderer.Fill()
+
+	renderer.PopTransform()
+}
@@ -26,14 +26,17 @@ func parseExpression(infixExpression string) (ApertureMacroExpression, error) {
 	stack := NewExpressionStack()
 	state := PARSING_NORMAL
 	numberAccumulator := ""
+	// Whether the next thing should be an operand, which is how we tell a unary minus from a subtraction
+	expectingOperand := true
 	
 	for _,char := range infixExpression {
 		switch state {
 			case PARSING_NORMAL:
-				if newState,err := nextParseState(char, &numberAccumulator, &postfixExpressionList, stack); err != nil {
+				if newState,err := nextParseState(char, expectingOperand, &numberAccumulator, &postfixExpressionList, stack); err != nil {
 					return nil,err
 				} else {
 					state = newState
+					expectingOperand = char != ')'
 				}
 			
 			case PARSING_LITERAL:
@@ -49,10 +52,11 @@ func parseExpression(infixExpression string) (ApertureMacroExpression, error) {
 					// Reset the string accumulator
 					numberAccumulator = ""
 					
-					if newState,err := nextParseState(char, &numberAccumulator, &postfixExpressionList, stack); err != nil {
+					if newState,err := nextParseState(char, false, &numberAccumulator, &postfixExpressionList, stack); err != nil {
 						return nil,err
 					} else {
 						state = newState
+						expectingOperand = char != ')'
 					}
 				}
 			
@@ -69,10 +73,11 @@ func parseExpression(infixExpression string) (ApertureMacroExpression, error) {
 					// Reset the string accumulator
 					numberAccumulator = ""
 					
-					if newState,err := nextParseState(char, &numberAccumulator, &postfixExpressionList, stack); err != nil {
+					if newState,err := nextParseState(char, false, &numberAccumulator, &postfixExpressionList, stack); err != nil {
 						return nil,err
 					} else {
 						state = newState
+						expectingOperand = char != ')'
 					}
 				}
 		}
@@ -108,7 +113,16 @@ func parseExpression(infixExpression string) (ApertureMacroExpression, error) {
 				stack.Push(expr)
 			
 			case *OperatorExpression:
-				if expr1,expr2,err := stack.Pop2(); err != nil {
+				if exprValue.unary {
+					if operand,err := stack.Pop(); err != nil {
+						return nil,err
+					} else if literal,ok := operand.(*LiteralExpression); ok {
+						// Negative numbers are just literals, the same as if they had been written without the sign and then negated
+						stack.Push(&LiteralExpression{-literal.value})
+					} else {
+						stack.Push(&ArithmeticExpression{OPERATOR_SUBTRACT, &LiteralExpression{0.0}, operand})
+					}
+				} else if expr1,expr2,err := stack.Pop2(); err != nil {
 					return nil,err
 				} else {
 					stack.Push(&ArithmeticExpression{exprValue.operator, expr2, expr1})
@@ -129,7 +143,7 @@ func parseExpression(infixExpression string) (ApertureMacroExpression, error) {
 	return expr,nil
 }
 
-func nextParseState(char rune, numberAccumulator *string, expressionList *[]ApertureMacroExpression, stack *ExpressionStack) (ExpressionParseState, error) {
+func nextParseState(char rune, expectingOperand bool, numberAccumulator *string, expressionList *[]ApertureMacroExpression, stack *ExpressionStack) (ExpressionParseState, error) {
 	if char == '$' {
 		return PARSING_VARIABLE,nil
 		
@@ -138,45 +152,44 @@ func nextParseState(char rune, numberAccumulator *string, expressionList *[]Aper
 		return PARSING_LITERAL,nil
 		
 	} else if char == '+' || char == '-' || char == 'x' || char == 'X' || char == '/' {
+		var operator *OperatorExpression
 		switch char {
-			case '+', '-':
-				done := false
-				for (stack.Len() > 0) && !done {
-					if topOfStack,err := stack.Peek(); err != nil {
-						return PARSING_NORMAL,err
-					} else {
-						switch exprValue := topOfStack.(type) {
-							case *OperatorExpression:
-								if exprValue.operator == OPERATOR_MULTIPLY || exprValue.operator == OPERATOR_DIVIDE {
-									// We've already checked that there are items on the stack, so it's safe to pop and ignore the error
-									expr,_ := stack.Pop()
-									*expressionList = append(*expressionList, expr)
-								} else {
-									done = true
-								}
-							
-							default:
-								done = true
-						}
-					}
+			case '+':
+				if expectingOperand {
+					// A unary plus doesn't change anything, so we just skip it
+					return PARSING_NORMAL,nil
 				}
-				fallthrough // We always want to push this new operator on the stack, so we fallthrough here
+				operator = &OperatorExpression{operator: OPERATOR_ADD}
+				
+			case '-':
+				// A minus sign where an operand should be negates the operand (e.g. "-0.5" or "$1x-2"), rather than subtracting it
+				operator = &OperatorExpression{operator: OPERATOR_SUBTRACT, unary: expectingOperand}
 			
-			case 'x', 'X', '/':
-				switch char {
-					case '+':
-						stack.Push(&OperatorExpression{OPERATOR_ADD})
-						
-					case '-':
-						stack.Push(&OperatorExpression{OPERATOR_SUBTRACT})
-					
-					case 'x', 'X':
-						stack.Push(&OperatorExpression{OPERATOR_MULTIPLY})
-					
-					case '/':
-						stack.Push(&OperatorExpression{OPERATOR_DIVIDE})
+			case 'x', 'X':
+				operator = &OperatorExpression{operator: OPERATOR_MULTIPLY}
+			
+			case '/':
+				operator = &OperatorExpression{operator: OPERATOR_DIVIDE}
+		}
+		
+		if !operator.unary {
+			// Operators are evaluated left to right, so every operator on the stack that binds at least as tightly as the new one
+			// comes off first (e.g. "5-2-1" is "(5-2)-1").  Unary minus is always applied to the operand right after it, so nothing
+			// comes off the stack for it
+			done := false
+			for (stack.Len() > 0) && !done {
+				if topOfStack,err := stack.Peek(); err != nil {
+					return PARSING_NORMAL,err
+				} else if topOperator,ok := topOfStack.(*OperatorExpression); ok && topOperator.precedence() >= operator.precedence() {
+					// We've already checked that there are items on the stack, so it's safe to pop and ignore the error
+					expr,_ := stack.Pop()
+					*expressionList = append(*expressionList, expr)
+				} else {
+					done = true
 				}
+			}
 		}
+		stack.Push(operator)
 		
 		return PARSING_NORMAL,nil
 		
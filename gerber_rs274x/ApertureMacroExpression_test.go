@@ -0,0 +1,48 @@
+package gerber_rs274x
+
+import (
+	"math"
+	"testing"
+)
+
+func TestEvaluateExpression(t *testing.T) {
+	env := NewExpressionEnvironment()
+	env.setVariableValue(1, 1.5)
+	env.setVariableValue(2, 2.0)
+
+	testCases := []struct {
+		expression string
+		value float64
+	}{
+		{"-0.5", -0.5},
+		{"+0.5", 0.5},
+		{"-$1", -1.5},
+		{"--$1", 1.5},
+		// Multiplication and division bind more tightly than addition and subtraction
+		{"2+3x4", 14.0},
+		{"10-2x3", 4.0},
+		{"-$1+2x3", 4.5},
+		{"2x3-$2/4", 5.5},
+		{"(2+3)x4", 20.0},
+		// Operators that bind just as tightly are evaluated left to right
+		{"5-2-1", 2.0},
+		{"8/4/2", 1.0},
+		{"6/3x2", 4.0},
+		{"1-2+3", 2.0},
+		// A minus sign straight after an operator or an opening parenthesis negates what comes after it
+		{"$1x-2", -3.0},
+		{"4/-$2", -2.0},
+		{"1--1", 2.0},
+		{"-($1+$2)x2", -7.0},
+		{"(-$1)x(-$2)", 3.0},
+		{"-$1X$2", -3.0},
+	}
+
+	for _,testCase := range testCases {
+		if expression,err := parseExpression(testCase.expression); err != nil {
+			t.Errorf("%s: error parsing: %v", testCase.expression, err)
+		} else if value := expression.EvaluateExpression(env); math.Abs(value - testCase.value) > 1e-9 {
+			t.Errorf("%s: evaluated to %v, expected %v", testCase.expression, value, testCase.value)
+		}
+	}
+}
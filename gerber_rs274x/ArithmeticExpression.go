@@ -4,13 +4,14 @@ import (
 	"fmt"
 )
 
+// The operators that can appear in aperture macro expressions
 type ArithmeticOperator int
 
 const (
-	OPERATOR_ADD ArithmeticOperator = iota
-	OPERATOR_SUBTRACT
-	OPERATOR_MULTIPLY
-	OPERATOR_DIVIDE
+	OPERATOR_ADD ArithmeticOperator = iota // +
+	OPERATOR_SUBTRACT // -
+	OPERATOR_MULTIPLY // x
+	OPERATOR_DIVIDE // /
 )
 
 type ArithmeticExpression struct {
@@ -55,4 +56,16 @@ func (expr *ArithmeticExpression) String() string {
 	}
 	
 	return fmt.Sprintf("{ArithmeticExpr, Operator: %s, LHS: %v, RHS: %v}\n", operator, expr.lhs, expr.rhs)
-}
\ No newline at end of file
+}
+
+func (expr *ArithmeticExpression) GetOperator() ArithmeticOperator {
+	return expr.operator
+}
+
+func (expr *ArithmeticExpression) GetLHS() ApertureMacroExpression {
+	return expr.lhs
+}
+
+func (expr *ArithmeticExpression) GetRHS() ApertureMacroExpression {
+	return expr.rhs
+}
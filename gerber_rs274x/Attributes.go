@@ -0,0 +1,188 @@
+package gerber_rs274x
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Gerber X2 attributes, by name, each with its values.  The names of the standard attributes start with a ".",
+// e.g. ".AperFunction" or ".N" (the net name).  Attribute dictionaries are shared between data blocks,
+// so they mustn't be modified
+type Attributes map[string][]string
+
+// The first value of the attribute, or "" if the attribute isn't set or has no values
+func (attributes Attributes) Value(name string) string {
+	if values := attributes[name]; len(values) > 0 {
+		return values[0]
+	}
+
+	return ""
+}
+
+func (attributes Attributes) String() string {
+	if len(attributes) == 0 {
+		return "{Attributes}"
+	}
+
+	fields := make([]string, 0, len(attributes))
+	for name,values := range attributes {
+		fields = append(fields, fmt.Sprintf("%s: %v", name, values))
+	}
+	// Map iteration order is random, but the output shouldn't be
+	sort.Strings(fields)
+
+	return fmt.Sprintf("{Attributes, %s}", strings.Join(fields, ", "))
+}
+
+// Returns a copy of the attributes with the named attribute set to the values
+func (attributes Attributes) with(name string, values []string) Attributes {
+	updated := make(Attributes, len(attributes) + 1)
+	for key,value := range attributes {
+		updated[key] = value
+	}
+	updated[name] = values
+
+	return updated
+}
+
+// Returns a copy of the attributes without the named attribute
+func (attributes Attributes) without(name string) Attributes {
+	if _,found := attributes[name]; !found {
+		return attributes
+	}
+
+	updated := make(Attributes, len(attributes))
+	for key,value := range attributes {
+		if key != name {
+			updated[key] = value
+		}
+	}
+
+	return updated
+}
+
+// Keeps track of the attribute dictionaries while a file is parsed, so that every draw and flash
+// can be given the attributes that apply to it
+type attributeTracker struct {
+	fileAttributes Attributes
+	// The current aperture dictionary, which is attached to each aperture as it's defined
+	apertureAttributes Attributes
+	objectAttributes Attributes
+	// The aperture attributes each aperture was defined with
+	definedApertures map[int]Attributes
+	currentAperture int
+	// Regions aren't drawn with an aperture, so they get the aperture dictionary as it is when they're drawn instead
+	regionModeOn bool
+	// The attributes of the next draw or flash.  These are only worked out when they're needed, and then shared
+	// by every draw and flash until one of the dictionaries or the current aperture changes
+	current Attributes
+	currentValid bool
+}
+
+func newAttributeTracker() *attributeTracker {
+	tracker := new(attributeTracker)
+	tracker.definedApertures = make(map[int]Attributes, 10)
+
+	return tracker
+}
+
+// Updates the dictionaries with a data block that has just been parsed, and attaches the attributes to it if it's a draw or flash
+func (tracker *attributeTracker) update(dataBlock DataBlock) {
+	switch dataBlockValue := dataBlock.(type) {
+		case *FileAttributeParameter:
+			tracker.fileAttributes = tracker.fileAttributes.with(dataBlockValue.name, dataBlockValue.values)
+
+		case *ApertureAttributeParameter:
+			tracker.apertureAttributes = tracker.apertureAttributes.with(dataBlockValue.name, dataBlockValue.values)
+			tracker.currentValid = false
+
+		case *ObjectAttributeParameter:
+			tracker.objectAttributes = tracker.objectAttributes.with(dataBlockValue.name, dataBlockValue.values)
+			tracker.currentValid = false
+
+		case *DeleteAttributeParameter:
+			// File attributes can't be deleted, so this only affects the aperture and object dictionaries
+			if dataBlockValue.name == "" {
+				tracker.apertureAttributes = nil
+				tracker.objectAttributes = nil
+			} else {
+				tracker.apertureAttributes = tracker.apertureAttributes.without(dataBlockValue.name)
+				tracker.objectAttributes = tracker.objectAttributes.without(dataBlockValue.name)
+			}
+			tracker.currentValid = false
+
+		case *ApertureDefinitionParameter:
+			tracker.definedApertures[dataBlockValue.apertureNumber] = tracker.apertureAttributes
+			if dataBlockValue.apertureNumber == tracker.currentAperture {
+				tracker.currentValid = false
+			}
+
+		case *SetCurrentAperture:
+			tracker.currentAperture = dataBlockValue.apertureNumber
+			tracker.currentValid = false
+
+		case *GraphicsStateChange:
+			switch dataBlockValue.fnCode {
+				case REGION_MODE_ON:
+					tracker.regionModeOn = true
+					tracker.currentValid = false
+
+				case REGION_MODE_OFF:
+					tracker.regionModeOn = false
+					tracker.currentValid = false
+			}
+
+		case *Interpolation:
+			if dataBlockValue.opCodeValid && (dataBlockValue.opCode == INTERPOLATE_OPERATION || dataBlockValue.opCode == FLASH_OPERATION) {
+				dataBlockValue.attributes = tracker.currentAttributes()
+			}
+	}
+}
+
+// The attributes of the aperture (or the aperture dictionary, for regions), together with the object attributes
+func (tracker *attributeTracker) currentAttributes() Attributes {
+	if tracker.currentValid {
+		return tracker.current
+	}
+
+	apertureAttributes := tracker.definedApertures[tracker.currentAperture]
+	if tracker.regionModeOn {
+		apertureAttributes = tracker.apertureAttributes
+	}
+	if len(apertureAttributes) == 0 {
+		tracker.current = tracker.objectAttributes
+	} else if len(tracker.objectAttributes) == 0 {
+		tracker.current = apertureAttributes
+	} else {
+		tracker.current = make(Attributes, len(apertureAttributes) + len(tracker.objectAttributes))
+		for name,values := range apertureAttributes {
+			tracker.current[name] = values
+		}
+		for name,values := range tracker.objectAttributes {
+			tracker.current[name] = values
+		}
+	}
+	tracker.currentValid = true
+
+	return tracker.current
+}
+
+// Splits the arguments of a TF, TA or TO parameter into the attribute name and its values
+func parseAttribute(paramCode string, restOfParameter string) (string, []string, error) {
+	fields := strings.Split(restOfParameter, ",")
+	if !attributeNameRegex.MatchString(fields[0]) {
+		return "",nil,fmt.Errorf("Invalid attribute name \"%s\" in %s parameter", fields[0], paramCode)
+	}
+
+	return fields[0],fields[1:],nil
+}
+
+// Writes the attribute back out as the arguments of a TF, TA or TO parameter
+func formatAttribute(name string, values []string) string {
+	if len(values) == 0 {
+		return name
+	}
+
+	return name + "," + strings.Join(values, ",")
+}
@@ -0,0 +1,69 @@
+package gerber_rs274x
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const attributesFile = `%FSLAX24Y24*%
+%MOIN*%
+%TF.FileFunction,Copper,L2,Inr*%
+%TA.AperFunction,SMDPad,CuDef*%
+%ADD10R,0.1X0.1*%
+%TD.AperFunction*%
+%ADD11C,0.01*%
+D10*
+%TO.N,GND*%
+X0Y0D03*
+D11*
+X10000Y0D01*
+%TD.N*%
+X20000Y0D01*
+D10*
+X0Y10000D03*
+M02*`
+
+func TestAttributeTracking(t *testing.T) {
+	parseResult,err := ParseGerberFileWithOptions(strings.NewReader(attributesFile), ParseOptions{Strict: true})
+	if err != nil {
+		t.Fatalf("Error parsing attributes: %v", err)
+	}
+
+	var got []Attributes
+	for _,dataBlock := range parseResult.DataBlocks {
+		if interpolation,isInterpolation := dataBlock.(*Interpolation); isInterpolation && interpolation.opCodeValid && (interpolation.opCode != MOVE_OPERATION) {
+			got = append(got, interpolation.GetAttributes())
+		}
+	}
+
+	aperFunction := []string{"SMDPad", "CuDef"}
+	net := []string{"GND"}
+	expected := []Attributes{
+		// The TA came before D10 was defined, so it's captured by D10, and the TO adds the net
+		{".AperFunction": aperFunction, ".N": net},
+		// D11 was defined after the TD, so it doesn't have an aperture function, but the net is still there
+		{".N": net},
+		// The TD removes the net from everything after it
+		nil,
+		// D10 still has the aperture function it was defined with
+		{".AperFunction": aperFunction},
+	}
+
+	if len(got) != len(expected) {
+		t.Fatalf("Got %d draws and flashes, expected %d", len(got), len(expected))
+	}
+
+	for index := range got {
+		if (len(got[index]) != 0 || len(expected[index]) != 0) && !reflect.DeepEqual(got[index], expected[index]) {
+			t.Errorf("Draw or flash %d has attributes %v, expected %v", index, got[index], expected[index])
+		}
+	}
+
+	if parseResult.FileAttributes == nil {
+		t.Fatalf("File attributes are missing from the parse result")
+	}
+	if values := parseResult.FileAttributes.Raw[".FileFunction"]; !reflect.DeepEqual(values, []string{"Copper", "L2", "Inr"}) {
+		t.Errorf(".FileFunction file attribute is %v, expected [Copper L2 Inr]", values)
+	}
+}
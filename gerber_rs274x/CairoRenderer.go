@@ -0,0 +1,454 @@
+//go:build cairo
+
+package gerber_rs274x
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	cairo "github.com/ungerik/go-cairo"
+)
+
+// Draws onto a cairo surface.  The surface is expected to already be transformed so that it can be drawn on
+// in the units of the gerber file, scaled by scaleFactor
+type cairoRenderer struct {
+	surface *cairo.Surface
+	scaleFactor float64
+	polarity Polarity
+	// What dark and clear polarity draw in
+	darkColor color.Color
+	clearColor color.Color
+	// The first time an aperture is flashed, we render it to its own cairo surface
+	// Then, we can just look up the rendered aperture the next time we need it
+	// This should provide for some optimization, since the same aperture will
+	// get used over and over to stroke a path
+	renderedApertures map[int]*renderedAperture
+	// We also need to save apertures rendered without their holes (if they have holes), for use in certain
+	// optimized stroke drawing routines.  Apertures without holes are only stored in the renderedApertures map
+	renderedAperturesNoHoles map[int]*renderedAperture
+	// When set, apertures are drawn straight onto the surface as paths every time they're flashed, and draws with
+	// circular apertures are stroked, instead of going through the rendered aperture cache.  This keeps everything
+	// as vectors on surfaces that support them (like PDF)
+	vectorApertures bool
+}
+
+type renderedAperture struct {
+	surface *cairo.Surface
+	// The offset from the center of the aperture to the corner of the surface
+	xMin float64
+	yMin float64
+}
+
+func newCairoRenderer(surface *cairo.Surface, scaleFactor float64) *cairoRenderer {
+	renderer := new(cairoRenderer)
+
+	renderer.surface = surface
+	renderer.scaleFactor = scaleFactor
+	renderer.polarity = DARK_POLARITY
+	renderer.darkColor = color.Black
+	renderer.clearColor = color.White
+	renderer.renderedApertures = make(map[int]*renderedAperture, 10) // Start with an initial capacity of 10 apertures, will grow as needed
+	renderer.renderedAperturesNoHoles = make(map[int]*renderedAperture, 10) // Same as above
+
+	return renderer
+}
+
+func (renderer *cairoRenderer) MoveTo(x float64, y float64) {
+	renderer.surface.MoveTo(x, y)
+}
+
+func (renderer *cairoRenderer) LineTo(x float64, y float64) {
+	renderer.surface.LineTo(x, y)
+}
+
+func (renderer *cairoRenderer) Arc(centerX float64, centerY float64, radius float64, startAngle float64, endAngle float64) {
+	// NOTE: The conversion to the cairo coordinate frame is inherent in the y-axis mirror transformation of the surface,
+	// so a counter-clockwise arc in the gerber file coordinate frame is a positive arc in cairo
+	renderer.surface.Arc(centerX, centerY, radius, startAngle, endAngle)
+}
+
+func (renderer *cairoRenderer) ArcNegative(centerX float64, centerY float64, radius float64, startAngle float64, endAngle float64) {
+	renderer.surface.ArcNegative(centerX, centerY, radius, startAngle, endAngle)
+}
+
+func (renderer *cairoRenderer) ClosePath() {
+	renderer.surface.ClosePath()
+}
+
+func (renderer *cairoRenderer) Fill() {
+	renderer.setSourceFromPolarity()
+	renderer.surface.Fill()
+}
+
+func (renderer *cairoRenderer) SetPolarity(polarity Polarity) {
+	renderer.polarity = polarity
+}
+
+func (renderer *cairoRenderer) Flash(aperture Aperture, gfxState *GraphicsState, x float64, y float64) error {
+	if renderer.vectorApertures {
+		return renderer.flashVectorAperture(aperture, gfxState, x, y, true)
+	}
+
+	return renderer.flashRenderedAperture(renderer.renderedApertures, aperture, gfxState, x, y, true)
+}
+
+func (renderer *cairoRenderer) FlashNoHole(aperture Aperture, gfxState *GraphicsState, x float64, y float64) error {
+	if aperture.GetHole() == nil {
+		// Without a hole, both versions of the aperture are the same, so we don't need to render it twice
+		return renderer.Flash(aperture, gfxState, x, y)
+	}
+
+	if renderer.vectorApertures {
+		return renderer.flashVectorAperture(aperture, gfxState, x, y, false)
+	}
+
+	return renderer.flashRenderedAperture(renderer.renderedAperturesNoHoles, aperture, gfxState, x, y, false)
+}
+
+func (renderer *cairoRenderer) PushTransform(xOffset float64, yOffset float64, rotation float64) {
+	renderer.surface.Save()
+	renderer.surface.Translate(xOffset, yOffset)
+	renderer.surface.Rotate(rotation)
+}
+
+func (renderer *cairoRenderer) PopTransform() {
+	renderer.surface.Restore()
+}
+
+func (renderer *cairoRenderer) StrokeLinear(aperture Aperture, gfxState *GraphicsState, startX float64, startY float64, endX float64, endY float64) (bool, error) {
+	diameter,ok := renderer.strokeDiameter(aperture)
+	if !ok {
+		return false,nil
+	}
+
+	renderer.surface.MoveTo(startX, startY)
+	renderer.surface.LineTo(endX, endY)
+	renderer.strokePath(diameter)
+
+	return true,nil
+}
+
+func (renderer *cairoRenderer) StrokeArc(aperture Aperture, gfxState *GraphicsState, centerX float64, centerY float64, radius float64, startAngle float64, endAngle float64, clockwise bool) (bool, error) {
+	diameter,ok := renderer.strokeDiameter(aperture)
+	if !ok {
+		return false,nil
+	}
+
+	renderer.surface.NewPath()
+	if clockwise {
+		renderer.surface.ArcNegative(centerX, centerY, radius, startAngle, endAngle)
+	} else {
+		renderer.surface.Arc(centerX, centerY, radius, startAngle, endAngle)
+	}
+	renderer.strokePath(diameter)
+
+	return true,nil
+}
+
+// Only solid circular apertures can be drawn as a stroke with round caps, everything else
+// is swept out by the aperture itself
+func (renderer *cairoRenderer) strokeDiameter(aperture Aperture) (float64, bool) {
+	if !renderer.vectorApertures {
+		return 0.0,false
+	}
+
+	if circle,isCircle := aperture.(*CircleAperture); isCircle && circle.GetHole() == nil {
+		return circle.diameter,true
+	}
+
+	return 0.0,false
+}
+
+func (renderer *cairoRenderer) strokePath(lineWidth float64) {
+	renderer.surface.Save()
+	renderer.setSourceFromPolarity()
+	renderer.surface.SetLineWidth(lineWidth)
+	renderer.surface.SetLineCap(cairo.LINE_CAP_ROUND)
+	renderer.surface.SetLineJoin(cairo.LINE_JOIN_ROUND)
+	renderer.surface.Stroke()
+	renderer.surface.Restore()
+}
+
+func (renderer *cairoRenderer) flashVectorAperture(aperture Aperture, gfxState *GraphicsState, x float64, y float64, withHole bool) error {
+	renderer.PushTransform(x, y, 0.0)
+	defer renderer.PopTransform()
+
+	return aperture.RenderApertureShape(renderer, gfxState, withHole)
+}
+
+func (renderer *cairoRenderer) setSourceFromPolarity() {
+	switch renderer.polarity {
+		case DARK_POLARITY:
+			renderer.surface.SetOperator(cairo.OPERATOR_OVER)
+			renderer.surface.SetSourceRGBA(colorComponents(renderer.darkColor))
+
+		case CLEAR_POLARITY:
+			// Clear polarity has to replace whatever is underneath it, even when the clear color is (partly) transparent.
+			// When it's opaque, drawing over the top does the same thing, and is better supported by vector surfaces
+			r,g,b,a := colorComponents(renderer.clearColor)
+			if a < 1.0 {
+				renderer.surface.SetOperator(cairo.OPERATOR_SOURCE)
+			} else {
+				renderer.surface.SetOperator(cairo.OPERATOR_OVER)
+			}
+			renderer.surface.SetSourceRGBA(r, g, b, a)
+	}
+}
+
+func (renderer *cairoRenderer) flashRenderedAperture(apertureTable map[int]*renderedAperture, aperture Aperture, gfxState *GraphicsState, x float64, y float64, withHole bool) error {
+	// Try to get the rendered aperture from the cache.  If it isn't in the cache,
+	// we need to actually render it, and put it in the cache for future use
+	rendered,found := apertureTable[aperture.GetApertureNumber()]
+	if !found {
+		var err error
+		if rendered,err = renderer.renderAperture(aperture, gfxState, withHole); err != nil {
+			return err
+		}
+		apertureTable[aperture.GetApertureNumber()] = rendered
+	}
+
+	renderer.setSourceFromPolarity()
+
+	// The aperture surfaces are already scaled, so remove the scaling while we draw them, so it isn't applied twice
+	// (which means we need to manually scale the coordinates here)
+	renderer.surface.Save()
+	renderer.surface.Scale(1.0 / renderer.scaleFactor, 1.0 / renderer.scaleFactor)
+	renderer.surface.MaskSurface(rendered.surface, (x + rendered.xMin) * renderer.scaleFactor, (y + rendered.yMin) * renderer.scaleFactor)
+	renderer.surface.Restore()
+
+	return nil
+}
+
+func (renderer *cairoRenderer) renderAperture(aperture Aperture, gfxState *GraphicsState, withHole bool) (*renderedAperture, error) {
+	// The size of the surface comes from the bounds of the aperture when it's flashed at the origin
+	bounds := newImageBounds()
+	if err := aperture.DrawApertureBoundsCheck(bounds, gfxState, 0.0, 0.0); err != nil {
+		return nil,err
+	}
+
+	// Construct the surface we're drawing to
+	imageWidth := int(math.Ceil((bounds.xMax - bounds.xMin) * renderer.scaleFactor))
+	imageHeight := int(math.Ceil((bounds.yMax - bounds.yMin) * renderer.scaleFactor))
+	surface := cairo.NewSurface(cairo.FORMAT_ARGB32, imageWidth, imageHeight)
+	surface.SetAntialias(cairo.ANTIALIAS_DEFAULT)
+	// Set fill rule to Even/Odd so that holes and rings render correctly
+	surface.SetFillRule(cairo.FILL_RULE_EVEN_ODD)
+	// Scale the surface so we can use unscaled coordinates while rendering the aperture
+	surface.Scale(renderer.scaleFactor, renderer.scaleFactor)
+	// Translate the surface so that the origin is actually the center of the aperture
+	surface.Translate(-bounds.xMin, -bounds.yMin)
+
+	// Only the shape matters when the aperture is flashed, so the aperture is always drawn dark
+	apertureRenderer := newCairoRenderer(surface, renderer.scaleFactor)
+	if err := aperture.RenderApertureShape(apertureRenderer, gfxState, withHole); err != nil {
+		surface.Finish()
+		surface.Destroy()
+		return nil,err
+	}
+
+	if withHole {
+		gfxState.writeApertureDebugImage(aperture.GetApertureNumber(), surface)
+	}
+
+	rendered := new(renderedAperture)
+	rendered.surface = surface
+	rendered.xMin = bounds.xMin
+	rendered.yMin = bounds.yMin
+
+	return rendered,nil
+}
+
+func (renderer *cairoRenderer) releaseRenderedApertures() {
+	for _,rendered := range renderer.renderedApertures {
+		rendered.surface.Finish()
+		rendered.surface.Destroy()
+	}
+
+	for _,rendered := range renderer.renderedAperturesNoHoles {
+		rendered.surface.Finish()
+		rendered.surface.Destroy()
+	}
+}
+
+// Renders the parsed file to a PNG image
+func GenerateSurface(outFileName string, parsedFile []DataBlock) error {
+	return GenerateSurfaceWithOptions(outFileName, parsedFile, RenderOptions{})
+}
+
+// Renders the parsed file to an image file, in the format given by the options
+func GenerateSurfaceWithOptions(outFileName string, parsedFile []DataBlock, options RenderOptions) error {
+	img,gfxState,err := renderImage(parsedFile, options)
+	if err != nil {
+		return err
+	}
+
+	outFile,err := os.Create(outFileName)
+	if err != nil {
+		return fmt.Errorf("Unable to write rendered image to %s: %w", outFileName, err)
+	}
+
+	if err := encodeImage(outFile, img, options.Format); err != nil {
+		outFile.Close()
+		return fmt.Errorf("Unable to write rendered image to %s: %w", outFileName, err)
+	}
+
+	if err := outFile.Close(); err != nil {
+		return fmt.Errorf("Unable to write rendered image to %s: %w", outFileName, err)
+	}
+
+	return checkFileComplete(gfxState)
+}
+
+// Renders the parsed file to a raster image, sized and colored according to the options
+func RenderImage(parsedFile []DataBlock, options RenderOptions) (image.Image, error) {
+	return RenderRGBA(parsedFile, options)
+}
+
+// The same as RenderImage, but returns the concrete image type, so the pixels can be used directly
+func RenderRGBA(parsedFile []DataBlock, options RenderOptions) (*image.RGBA, error) {
+	img,gfxState,err := renderImage(parsedFile, options)
+	if err != nil {
+		return nil,err
+	}
+
+	if err := checkFileComplete(gfxState); err != nil {
+		return nil,err
+	}
+
+	return img,nil
+}
+
+// Renders the parsed file and writes the image to w, in the format given by the options (PNG by default).
+// Nothing is written if the render fails
+func WriteImage(w io.Writer, parsedFile []DataBlock, options RenderOptions) error {
+	img,err := RenderRGBA(parsedFile, options)
+	if err != nil {
+		return err
+	}
+
+	return encodeImage(w, img, options.Format)
+}
+
+// Renders the parsed file and writes the raw pixels to w, ignoring the format in the options.  The pixels are written
+// row by row from the top of the image, 4 bytes per pixel (red, green, blue and alpha, with the colors premultiplied
+// by alpha, the same as image.RGBA), with no padding between rows.  Returns the width and height of the image.
+// Nothing is written if the render fails
+func WriteRawPixels(w io.Writer, parsedFile []DataBlock, options RenderOptions) (int, int, error) {
+	img,err := RenderRGBA(parsedFile, options)
+	if err != nil {
+		return 0,0,err
+	}
+
+	width := img.Rect.Dx()
+	height := img.Rect.Dy()
+	for y := 0; y < height; y++ {
+		if _,err := w.Write(img.Pix[y * img.Stride:(y * img.Stride) + (4 * width)]); err != nil {
+			return width,height,err
+		}
+	}
+
+	return width,height,nil
+}
+
+func renderImage(parsedFile []DataBlock, options RenderOptions) (*image.RGBA, *GraphicsState, error) {
+	// First, need to do a full render of the file, just keeping track of the bounds
+	// of the generated image, so we can do the proper scaling when we render it for real
+	bounds,units,err := computeImageBounds(parsedFile, options)
+	if err != nil {
+		return nil,nil,err
+	}
+
+	width,height,scaling,err := options.rasterLayout(bounds, units)
+	if err != nil {
+		return nil,nil,err
+	}
+
+	// Set up the graphics state for the actual drawing
+	gfxState := newGraphicsState()
+	gfxState.setRenderOptions(options)
+
+	// Construct the surface we're drawing to
+	surface := cairo.NewSurface(cairo.FORMAT_ARGB32, width, height)
+	defer surface.Destroy()
+	if options.Antialias {
+		surface.SetAntialias(cairo.ANTIALIAS_DEFAULT)
+	} else {
+		surface.SetAntialias(cairo.ANTIALIAS_NONE)
+	}
+
+	// The surface starts out transparent, so we only need to paint the background if it's been given
+	if options.Background != nil {
+		surface.SetOperator(cairo.OPERATOR_SOURCE)
+		surface.SetSourceRGBA(colorComponents(options.Background))
+		surface.Paint()
+		surface.SetOperator(cairo.OPERATOR_OVER)
+	}
+
+	// This is important for regions with cut-ins.  If we leave the fill rule the default (winding),
+	// cut-ins don't render correctly
+	surface.SetFillRule(cairo.FILL_RULE_EVEN_ODD)
+	// Invert the Y-axis.  This is to correct for the difference in coordinate frames between the gerber file and cairo
+	surface.Scale(1.0, -1.0)
+	surface.Translate(0.0, float64(-height))
+	// Apply the x and y offsets as translations to the surface
+	surface.Translate(scaling.xOffset, scaling.yOffset)
+	// Finally, scale the surface so that we can draw in the units of the file
+	surface.Scale(scaling.scaleFactor, scaling.scaleFactor)
+
+	renderer := newCairoRenderer(surface, scaling.scaleFactor)
+	renderer.darkColor = options.foreground()
+	renderer.clearColor = options.background()
+	err = renderDataBlocks(renderer, parsedFile, gfxState)
+	renderer.releaseRenderedApertures()
+	if err != nil {
+		surface.Finish()
+		return nil,nil,err
+	}
+
+	surface.Flush()
+	img := imageFromSurface(surface)
+	surface.Finish()
+
+	return img,gfxState,nil
+}
+
+// Copies the pixels out of an ARGB32 cairo surface.  Cairo stores each pixel as a premultiplied 32 bit value
+// in native byte order, which is B, G, R, A in memory on little endian machines
+func imageFromSurface(surface *cairo.Surface) *image.RGBA {
+	width := surface.GetWidth()
+	height := surface.GetHeight()
+	stride := surface.GetStride()
+	data := surface.GetData()
+
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		row := data[y * stride:]
+		pixels := img.Pix[y * img.Stride:]
+		for x := 0; x < width; x++ {
+			pixels[(4 * x) + 0] = row[(4 * x) + 2]
+			pixels[(4 * x) + 1] = row[(4 * x) + 1]
+			pixels[(4 * x) + 2] = row[(4 * x) + 0]
+			pixels[(4 * x) + 3] = row[(4 * x) + 3]
+		}
+	}
+
+	return img
+}
+
+// Writes out a rendered aperture for debugging, if the caller asked for it
+func (gfxState *GraphicsState) writeApertureDebugImage(apertureNumber int, surface *cairo.Surface) {
+	if gfxState.apertureDebugDir == "" {
+		return
+	}
+
+	fileName := filepath.Join(gfxState.apertureDebugDir, fmt.Sprintf("Aperture-%d.png", apertureNumber))
+	if status := surface.WriteToPNG(fileName); status != cairo.STATUS_SUCCESS {
+		gfxState.logger.Warn("Unable to write aperture debug image", "aperture", apertureNumber, "file", fileName, "status", status)
+	} else {
+		gfxState.logger.Debug("Wrote aperture debug image", "aperture", apertureNumber, "file", fileName)
+	}
+}
@@ -2,7 +2,6 @@ package gerber_rs274x
 
 import (
 	"fmt"
-	cairo "github.com/ungerik/go-cairo"
 )
 
 type CenterLinePrimitive struct {
@@ -23,15 +22,29 @@ func (primitive *CenterLinePrimitive) ApertureMacroDataBlockPlaceholder() {
 }
 
 func (primitive *CenterLinePrimitive) GetPrimitiveBounds(env *ExpressionEnvironment) (xMin float64, xMax float64, yMin float64, yMax float64) {
-	//TODO: Implement
-	return 0.0,0.0,0.0,0.0
+	xs,ys,rotation := primitive.outline(env)
+	return primitiveOutlineBounds(xs, ys, rotation)
 }
 
-func (primitive *CenterLinePrimitive) DrawPrimitiveToSurface(surface *cairo.Surface, env *ExpressionEnvironment) error {
-	//TODO: Implement
+func (primitive *CenterLinePrimitive) DrawPrimitive(renderer Renderer, env *ExpressionEnvironment) error {
+	xs,ys,rotation := primitive.outline(env)
+	fillPrimitiveOutline(renderer, xs, ys, rotation)
 	return nil
 }
 
+// The corners of the rectangle, before it is rotated
+func (primitive *CenterLinePrimitive) outline(env *ExpressionEnvironment) ([]float64, []float64, float64) {
+	halfWidth := primitive.width.EvaluateExpression(env) / 2.0
+	halfHeight := primitive.height.EvaluateExpression(env) / 2.0
+	centerX := primitive.centerX.EvaluateExpression(env)
+	centerY := primitive.centerY.EvaluateExpression(env)
+
+	xs := []float64{centerX - halfWidth, centerX + halfWidth, centerX + halfWidth, centerX - halfWidth}
+	ys := []float64{centerY - halfHeight, centerY - halfHeight, centerY + halfHeight, centerY + halfHeight}
+
+	return xs,ys,primitive.rotationAngle.EvaluateExpression(env)
+}
+
 func (primitive *CenterLinePrimitive) String() string {
 	return fmt.Sprintf("{Center Line, Exposure %v, Width %v, Height %v, Center (%v %v), Rotation %v}",
 						primitive.exposure,
@@ -40,4 +53,28 @@ func (primitive *CenterLinePrimitive) String() string {
 						primitive.centerX,
 						primitive.centerY,
 						primitive.rotationAngle)
-}
\ No newline at end of file
+}
+
+func (primitive *CenterLinePrimitive) GetExposure() ApertureMacroExpression {
+	return primitive.exposure
+}
+
+func (primitive *CenterLinePrimitive) GetWidth() ApertureMacroExpression {
+	return primitive.width
+}
+
+func (primitive *CenterLinePrimitive) GetHeight() ApertureMacroExpression {
+	return primitive.height
+}
+
+func (primitive *CenterLinePrimitive) GetCenterX() ApertureMacroExpression {
+	return primitive.centerX
+}
+
+func (primitive *CenterLinePrimitive) GetCenterY() ApertureMacroExpression {
+	return primitive.centerY
+}
+
+func (primitive *CenterLinePrimitive) GetRotationAngle() ApertureMacroExpression {
+	return primitive.rotationAngle
+}
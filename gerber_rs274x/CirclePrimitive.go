@@ -2,7 +2,6 @@ package gerber_rs274x
 
 import (
 	"fmt"
-	cairo "github.com/ungerik/go-cairo"
 )
 
 type CirclePrimitive struct {
@@ -28,11 +27,35 @@ func (primitive *CirclePrimitive) GetPrimitiveBounds(env *ExpressionEnvironment)
 	return centerX - radius,centerX + radius,centerY - radius,centerY + radius
 }
 
-func (primitive *CirclePrimitive) DrawPrimitiveToSurface(surface *cairo.Surface, env *ExpressionEnvironment) error {
-	//TODO: Implement
+func (primitive *CirclePrimitive) DrawPrimitive(renderer Renderer, env *ExpressionEnvironment) error {
+	centerX := primitive.centerX.EvaluateExpression(env)
+	centerY := primitive.centerY.EvaluateExpression(env)
+	radius := primitive.diameter.EvaluateExpression(env) / 2.0
+
+	renderer.MoveTo(centerX + radius, centerY)
+	renderer.Arc(centerX, centerY, radius, 0.0, TWO_PI)
+	renderer.ClosePath()
+	renderer.Fill()
+
 	return nil
 }
 
 func (primitive *CirclePrimitive) String() string {
 	return fmt.Sprintf("{Circle, Exposure %v, Diameter %v, Center (%v %v)}", primitive.exposure, primitive.diameter, primitive.centerX, primitive.centerY)
-}
\ No newline at end of file
+}
+
+func (primitive *CirclePrimitive) GetExposure() ApertureMacroExpression {
+	return primitive.exposure
+}
+
+func (primitive *CirclePrimitive) GetDiameter() ApertureMacroExpression {
+	return primitive.diameter
+}
+
+func (primitive *CirclePrimitive) GetCenterX() ApertureMacroExpression {
+	return primitive.centerX
+}
+
+func (primitive *CirclePrimitive) GetCenterY() ApertureMacroExpression {
+	return primitive.centerY
+}
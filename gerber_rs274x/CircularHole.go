@@ -2,7 +2,6 @@ package gerber_rs274x
 
 import (
 	"fmt"
-	cairo "github.com/ungerik/go-cairo"
 )
 
 type CircularHole struct {
@@ -17,18 +16,17 @@ func (hole *CircularHole) String() string {
 	return fmt.Sprintf("{CH, Diameter: %f}", hole.holeDiameter)
 }
 
-func (hole *CircularHole) DrawHoleSurface(surface *cairo.Surface) error {
+func (hole *CircularHole) DrawHole(renderer Renderer) error {
 	
 	radius := (hole.holeDiameter / 2.0)
 	
-	surface.Save()
-	
-	// We temporarily set the compositing operator to clear, to clear the hole to transparent
-	surface.SetOperator(cairo.OPERATOR_CLEAR)
-	surface.Arc(0.0, 0.0, radius, 0, TWO_PI)
-	surface.Fill()
-	
-	surface.Restore()
+	renderer.MoveTo(radius, 0.0)
+	renderer.Arc(0.0, 0.0, radius, 0, TWO_PI)
+	renderer.ClosePath()
 	
 	return nil
-}
\ No newline at end of file
+}
+
+func (hole *CircularHole) GetDiameter() float64 {
+	return hole.holeDiameter
+}
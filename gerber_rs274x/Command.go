@@ -1,12 +1,20 @@
 package gerber_rs274x
 
+// Identifies which parameter (the two letter code between "%" characters) a parameter data block came from
 type ParameterCode int
+// The standard aperture templates (C, R, O and P), or a macro aperture
 type ApertureType int
+// The G and M codes.  Interpolations carry their function code (if they had one), and the rest are GraphicsStateChange blocks
 type FunctionCode int
+// The D01, D02 and D03 codes of an interpolation
 type OperationCode int
+// Whether objects darken (add to) or clear (remove from) the image, as set by the LP parameter
 type Polarity int
+// Which zeros are left off of coordinate data, as set by the FS parameter
 type ZeroOmissionMode int
+// Whether coordinates are absolute, or relative to the previous coordinate, as set by the FS parameter
 type CoordinateNotation int
+// The units of coordinates and aperture sizes, as set by the MO parameter
 type Units int
 
 const (
@@ -16,6 +24,10 @@ const (
 	AM_PARAMETER
 	SR_PARAMETER
 	LP_PARAMETER
+	TF_PARAMETER // File attribute (Gerber X2)
+	TA_PARAMETER // Aperture attribute (Gerber X2)
+	TO_PARAMETER // Object attribute (Gerber X2)
+	TD_PARAMETER // Attribute delete (Gerber X2)
 	IN_PARAMETER // NOTE: Deprecated
 	AS_PARAMETER // NOTE: Deprecated
 	LN_PARAMETER // NOTE: Deprecated
@@ -27,57 +39,57 @@ const (
 )
 
 const (
-	INTERPOLATE_OPERATION OperationCode = iota
-	MOVE_OPERATION
-	FLASH_OPERATION
+	INTERPOLATE_OPERATION OperationCode = iota // D01: Draw from the current point to the new coordinate
+	MOVE_OPERATION // D02: Move to the new coordinate without drawing
+	FLASH_OPERATION // D03: Flash the current aperture at the new coordinate
 )
 
 const (
-	LINEAR_INTERPOLATION FunctionCode = iota
-	CIRCULAR_INTERPOLATION_CLOCKWISE
-	CIRCULAR_INTERPOLATION_COUNTER_CLOCKWISE
-	IGNORE_DATA_BLOCK
-	REGION_MODE_ON
-	REGION_MODE_OFF
-	SINGLE_QUADRANT_MODE
-	MULTI_QUADRANT_MODE
-	END_OF_FILE
-	SELECT_APERTURE	// NOTE: Deprecated
-	SET_UNIT_INCH // NOTE: Deprecated
-	SET_UNIT_MM // NOTE: Deprecated
-	SET_NOTATION_INCREMENTAL // NOTE: Deprecated
-	SET_NOTATION_ABSOLUTE // NOTE: Deprecated
-	OPTIONAL_STOP // NOTE: Deprecated
-	PROGRAM_STOP // NOTE: Deprecated
-	PREPARE_FOR_FLASH // NOTE: Deprecated
+	LINEAR_INTERPOLATION FunctionCode = iota // G01
+	CIRCULAR_INTERPOLATION_CLOCKWISE // G02
+	CIRCULAR_INTERPOLATION_COUNTER_CLOCKWISE // G03
+	IGNORE_DATA_BLOCK // G04: Comments are parsed into IgnoreDataBlock
+	REGION_MODE_ON // G36
+	REGION_MODE_OFF // G37
+	SINGLE_QUADRANT_MODE // G74
+	MULTI_QUADRANT_MODE // G75
+	END_OF_FILE // M02
+	SELECT_APERTURE	// G54 NOTE: Deprecated
+	SET_UNIT_INCH // G70 NOTE: Deprecated
+	SET_UNIT_MM // G71 NOTE: Deprecated
+	SET_NOTATION_INCREMENTAL // G91 NOTE: Deprecated
+	SET_NOTATION_ABSOLUTE // G90 NOTE: Deprecated
+	OPTIONAL_STOP // M01 NOTE: Deprecated
+	PROGRAM_STOP // M00 NOTE: Deprecated
+	PREPARE_FOR_FLASH // G55 NOTE: Deprecated
 )
 
 const (
-	OMIT_LEADING_ZEROS ZeroOmissionMode = iota
-	OMIT_TRAILING_ZEROS
+	OMIT_LEADING_ZEROS ZeroOmissionMode = iota // L
+	OMIT_TRAILING_ZEROS // T NOTE: Deprecated
 )
 
 const (
-	ABSOLUTE_NOTATION CoordinateNotation = iota
-	INCREMENTAL_NOTATION
+	ABSOLUTE_NOTATION CoordinateNotation = iota // A
+	INCREMENTAL_NOTATION // I NOTE: Deprecated
 )
 
 const (
-	UNITS_IN Units = iota
-	UNITS_MM
+	UNITS_IN Units = iota // Inches
+	UNITS_MM // Millimeters
 )
 
 const (
-	CIRCLE_APERTURE ApertureType = iota
-	RECTANGLE_APERTURE
-	OBROUND_APERTURE
-	POLYGON_APERTURE
-	MACRO_APERTURE
+	CIRCLE_APERTURE ApertureType = iota // C: *CircleAperture
+	RECTANGLE_APERTURE // R: *RectangleAperture
+	OBROUND_APERTURE // O: *ObroundAperture
+	POLYGON_APERTURE // P: *PolygonAperture
+	MACRO_APERTURE // Any other name: *MacroAperture
 )
 
 const (
-	CLEAR_POLARITY Polarity = iota
-	DARK_POLARITY
+	CLEAR_POLARITY Polarity = iota // C
+	DARK_POLARITY // D
 )
 
 type Command struct {
@@ -0,0 +1,167 @@
+package gerber_rs274x
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// The most integer or decimal digits a coordinate format can have
+const MAX_COORDINATE_DIGITS = 7
+
+// The way coordinate numbers are written in a file, as given by its FS parameter.  A coordinate format decodes the
+// numbers in coordinate data into values in the file's units, and encodes values back into numbers the same way
+type CoordinateFormat struct {
+	numDigits int
+	numDecimals int
+	zeroOmissionMode ZeroOmissionMode
+	isSet bool
+}
+
+// Makes a coordinate format with the given number of integer digits (between 1 and 7) and decimal digits (between 0 and 7)
+func NewCoordinateFormat(numDigits int, numDecimals int, zeroOmissionMode ZeroOmissionMode) (*CoordinateFormat, error) {
+	if (numDigits < 1) || (numDigits > MAX_COORDINATE_DIGITS) {
+		return nil,fmt.Errorf("Coordinate format number of integer digits must be between 1 and %d.  Received %d", MAX_COORDINATE_DIGITS, numDigits)
+	}
+	if (numDecimals < 0) || (numDecimals > MAX_COORDINATE_DIGITS) {
+		return nil,fmt.Errorf("Coordinate format number of decimal digits must be between 0 and %d.  Received %d", MAX_COORDINATE_DIGITS, numDecimals)
+	}
+
+	switch zeroOmissionMode {
+		case OMIT_LEADING_ZEROS, OMIT_TRAILING_ZEROS:
+
+		default:
+			return nil,fmt.Errorf("Unknown zero omission mode %d", zeroOmissionMode)
+	}
+
+	coordFormat := new(CoordinateFormat)
+	coordFormat.numDigits = numDigits
+	coordFormat.numDecimals = numDecimals
+	coordFormat.zeroOmissionMode = zeroOmissionMode
+	coordFormat.isSet = true
+
+	return coordFormat,nil
+}
+
+// Decodes a number from coordinate data (e.g. the "-12" in "X-12Y300D01") into a value in the file's units.
+// Numbers are usually just digits, with the decimal point implied by the format, but numbers written with
+// an explicit decimal point are taken as written.  Numbers with more digits than the format allows are an error
+func (coordFormat *CoordinateFormat) Decode(coordinateData string) (float64, error) {
+	// The sign isn't one of the digits, so it has to come off before working out which zeros were omitted
+	digits := coordinateData
+	negative := false
+	if strings.HasPrefix(digits, "-") || strings.HasPrefix(digits, "+") {
+		negative = (digits[0] == '-')
+		digits = digits[1:]
+	}
+
+	if strings.Contains(digits, ".") {
+		return coordFormat.decodeExplicitDecimal(coordinateData, digits)
+	}
+
+	if len(digits) == 0 {
+		return 0.0,fmt.Errorf("Coordinate number \"%s\" has no digits", coordinateData)
+	}
+	if strings.IndexFunc(digits, isNotDigit) >= 0 {
+		return 0.0,fmt.Errorf("Invalid coordinate number \"%s\"", coordinateData)
+	}
+
+	totalDigits := coordFormat.numDigits + coordFormat.numDecimals
+	if len(digits) > totalDigits {
+		return 0.0,fmt.Errorf("Coordinate number \"%s\" has more digits than the coordinate format allows (%d integer and %d decimal digits)", coordinateData, coordFormat.numDigits, coordFormat.numDecimals)
+	}
+
+	if coordFormat.zeroOmissionMode == OMIT_TRAILING_ZEROS {
+		// The digits line up from the left, so we put the missing zeros back on the right
+		digits += strings.Repeat("0", totalDigits - len(digits))
+	}
+
+	// With at most 14 digits, the number is exact as an integer, so the only rounding is in the one division
+	if scaled,err := strconv.ParseInt(digits, 10, 64); err != nil {
+		return 0.0,err
+	} else {
+		value := float64(scaled) / math.Pow10(coordFormat.numDecimals)
+		if negative {
+			value = -value
+		}
+
+		return value,nil
+	}
+}
+
+func (coordFormat *CoordinateFormat) decodeExplicitDecimal(coordinateData string, digits string) (float64, error) {
+	intDigits,decDigits,_ := strings.Cut(digits, ".")
+	if (len(intDigits) + len(decDigits) == 0) || (strings.IndexFunc(intDigits + decDigits, isNotDigit) >= 0) {
+		return 0.0,fmt.Errorf("Invalid coordinate number \"%s\"", coordinateData)
+	}
+
+	// Zeros written out in front of the integer digits or after the decimal digits don't count against the format
+	if (len(strings.TrimLeft(intDigits, "0")) > coordFormat.numDigits) || (len(strings.TrimRight(decDigits, "0")) > coordFormat.numDecimals) {
+		return 0.0,fmt.Errorf("Coordinate number \"%s\" has more digits than the coordinate format allows (%d integer and %d decimal digits)", coordinateData, coordFormat.numDigits, coordFormat.numDecimals)
+	}
+
+	return strconv.ParseFloat(coordinateData, 64)
+}
+
+func isNotDigit(char rune) bool {
+	return (char < '0') || (char > '9')
+}
+
+// Encodes the value as a number for coordinate data, rounded to the number of decimal digits in the format,
+// with the zeros the format omits left off.  Negative values start with a "-", but positive values have no sign
+func (coordFormat *CoordinateFormat) Encode(value float64) (string, error) {
+	totalDigits := coordFormat.numDigits + coordFormat.numDecimals
+	scaled := math.Round(math.Abs(value) * math.Pow10(coordFormat.numDecimals))
+	if math.IsNaN(scaled) || (scaled >= math.Pow10(totalDigits)) {
+		return "",fmt.Errorf("Coordinate %f doesn't fit in the coordinate format (%d integer and %d decimal digits)", value, coordFormat.numDigits, coordFormat.numDecimals)
+	}
+
+	digits := strconv.FormatInt(int64(scaled), 10)
+	if coordFormat.zeroOmissionMode == OMIT_TRAILING_ZEROS {
+		// The digits have to line up from the left, so the leading zeros stay, and the trailing ones go
+		// (except for a single zero, if that's all there is)
+		digits = strings.Repeat("0", totalDigits - len(digits)) + digits
+		digits = strings.TrimRight(digits, "0")
+		if digits == "" {
+			digits = "0"
+		}
+	}
+
+	if (scaled != 0.0) && (value < 0.0) {
+		return "-" + digits,nil
+	}
+
+	return digits,nil
+}
+
+// Number of integer digits
+func (coordFormat *CoordinateFormat) GetNumDigits() int {
+	return coordFormat.numDigits
+}
+
+// Number of decimal digits
+func (coordFormat *CoordinateFormat) GetNumDecimals() int {
+	return coordFormat.numDecimals
+}
+
+func (coordFormat *CoordinateFormat) GetZeroOmissionMode() ZeroOmissionMode {
+	return coordFormat.zeroOmissionMode
+}
+
+func (coordFormat *CoordinateFormat) String() string {
+	var zeroOmissionMode string
+
+	switch coordFormat.zeroOmissionMode {
+		case OMIT_LEADING_ZEROS:
+			zeroOmissionMode = "Omit Leading"
+
+		case OMIT_TRAILING_ZEROS:
+			zeroOmissionMode = "Omit Trailing"
+
+		default:
+			zeroOmissionMode = "Unknown"
+	}
+
+	return fmt.Sprintf("{Coordinate Format, Zero Omission Mode: %s, Int Pos: %d, Dec Pos: %d}", zeroOmissionMode, coordFormat.numDigits, coordFormat.numDecimals)
+}
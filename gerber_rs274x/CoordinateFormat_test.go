@@ -0,0 +1,185 @@
+package gerber_rs274x
+
+import (
+	"math"
+	"testing"
+)
+
+func TestNewCoordinateFormat(t *testing.T) {
+	for _,numDigits := range []int{-1, 0, MAX_COORDINATE_DIGITS + 1} {
+		if _,err := NewCoordinateFormat(numDigits, 4, OMIT_LEADING_ZEROS); err == nil {
+			t.Errorf("Coordinate format with %d integer digits was accepted", numDigits)
+		}
+	}
+
+	for _,numDecimals := range []int{-1, MAX_COORDINATE_DIGITS + 1} {
+		if _,err := NewCoordinateFormat(2, numDecimals, OMIT_LEADING_ZEROS); err == nil {
+			t.Errorf("Coordinate format with %d decimal digits was accepted", numDecimals)
+		}
+	}
+
+	if _,err := NewCoordinateFormat(1, 0, OMIT_TRAILING_ZEROS); err != nil {
+		t.Errorf("Coordinate format with 1 integer digit and no decimal digits was rejected: %v", err)
+	}
+}
+
+func TestCoordinateFormatDecode(t *testing.T) {
+	testCases := []struct {
+		numDigits, numDecimals int
+		zeroOmissionMode ZeroOmissionMode
+		coordinateData string
+		value float64
+		valid bool
+	}{
+		{2, 4, OMIT_LEADING_ZEROS, "12", 0.0012, true},
+		{2, 4, OMIT_LEADING_ZEROS, "-12", -0.0012, true},
+		{2, 4, OMIT_LEADING_ZEROS, "+12", 0.0012, true},
+		{2, 4, OMIT_LEADING_ZEROS, "123456", 12.3456, true},
+		{2, 4, OMIT_LEADING_ZEROS, "1234567", 0.0, false},
+		{2, 4, OMIT_TRAILING_ZEROS, "12", 12.0, true},
+		{2, 4, OMIT_TRAILING_ZEROS, "-12", -12.0, true},
+		{2, 4, OMIT_TRAILING_ZEROS, "-123456", -12.3456, true},
+		{2, 4, OMIT_TRAILING_ZEROS, "-1234567", 0.0, false},
+		{2, 4, OMIT_LEADING_ZEROS, "1.5", 1.5, true},
+		{2, 4, OMIT_TRAILING_ZEROS, "-.25", -0.25, true},
+		{2, 4, OMIT_LEADING_ZEROS, "001.50000", 1.5, true},
+		{2, 4, OMIT_LEADING_ZEROS, "123.5", 0.0, false},
+		{2, 4, OMIT_LEADING_ZEROS, "1.23456", 0.0, false},
+		{2, 4, OMIT_LEADING_ZEROS, "", 0.0, false},
+		{2, 4, OMIT_LEADING_ZEROS, "-", 0.0, false},
+		{2, 4, OMIT_LEADING_ZEROS, ".", 0.0, false},
+		{2, 4, OMIT_LEADING_ZEROS, "--1", 0.0, false},
+		{2, 4, OMIT_LEADING_ZEROS, "1.2.3", 0.0, false},
+	}
+
+	for _,testCase := range testCases {
+		coordFormat,err := NewCoordinateFormat(testCase.numDigits, testCase.numDecimals, testCase.zeroOmissionMode)
+		if err != nil {
+			t.Fatalf("Error making coordinate format: %v", err)
+		}
+
+		value,err := coordFormat.Decode(testCase.coordinateData)
+		if !testCase.valid {
+			if err == nil {
+				t.Errorf("%v: decoding \"%s\" gave %v, expected an error", coordFormat, testCase.coordinateData, value)
+			}
+		} else if err != nil {
+			t.Errorf("%v: decoding \"%s\" gave error %v", coordFormat, testCase.coordinateData, err)
+		} else if value != testCase.value {
+			t.Errorf("%v: decoding \"%s\" gave %v, expected %v", coordFormat, testCase.coordinateData, value, testCase.value)
+		}
+	}
+}
+
+func TestCoordinateFormatEncode(t *testing.T) {
+	testCases := []struct {
+		numDigits, numDecimals int
+		zeroOmissionMode ZeroOmissionMode
+		value float64
+		coordinateData string
+	}{
+		{2, 4, OMIT_LEADING_ZEROS, 0.0, "0"},
+		{2, 4, OMIT_TRAILING_ZEROS, 0.0, "0"},
+		{2, 4, OMIT_LEADING_ZEROS, -0.0012, "-12"},
+		{2, 4, OMIT_TRAILING_ZEROS, -12.0, "-12"},
+		{2, 4, OMIT_TRAILING_ZEROS, 0.5, "005"},
+		// Anything that rounds to zero doesn't get a sign
+		{2, 4, OMIT_TRAILING_ZEROS, -0.00001, "0"},
+	}
+
+	for _,testCase := range testCases {
+		coordFormat,err := NewCoordinateFormat(testCase.numDigits, testCase.numDecimals, testCase.zeroOmissionMode)
+		if err != nil {
+			t.Fatalf("Error making coordinate format: %v", err)
+		}
+
+		if coordinateData,err := coordFormat.Encode(testCase.value); err != nil {
+			t.Errorf("%v: encoding %v gave error %v", coordFormat, testCase.value, err)
+		} else if coordinateData != testCase.coordinateData {
+			t.Errorf("%v: encoding %v gave \"%s\", expected \"%s\"", coordFormat, testCase.value, coordinateData, testCase.coordinateData)
+		}
+	}
+
+	// Values that need more integer digits than the format has can't be encoded
+	if coordFormat,err := NewCoordinateFormat(2, 4, OMIT_LEADING_ZEROS); err != nil {
+		t.Fatalf("Error making coordinate format: %v", err)
+	} else if coordinateData,err := coordFormat.Encode(99.99995); err == nil {
+		t.Errorf("%v: encoding 99.99995 gave \"%s\", expected an error", coordFormat, coordinateData)
+	}
+}
+
+// Makes one of the valid coordinate formats out of whatever the fuzzer came up with
+func fuzzCoordinateFormat(t *testing.T, numDigits uint8, numDecimals uint8, omitTrailing bool) *CoordinateFormat {
+	zeroOmissionMode := OMIT_LEADING_ZEROS
+	if omitTrailing {
+		zeroOmissionMode = OMIT_TRAILING_ZEROS
+	}
+
+	coordFormat,err := NewCoordinateFormat(1 + int(numDigits % MAX_COORDINATE_DIGITS), int(numDecimals % (MAX_COORDINATE_DIGITS + 1)), zeroOmissionMode)
+	if err != nil {
+		t.Fatalf("Error making coordinate format: %v", err)
+	}
+
+	return coordFormat
+}
+
+// Every value that the format can represent comes back exactly the same after being encoded and decoded
+func FuzzCoordinateFormatEncode(f *testing.F) {
+	f.Add(uint8(2), uint8(4), false, int64(12))
+	f.Add(uint8(2), uint8(4), false, int64(-12))
+	f.Add(uint8(2), uint8(4), true, int64(-120000))
+	f.Add(uint8(2), uint8(4), true, int64(5000))
+	f.Add(uint8(7), uint8(7), true, int64(-99999999999999))
+	f.Add(uint8(1), uint8(0), false, int64(0))
+
+	f.Fuzz(func(t *testing.T, numDigits uint8, numDecimals uint8, omitTrailing bool, scaled int64) {
+		coordFormat := fuzzCoordinateFormat(t, numDigits, numDecimals, omitTrailing)
+
+		// Bring the number into the range the format can hold, keeping its sign
+		limit := int64(math.Pow10(coordFormat.numDigits + coordFormat.numDecimals))
+		scaled %= limit
+		value := float64(scaled) / math.Pow10(coordFormat.numDecimals)
+
+		coordinateData,err := coordFormat.Encode(value)
+		if err != nil {
+			t.Fatalf("%v: encoding %v gave error %v", coordFormat, value, err)
+		}
+
+		if decoded,err := coordFormat.Decode(coordinateData); err != nil {
+			t.Fatalf("%v: decoding \"%s\" (encoded from %v) gave error %v", coordFormat, coordinateData, value, err)
+		} else if decoded != value {
+			t.Fatalf("%v: %v was encoded as \"%s\", which decoded as %v", coordFormat, value, coordinateData, decoded)
+		}
+	})
+}
+
+// Once a number has been decoded, encoding it gives a number that decodes to the same value, and encodes back to itself
+func FuzzCoordinateFormatDecode(f *testing.F) {
+	f.Add(uint8(2), uint8(4), false, "-12")
+	f.Add(uint8(2), uint8(4), true, "-12")
+	f.Add(uint8(2), uint8(4), true, "+0012")
+	f.Add(uint8(3), uint8(3), true, "-1.5")
+	f.Add(uint8(7), uint8(7), false, "12345678901234")
+
+	f.Fuzz(func(t *testing.T, numDigits uint8, numDecimals uint8, omitTrailing bool, coordinateData string) {
+		coordFormat := fuzzCoordinateFormat(t, numDigits, numDecimals, omitTrailing)
+
+		decoded,err := coordFormat.Decode(coordinateData)
+		if err != nil {
+			return
+		}
+
+		encoded,err := coordFormat.Encode(decoded)
+		if err != nil {
+			t.Fatalf("%v: \"%s\" decoded as %v, which gave error %v when encoded", coordFormat, coordinateData, decoded, err)
+		}
+
+		if redecoded,err := coordFormat.Decode(encoded); err != nil {
+			t.Fatalf("%v: decoding \"%s\" (encoded from \"%s\") gave error %v", coordFormat, encoded, coordinateData, err)
+		} else if redecoded != decoded {
+			t.Fatalf("%v: \"%s\" decoded as %v, but was encoded as \"%s\", which decoded as %v", coordFormat, coordinateData, decoded, encoded, redecoded)
+		} else if reencoded,err := coordFormat.Encode(redecoded); (err != nil) || (reencoded != encoded) {
+			t.Fatalf("%v: \"%s\" was encoded as \"%s\" and then \"%s\" (%v)", coordFormat, coordinateData, encoded, reencoded, err)
+		}
+	})
+}
@@ -1,9 +1,9 @@
 package gerber_rs274x
 
-import cairo "github.com/ungerik/go-cairo"
-
 type DataBlock interface {
 	DataBlockPlaceholder()
+	// Calls the method of the visitor that matches the type of this data block
+	Accept(visitor Visitor) error
 	ProcessDataBlockBoundsCheck(imageBounds *ImageBounds, gfxState *GraphicsState) error
-	ProcessDataBlockSurface(surface *cairo.Surface, gfxState *GraphicsState) error
+	ProcessDataBlockRender(renderer Renderer, gfxState *GraphicsState) error
 }
\ No newline at end of file
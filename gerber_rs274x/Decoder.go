@@ -0,0 +1,209 @@
+package gerber_rs274x
+
+import (
+	"errors"
+	"fmt"
+	"io"
+)
+
+// A Decoder reads data blocks from a gerber file one at a time, so that the whole file never
+// has to be held in memory.  The parse environment (coordinate format, units, defined apertures, etc.)
+// is carried along from one call to the next, so callers are free to stop early, or skip over
+// blocks they aren't interested in
+type Decoder struct {
+	tokenizer *gerberTokenizer
+	parseEnv *ParseEnvironment
+	tokenIndex int
+	// A block that was read while finishing off a step and repeat block, but that doesn't belong to it,
+	// so it needs to be returned by the next call to Next
+	pendingDataBlock DataBlock
+	// Errors reading from the underlying reader can't be recovered from, so once one happens,
+	// every subsequent call to Next returns it
+	readErr error
+	warnings []*ParseError
+}
+
+// Creates a Decoder that parses in lenient mode
+func NewDecoder(in io.Reader) *Decoder {
+	return NewDecoderWithOptions(in, ParseOptions{})
+}
+
+func NewDecoderWithOptions(in io.Reader, options ParseOptions) *Decoder {
+	decoder := new(Decoder)
+	decoder.tokenizer = newGerberTokenizer(in)
+	decoder.parseEnv = newParseEnv(options)
+
+	return decoder
+}
+
+// Returns the problems that have been recovered from so far in lenient mode
+func (decoder *Decoder) Warnings() []*ParseError {
+	return decoder.warnings
+}
+
+// Returns the standard file attributes from the TF parameters read so far.  These normally all come at the start of the file
+func (decoder *Decoder) FileAttributes() *FileAttributes {
+	return ParseFileAttributes(decoder.parseEnv.attributes.fileAttributes)
+}
+
+// Returns the next data block in the file, or io.EOF once the end of the file has been reached.
+// If a single data block fails to parse, a *ParseError is returned along with a nil data block,
+// and decoding can carry on with the next call.  Step and repeat parameters are returned
+// only once their whole block has been read, with the blocks inside them attached
+func (decoder *Decoder) Next() (DataBlock, error) {
+	for {
+		var dataBlock DataBlock
+
+		if decoder.pendingDataBlock != nil {
+			dataBlock = decoder.pendingDataBlock
+			decoder.pendingDataBlock = nil
+		} else {
+			var err error
+			if dataBlock,err = decoder.readDataBlock(); err == io.EOF {
+				// The end of the file closes any open step and repeat block
+				if openStepAndRepeat := decoder.closeStepAndRepeat(); openStepAndRepeat != nil {
+					return openStepAndRepeat,nil
+				}
+				return nil,io.EOF
+			} else if err != nil {
+				return nil,err
+			} else if dataBlock == nil {
+				// An SR parameter with no arguments closes the currently open step and repeat block
+				if openStepAndRepeat := decoder.closeStepAndRepeat(); openStepAndRepeat != nil {
+					return openStepAndRepeat,nil
+				}
+				continue
+			}
+		}
+
+		openStepAndRepeat := decoder.parseEnv.openStepAndRepeat
+
+		if openStepAndRepeat == nil {
+			if stepAndRepeat,isStepAndRepeat := dataBlock.(*StepAndRepeatParameter); isStepAndRepeat {
+				// Hold on to the step and repeat parameter until we've read the whole block
+				decoder.parseEnv.openStepAndRepeat = stepAndRepeat
+				continue
+			}
+			return dataBlock,nil
+		}
+
+		if closesStepAndRepeat(dataBlock) {
+			// This block ends the open step and repeat block, but isn't part of it, so save it
+			// to be returned from the next call
+			decoder.pendingDataBlock = dataBlock
+			return decoder.closeStepAndRepeat(),nil
+		}
+
+		openStepAndRepeat.dataBlocks = append(openStepAndRepeat.dataBlocks, dataBlock)
+	}
+}
+
+// Reads and parses the next token in the file.  A nil data block with no error means
+// the token was an SR parameter with no arguments
+func (decoder *Decoder) readDataBlock() (DataBlock, error) {
+	for {
+		if decoder.readErr != nil {
+			return nil,decoder.readErr
+		}
+
+		token,err := decoder.tokenizer.nextToken()
+		if err == io.EOF {
+			return nil,io.EOF
+		} else if syntaxErr,isParseError := err.(*ParseError); isParseError {
+			// The tokenizer knows where the broken block is, but not which token it was
+			syntaxErr.TokenIndex = decoder.tokenIndex
+			decoder.tokenIndex++
+			return nil,syntaxErr
+		} else if err != nil {
+			decoder.readErr = &ParseError{
+				TokenIndex: decoder.tokenIndex,
+				Line: decoder.tokenizer.line,
+				Column: decoder.tokenizer.column,
+				Category: READ_ERROR,
+				Err: fmt.Errorf("Error encountered while reading file: %v", err),
+			}
+			return nil,decoder.readErr
+		}
+
+		index := decoder.tokenIndex
+		decoder.tokenIndex++
+
+		var dataBlock DataBlock
+		var category ParseErrorCategory
+
+		switch token.tokenType {
+			case PARAMETER_TOKEN:
+				decoder.parseEnv.logger.Debug("Read parameter", "token", index, "line", token.line, "parameter", token.contents)
+				if token.contents == "SR" {
+					return nil,nil
+				}
+
+				dataBlock,err = parseParameter(token.contents, decoder.parseEnv)
+				category = PARAMETER_ERROR
+
+			default:
+				dataBlock,err = parseDataBlock(token.contents, decoder.parseEnv)
+				category = DATA_BLOCK_ERROR
+		}
+
+		decoder.collectWarnings(index, token)
+
+		if err != nil {
+			var catErr *categorizedError
+			if errors.As(err, &catErr) {
+				category = catErr.category
+			}
+			return nil,newParseError(index, token, category, err)
+		} else if dataBlock == nil {
+			// Lenient mode skipped over the whole block, so move on to the next one
+			continue
+		}
+
+		decoder.parseEnv.attributes.update(dataBlock)
+		if decoder.parseEnv.unitConverter != nil {
+			decoder.parseEnv.unitConverter.convert(dataBlock)
+		}
+
+		return dataBlock,nil
+	}
+}
+
+func (decoder *Decoder) collectWarnings(index int, token *gerberToken) {
+	for _,warning := range decoder.parseEnv.warnings {
+		decoder.warnings = append(decoder.warnings, newParseError(index, token, warning.category, warning.err))
+	}
+	decoder.parseEnv.warnings = decoder.parseEnv.warnings[:0]
+}
+
+func newParseError(index int, token *gerberToken, category ParseErrorCategory, err error) *ParseError {
+	return &ParseError{
+		TokenIndex: index,
+		Line: token.line,
+		Column: token.column,
+		Block: token.contents,
+		Category: category,
+		Err: err,
+	}
+}
+
+func (decoder *Decoder) closeStepAndRepeat() *StepAndRepeatParameter {
+	openStepAndRepeat := decoder.parseEnv.openStepAndRepeat
+	decoder.parseEnv.openStepAndRepeat = nil
+
+	return openStepAndRepeat
+}
+
+func closesStepAndRepeat(dataBlock DataBlock) bool {
+	switch dataBlockValue := dataBlock.(type) {
+		case *StepAndRepeatParameter:
+			// A new step and repeat parameter implicitly closes any open step and repeat block
+			return true
+
+		case *GraphicsStateChange:
+			// The end of file code can't be part of a step and repeat block
+			return dataBlockValue.fnCode == END_OF_FILE
+
+		default:
+			return false
+	}
+}
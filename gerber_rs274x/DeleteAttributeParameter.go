@@ -0,0 +1,43 @@
+package gerber_rs274x
+
+import (
+	"fmt"
+)
+
+// A TD parameter, which deletes an attribute from the aperture and object dictionaries, or all of the attributes
+// in them if it has no name
+type DeleteAttributeParameter struct {
+	paramCode ParameterCode
+	name string
+}
+
+func (deleteAttribute *DeleteAttributeParameter) DataBlockPlaceholder() {
+
+}
+
+func (deleteAttribute *DeleteAttributeParameter) Accept(visitor Visitor) error {
+	return visitor.VisitDeleteAttribute(deleteAttribute)
+}
+
+func (deleteAttribute *DeleteAttributeParameter) ProcessDataBlockBoundsCheck(imageBounds *ImageBounds, gfxState *GraphicsState) error {
+	// The attribute was removed from the draws and flashes after it when the file was parsed
+	return nil
+}
+
+func (deleteAttribute *DeleteAttributeParameter) ProcessDataBlockRender(renderer Renderer, gfxState *GraphicsState) error {
+	// The attribute was removed from the draws and flashes after it when the file was parsed
+	return nil
+}
+
+func (deleteAttribute *DeleteAttributeParameter) String() string {
+	if deleteAttribute.name == "" {
+		return "{TD, All}"
+	}
+
+	return fmt.Sprintf("{TD, Name: %s}", deleteAttribute.name)
+}
+
+// The name of the attribute to delete, or "" to delete all of them
+func (deleteAttribute *DeleteAttributeParameter) GetName() string {
+	return deleteAttribute.name
+}
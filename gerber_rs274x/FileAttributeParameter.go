@@ -0,0 +1,45 @@
+package gerber_rs274x
+
+import (
+	"fmt"
+)
+
+// A TF parameter, which sets an attribute of the file as a whole (e.g. .FileFunction)
+type FileAttributeParameter struct {
+	paramCode ParameterCode
+	name string
+	values []string
+}
+
+func (fileAttribute *FileAttributeParameter) DataBlockPlaceholder() {
+
+}
+
+func (fileAttribute *FileAttributeParameter) Accept(visitor Visitor) error {
+	return visitor.VisitFileAttribute(fileAttribute)
+}
+
+func (fileAttribute *FileAttributeParameter) ProcessDataBlockBoundsCheck(imageBounds *ImageBounds, gfxState *GraphicsState) error {
+	gfxState.fileAttributes = gfxState.fileAttributes.with(fileAttribute.name, fileAttribute.values)
+	
+	return nil
+}
+
+func (fileAttribute *FileAttributeParameter) ProcessDataBlockRender(renderer Renderer, gfxState *GraphicsState) error {
+	gfxState.fileAttributes = gfxState.fileAttributes.with(fileAttribute.name, fileAttribute.values)
+	
+	return nil
+}
+
+func (fileAttribute *FileAttributeParameter) String() string {
+	return fmt.Sprintf("{TF, Name: %s, Values: %v}", fileAttribute.name, fileAttribute.values)
+}
+
+// The name of the attribute, including the leading "." for standard attributes
+func (fileAttribute *FileAttributeParameter) GetName() string {
+	return fileAttribute.name
+}
+
+func (fileAttribute *FileAttributeParameter) GetValues() []string {
+	return fileAttribute.values
+}
@@ -3,7 +3,6 @@ package gerber_rs274x
 import (
 	"fmt"
 	"math"
-	cairo "github.com/ungerik/go-cairo"
 )
 
 type FormatSpecificationParameter struct {
@@ -20,6 +19,10 @@ func (formatSpecification *FormatSpecificationParameter) DataBlockPlaceholder()
 
 }
 
+func (formatSpecification *FormatSpecificationParameter) Accept(visitor Visitor) error {
+	return visitor.VisitFormatSpecification(formatSpecification)
+}
+
 func (formatSpecification *FormatSpecificationParameter) ProcessDataBlockBoundsCheck(imageBounds *ImageBounds, gfxState *GraphicsState) error {
 	if gfxState.coordinateNotationSet {
 		return fmt.Errorf("Tried to process illegal 2nd format specification parameter")
@@ -32,7 +35,7 @@ func (formatSpecification *FormatSpecificationParameter) ProcessDataBlockBoundsC
 	return nil
 }
 
-func (formatSpecification *FormatSpecificationParameter) ProcessDataBlockSurface(surface *cairo.Surface, gfxState *GraphicsState) error {
+func (formatSpecification *FormatSpecificationParameter) ProcessDataBlockRender(renderer Renderer, gfxState *GraphicsState) error {
 	if gfxState.coordinateNotationSet {
 		return fmt.Errorf("Tried to process illegal 2nd format specification parameter")
 	}
@@ -77,4 +80,38 @@ func (fsParam *FormatSpecificationParameter) String() string {
 						fsParam.xNumDecimals,
 						fsParam.yNumDigits,
 						fsParam.yNumDecimals)
-}
\ No newline at end of file
+}
+
+func (fsParam *FormatSpecificationParameter) GetZeroOmissionMode() ZeroOmissionMode {
+	return fsParam.zeroOmissionMode
+}
+
+func (fsParam *FormatSpecificationParameter) GetCoordinateNotation() CoordinateNotation {
+	return fsParam.coordinateNotation
+}
+
+// The coordinate format that decodes and encodes the numbers in coordinate data.  The spec requires the X and Y formats
+// to match, so this is the format for both
+func (fsParam *FormatSpecificationParameter) GetCoordinateFormat() (*CoordinateFormat, error) {
+	return NewCoordinateFormat(fsParam.xNumDigits, fsParam.xNumDecimals, fsParam.zeroOmissionMode)
+}
+
+// Number of integer digits in X coordinates
+func (fsParam *FormatSpecificationParameter) GetXNumDigits() int {
+	return fsParam.xNumDigits
+}
+
+// Number of decimal digits in X coordinates
+func (fsParam *FormatSpecificationParameter) GetXNumDecimals() int {
+	return fsParam.xNumDecimals
+}
+
+// Number of integer digits in Y coordinates
+func (fsParam *FormatSpecificationParameter) GetYNumDigits() int {
+	return fsParam.yNumDigits
+}
+
+// Number of decimal digits in Y coordinates
+func (fsParam *FormatSpecificationParameter) GetYNumDecimals() int {
+	return fsParam.yNumDecimals
+}
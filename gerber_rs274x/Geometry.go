@@ -0,0 +1,308 @@
+package gerber_rs274x
+
+import (
+	"fmt"
+	"log/slog"
+	"math"
+	"gerber_rs274x/polygon"
+)
+
+// Arcs are approximated this closely if the caller doesn't ask for anything else
+const DEFAULT_GEOMETRY_TOLERANCE_INCHES float64 = 0.0001
+
+// GeometryOptions controls how the shapes in a parsed file are extracted
+type GeometryOptions struct {
+	// Diagnostics go here.  If nil, they are discarded
+	Logger *slog.Logger
+	// The furthest the straight segments that approximate an arc are allowed to stray from the true arc, in the units of the file.
+	// Defaults to 0.0001 inches (or the same distance in millimeters)
+	Tolerance float64
+}
+
+// The exact shapes drawn by a gerber file
+type Geometry struct {
+	// The units of the file, which are the units of all of the coordinates
+	Units Units
+	// The filled area of the image, as polygons that don't overlap each other.  Everything drawn with clear polarity
+	// has already been cut out of what was drawn before it
+	Polygons []polygon.Polygon
+	// The furthest the straight segments that approximate arcs stray from the true arcs
+	Tolerance float64
+}
+
+// Works out the shapes drawn by the parsed file.  Flashes become the outline of the aperture, draws become the area swept
+// out by the aperture, and regions become their contours, all with arcs broken up into straight segments
+func ExtractGeometry(parsedFile []DataBlock, options GeometryOptions) (*Geometry, error) {
+	if options.Tolerance < 0.0 {
+		return nil,fmt.Errorf("Invalid geometry tolerance %v", options.Tolerance)
+	}
+
+	gfxState := newGraphicsState()
+	gfxState.setRenderOptions(RenderOptions{Logger: options.Logger})
+
+	renderer := newGeometryRenderer(gfxState, options.Tolerance)
+	if err := renderDataBlocks(renderer, parsedFile, gfxState); err != nil {
+		return nil,err
+	}
+
+	if err := checkFileComplete(gfxState); err != nil {
+		return nil,err
+	}
+
+	geometry := new(Geometry)
+	geometry.Units = gfxState.units
+	geometry.Tolerance = renderer.arcTolerance()
+	if polygons,err := renderer.finish(); err != nil {
+		return nil,err
+	} else {
+		geometry.Polygons = polygons
+	}
+
+	return geometry,nil
+}
+
+// Grows the shapes by the distance (in the units of the file), or shrinks them if the distance is negative.
+// This is how clearances, solder mask expansion and isolation paths are worked out.  Round joins are approximated
+// to the same tolerance as the arcs were, so offsetting doesn't lose any more precision
+func (geometry *Geometry) Offset(distance float64, join polygon.JoinType) (*Geometry, error) {
+	offset := new(Geometry)
+	offset.Units = geometry.Units
+	offset.Tolerance = geometry.Tolerance
+	if polygons,err := polygon.Offset(geometry.Polygons, distance, polygon.OffsetOptions{Join: join, Tolerance: geometry.Tolerance}); err != nil {
+		return nil,err
+	} else {
+		offset.Polygons = polygons
+	}
+
+	return offset,nil
+}
+
+// Collects everything that's filled as polygons.  Shapes filled with the same polarity are saved up, and then combined
+// with everything before them all at once when the polarity changes
+type geometryRenderer struct {
+	// The units of the file aren't known until its mode parameter has been processed, so we need the graphics state
+	// to work out the default tolerance
+	gfxState *GraphicsState
+	tolerance float64
+	polarity Polarity
+	// The finished sub-paths of the path being built up for the next fill
+	path []polygon.Ring
+	// The sub-path currently being built
+	subPath polygon.Ring
+	// Where the next sub-path starts if a line is drawn after the last one was closed
+	closedStart *polygon.Point
+	currentTransform pathTransform
+	transformStack []pathTransform
+	// Shapes filled with the current polarity that haven't been combined with the result yet
+	pending []polygon.Polygon
+	result []polygon.Polygon
+	// The renderer methods can't return errors, so the first error from combining the shapes is saved for finish to return
+	err error
+}
+
+func newGeometryRenderer(gfxState *GraphicsState, tolerance float64) *geometryRenderer {
+	renderer := new(geometryRenderer)
+
+	renderer.gfxState = gfxState
+	renderer.tolerance = tolerance
+	renderer.polarity = DARK_POLARITY
+
+	return renderer
+}
+
+func (renderer *geometryRenderer) MoveTo(x float64, y float64) {
+	renderer.finishSubPath()
+	renderer.subPath = polygon.Ring{renderer.transformPoint(x, y)}
+}
+
+func (renderer *geometryRenderer) LineTo(x float64, y float64) {
+	renderer.lineToPoint(renderer.transformPoint(x, y))
+}
+
+func (renderer *geometryRenderer) Arc(centerX float64, centerY float64, radius float64, startAngle float64, endAngle float64) {
+	for endAngle < startAngle {
+		endAngle += TWO_PI
+	}
+
+	renderer.appendArc(centerX, centerY, radius, startAngle, endAngle)
+}
+
+func (renderer *geometryRenderer) ArcNegative(centerX float64, centerY float64, radius float64, startAngle float64, endAngle float64) {
+	for endAngle > startAngle {
+		endAngle -= TWO_PI
+	}
+
+	renderer.appendArc(centerX, centerY, radius, startAngle, endAngle)
+}
+
+func (renderer *geometryRenderer) ClosePath() {
+	if len(renderer.subPath) > 0 {
+		start := renderer.subPath[0]
+		renderer.finishSubPath()
+		renderer.closedStart = &start
+	}
+}
+
+func (renderer *geometryRenderer) Fill() {
+	renderer.finishSubPath()
+	rings := renderer.path
+	renderer.path = nil
+	renderer.closedStart = nil
+
+	if len(rings) > 0 {
+		if polygons,err := polygon.Simplify(rings, polygon.EVEN_ODD); err != nil {
+			renderer.setErr(err)
+		} else {
+			renderer.pending = append(renderer.pending, polygons...)
+		}
+	}
+}
+
+func (renderer *geometryRenderer) SetPolarity(polarity Polarity) {
+	if polarity != renderer.polarity {
+		renderer.combinePending()
+		renderer.polarity = polarity
+	}
+}
+
+func (renderer *geometryRenderer) Flash(aperture Aperture, gfxState *GraphicsState, x float64, y float64) error {
+	return renderer.flash(aperture, gfxState, x, y, true)
+}
+
+func (renderer *geometryRenderer) FlashNoHole(aperture Aperture, gfxState *GraphicsState, x float64, y float64) error {
+	return renderer.flash(aperture, gfxState, x, y, false)
+}
+
+func (renderer *geometryRenderer) PushTransform(xOffset float64, yOffset float64, rotation float64) {
+	renderer.transformStack = append(renderer.transformStack, renderer.currentTransform)
+	renderer.currentTransform = renderer.currentTransform.push(xOffset, yOffset, rotation)
+}
+
+func (renderer *geometryRenderer) PopTransform() {
+	if len(renderer.transformStack) == 0 {
+		return
+	}
+
+	renderer.currentTransform = renderer.transformStack[len(renderer.transformStack) - 1]
+	renderer.transformStack = renderer.transformStack[:len(renderer.transformStack) - 1]
+}
+
+func (renderer *geometryRenderer) flash(aperture Aperture, gfxState *GraphicsState, x float64, y float64, withHole bool) error {
+	renderer.PushTransform(x, y, 0.0)
+	defer renderer.PopTransform()
+
+	return aperture.RenderApertureShape(renderer, gfxState, withHole)
+}
+
+// Combines everything left over with the result, and returns the result (or the first error from combining the shapes)
+func (renderer *geometryRenderer) finish() ([]polygon.Polygon, error) {
+	renderer.combinePending()
+	if renderer.err != nil {
+		return nil,renderer.err
+	}
+
+	return renderer.result,nil
+}
+
+func (renderer *geometryRenderer) setErr(err error) {
+	if renderer.err == nil {
+		renderer.err = err
+	}
+}
+
+func (renderer *geometryRenderer) combinePending() {
+	if len(renderer.pending) == 0 {
+		return
+	}
+
+	var combined []polygon.Polygon
+	var err error
+	switch renderer.polarity {
+		case DARK_POLARITY:
+			combined,err = polygon.Union(renderer.result, renderer.pending)
+
+		case CLEAR_POLARITY:
+			combined,err = polygon.Difference(renderer.result, renderer.pending)
+	}
+
+	if err != nil {
+		renderer.setErr(err)
+	} else {
+		renderer.result = combined
+	}
+
+	renderer.pending = nil
+}
+
+func (renderer *geometryRenderer) transformPoint(x float64, y float64) polygon.Point {
+	x,y = renderer.currentTransform.apply(x, y)
+	return polygon.Point{X: x, Y: y}
+}
+
+// Just like cairo, a line without a current point only moves to its end, and a line after a sub-path has been
+// closed starts a new sub-path where the closed one started
+func (renderer *geometryRenderer) lineToPoint(point polygon.Point) {
+	if len(renderer.subPath) == 0 {
+		if renderer.closedStart != nil {
+			renderer.subPath = polygon.Ring{*renderer.closedStart}
+			renderer.closedStart = nil
+		} else {
+			renderer.subPath = polygon.Ring{point}
+			return
+		}
+	}
+
+	renderer.subPath = append(renderer.subPath, point)
+}
+
+func (renderer *geometryRenderer) finishSubPath() {
+	if len(renderer.subPath) > 0 {
+		renderer.path = append(renderer.path, renderer.subPath)
+	}
+	renderer.subPath = nil
+	renderer.closedStart = nil
+}
+
+// Adds the arc (with the end angle already on the correct side of the start angle) as a series of straight segments
+// that stay within the tolerance of the true arc
+func (renderer *geometryRenderer) appendArc(centerX float64, centerY float64, radius float64, startAngle float64, endAngle float64) {
+	sweep := endAngle - startAngle
+	steps := arcSteps(radius, sweep, renderer.arcTolerance())
+
+	for step := 0; step <= steps; step++ {
+		angle := startAngle + (sweep * float64(step) / float64(steps))
+		renderer.lineToPoint(renderer.transformPoint(centerX + (radius * math.Cos(angle)), centerY + (radius * math.Sin(angle))))
+	}
+}
+
+func (renderer *geometryRenderer) arcTolerance() float64 {
+	if renderer.tolerance > 0.0 {
+		return renderer.tolerance
+	}
+
+	return defaultArcTolerance(renderer.gfxState.units)
+}
+
+func defaultArcTolerance(units Units) float64 {
+	if units == UNITS_MM {
+		return DEFAULT_GEOMETRY_TOLERANCE_INCHES * MM_PER_INCH
+	}
+
+	return DEFAULT_GEOMETRY_TOLERANCE_INCHES
+}
+
+// The number of straight segments needed to keep an arc within the tolerance.  A chord across an angle a of a circle
+// strays r * (1 - cos(a / 2)) from the circle at its middle
+func arcSteps(radius float64, sweep float64, tolerance float64) int {
+	radius = math.Abs(radius)
+	if (radius == 0.0) || (sweep == 0.0) {
+		return 1
+	}
+
+	maxAngle := math.Pi
+	if tolerance < radius {
+		maxAngle = 2.0 * math.Acos(1.0 - (tolerance / radius))
+	}
+
+	return max(1, int(math.Ceil(math.Abs(sweep) / maxAngle)))
+}
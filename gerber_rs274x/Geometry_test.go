@@ -0,0 +1,90 @@
+package gerber_rs274x
+
+import (
+	"math"
+	"strings"
+	"testing"
+	"gerber_rs274x/polygon"
+)
+
+func extractGeometry(t *testing.T, name string, contents string) *Geometry {
+	t.Helper()
+
+	dataBlocks,err := ParseGerberFile(strings.NewReader(contents))
+	if err != nil {
+		t.Fatalf("%s: error parsing: %v", name, err)
+	}
+
+	geometry,err := ExtractGeometry(dataBlocks, GeometryOptions{})
+	if err != nil {
+		t.Fatalf("%s: error extracting the geometry: %v", name, err)
+	}
+
+	return geometry
+}
+
+func checkGeometry(t *testing.T, name string, geometry *Geometry, numPolygons int, numHoles int, area float64, xMin, xMax, yMin, yMax float64) {
+	t.Helper()
+
+	holes := 0
+	gotXMin,gotXMax,gotYMin,gotYMax := math.Inf(1),math.Inf(-1),math.Inf(1),math.Inf(-1)
+	for _,shape := range geometry.Polygons {
+		holes += len(shape.Holes)
+		ringXMin,ringXMax,ringYMin,ringYMax := shape.Outer.Bounds()
+		gotXMin,gotXMax = math.Min(gotXMin, ringXMin),math.Max(gotXMax, ringXMax)
+		gotYMin,gotYMax = math.Min(gotYMin, ringYMin),math.Max(gotYMax, ringYMax)
+	}
+
+	if (len(geometry.Polygons) != numPolygons) || (holes != numHoles) || (math.Abs(polygon.Area(geometry.Polygons) - area) > 1e-9) {
+		t.Errorf("%s: got %d polygons with %d holes and area %v, expected %d polygons with %d holes and area %v",
+			name, len(geometry.Polygons), holes, polygon.Area(geometry.Polygons), numPolygons, numHoles, area)
+		return
+	}
+
+	got := []float64{gotXMin, gotXMax, gotYMin, gotYMax}
+	expected := []float64{xMin, xMax, yMin, yMax}
+	for index := range got {
+		if math.Abs(got[index] - expected[index]) > 1e-9 {
+			t.Errorf("%s: bounds are (xMin, xMax, yMin, yMax) %v, expected %v", name, got, expected)
+			break
+		}
+	}
+}
+
+func TestExtractGeometry(t *testing.T) {
+	flash := extractGeometry(t, "Flashed rectangle", "%FSLAX24Y24*%%MOIN*%%ADD10R,0.2000X0.1000*%D10*X10000Y10000D03*M02*")
+	checkGeometry(t, "Flashed rectangle", flash, 1, 0, 0.02, 0.9, 1.1, 0.95, 1.05)
+	if flash.Units != UNITS_IN {
+		t.Errorf("Flashed rectangle: units are %v, expected inches", flash.Units)
+	}
+
+	// Clear polarity cuts into what was drawn before it, but what's drawn dark afterwards goes on top again
+	hole := extractGeometry(t, "Clear polarity", "%FSLAX24Y24*%%MOMM*%%ADD10R,2X2*%%ADD11R,1X1*%D10*X0Y0D03*%LPC*%D11*X0Y0D03*M02*")
+	checkGeometry(t, "Clear polarity", hole, 1, 1, 3.0, -1.0, 1.0, -1.0, 1.0)
+	island := extractGeometry(t, "Dark inside a hole", "%FSLAX24Y24*%%MOMM*%%ADD10R,2X2*%%ADD11R,1X1*%%ADD12R,0.5X0.5*%D10*X0Y0D03*%LPC*%D11*X0Y0D03*%LPD*%D12*X0Y0D03*M02*")
+	checkGeometry(t, "Dark inside a hole", island, 2, 1, 3.25, -1.0, 1.0, -1.0, 1.0)
+	filled := extractGeometry(t, "Hole filled in again", "%FSLAX24Y24*%%MOMM*%%ADD10R,2X2*%%ADD11R,1X1*%D10*X0Y0D03*%LPC*%D11*X0Y0D03*%LPD*%D11*X0Y0D03*M02*")
+	checkGeometry(t, "Hole filled in again", filled, 1, 0, 4.0, -1.0, 1.0, -1.0, 1.0)
+}
+
+// Growing and then shrinking the geometry by the same distance (with miter joins, so that the corners stay sharp)
+// gives back the original shape
+func TestGeometryOffset(t *testing.T) {
+	geometry := extractGeometry(t, "Offset", "%FSLAX24Y24*%%MOIN*%%ADD10R,0.2000X0.1000*%D10*X0Y0D03*M02*")
+
+	grown,err := geometry.Offset(0.05, polygon.JOIN_MITER)
+	if err != nil {
+		t.Fatalf("Error growing the geometry: %v", err)
+	}
+	checkGeometry(t, "Grown", grown, 1, 0, 0.3 * 0.2, -0.15, 0.15, -0.1, 0.1)
+
+	shrunk,err := grown.Offset(-0.05, polygon.JOIN_MITER)
+	if err != nil {
+		t.Fatalf("Error shrinking the geometry: %v", err)
+	}
+	checkGeometry(t, "Grown and shrunk", shrunk, 1, 0, 0.02, -0.1, 0.1, -0.05, 0.05)
+
+	if (grown.Units != geometry.Units) || (shrunk.Tolerance != geometry.Tolerance) {
+		t.Errorf("Offsetting changed the units or tolerance of the geometry")
+	}
+}
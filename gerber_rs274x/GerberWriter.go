@@ -0,0 +1,491 @@
+package gerber_rs274x
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// WriteOptions controls how WriteGerber formats its output
+type WriteOptions struct {
+	// Written after every data block and parameter.  Defaults to "\n" if empty
+	LineEnding string
+}
+
+type gerberWriter struct {
+	out io.Writer
+	lineEnding string
+	// Coordinates are written using the format from the most recent FS parameter
+	coordFormat CoordinateFormat
+}
+
+// Writes the data blocks out as RS-274X.  Coordinates are written using the format given by the FS parameter in the blocks,
+// so the FS parameter has to come before any coordinate data.  Parsing the output gives back data blocks identical to the input
+func WriteGerber(w io.Writer, blocks []DataBlock, opts WriteOptions) error {
+	writer := new(gerberWriter)
+	writer.out = w
+	writer.lineEnding = opts.LineEnding
+	if writer.lineEnding == "" {
+		writer.lineEnding = "\n"
+	}
+
+	return Walk(blocks, writer)
+}
+
+func (writer *gerberWriter) VisitFormatSpecification(fsParam *FormatSpecificationParameter) error {
+	return writer.writeFormatSpecification(fsParam)
+}
+
+func (writer *gerberWriter) VisitMode(moParam *ModeParameter) error {
+	switch moParam.units {
+		case UNITS_IN:
+			return writer.writeParameter("MOIN")
+
+		case UNITS_MM:
+			return writer.writeParameter("MOMM")
+
+		default:
+			return fmt.Errorf("Unable to write MO parameter with unknown units %d", moParam.units)
+	}
+}
+
+func (writer *gerberWriter) VisitApertureDefinition(adParam *ApertureDefinitionParameter) error {
+	return writer.writeApertureDefinition(adParam)
+}
+
+func (writer *gerberWriter) VisitApertureMacro(amParam *ApertureMacroParameter) error {
+	return writer.writeApertureMacro(amParam)
+}
+
+func (writer *gerberWriter) VisitStepAndRepeat(srParam *StepAndRepeatParameter) error {
+	// The blocks inside the step and repeat are written out between the SR parameter and an empty SR parameter that closes the block
+	if err := writer.writeParameter(fmt.Sprintf("SRX%dY%dI%sJ%s", srParam.xRepeats, srParam.yRepeats, formatDecimal(srParam.xStepDistance), formatDecimal(srParam.yStepDistance))); err != nil {
+		return err
+	}
+	if err := Walk(srParam.dataBlocks, writer); err != nil {
+		return err
+	}
+	return writer.writeParameter("SR")
+}
+
+func (writer *gerberWriter) VisitLevelPolarity(lpParam *LevelPolarityParameter) error {
+	switch lpParam.polarity {
+		case CLEAR_POLARITY:
+			return writer.writeParameter("LPC")
+
+		case DARK_POLARITY:
+			return writer.writeParameter("LPD")
+
+		default:
+			return fmt.Errorf("Unable to write LP parameter with unknown polarity %d", lpParam.polarity)
+	}
+}
+
+func (writer *gerberWriter) VisitFileAttribute(tfParam *FileAttributeParameter) error {
+	return writer.writeParameter("TF" + formatAttribute(tfParam.name, tfParam.values))
+}
+
+func (writer *gerberWriter) VisitApertureAttribute(taParam *ApertureAttributeParameter) error {
+	return writer.writeParameter("TA" + formatAttribute(taParam.name, taParam.values))
+}
+
+func (writer *gerberWriter) VisitObjectAttribute(toParam *ObjectAttributeParameter) error {
+	return writer.writeParameter("TO" + formatAttribute(toParam.name, toParam.values))
+}
+
+func (writer *gerberWriter) VisitDeleteAttribute(tdParam *DeleteAttributeParameter) error {
+	return writer.writeParameter("TD" + tdParam.name)
+}
+
+func (writer *gerberWriter) VisitComment(comment *IgnoreDataBlock) error {
+	// The parsed comment keeps any whitespace that followed the G04
+	return writer.writeBlock("G04" + comment.comment)
+}
+
+func (writer *gerberWriter) VisitSetCurrentAperture(setCurrentAperture *SetCurrentAperture) error {
+	return writer.writeBlock(fmt.Sprintf("D%d", setCurrentAperture.apertureNumber))
+}
+
+func (writer *gerberWriter) VisitGraphicsStateChange(graphicsStateChange *GraphicsStateChange) error {
+	if code,err := functionCodeString(graphicsStateChange.fnCode); err != nil {
+		return err
+	} else {
+		return writer.writeBlock(code)
+	}
+}
+
+func (writer *gerberWriter) VisitInterpolation(interpolation *Interpolation) error {
+	return writer.writeInterpolation(interpolation)
+}
+
+func (writer *gerberWriter) writeFormatSpecification(fsParam *FormatSpecificationParameter) error {
+	var zeroOmissionMode string
+	var coordinateNotation string
+
+	switch fsParam.zeroOmissionMode {
+		case OMIT_LEADING_ZEROS:
+			zeroOmissionMode = "L"
+
+		case OMIT_TRAILING_ZEROS:
+			zeroOmissionMode = "T"
+
+		default:
+			return fmt.Errorf("Unable to write FS parameter with unknown zero omission mode %d", fsParam.zeroOmissionMode)
+	}
+
+	switch fsParam.coordinateNotation {
+		case ABSOLUTE_NOTATION:
+			coordinateNotation = "A"
+
+		case INCREMENTAL_NOTATION:
+			coordinateNotation = "I"
+
+		default:
+			return fmt.Errorf("Unable to write FS parameter with unknown coordinate notation %d", fsParam.coordinateNotation)
+	}
+
+	// Subsequent coordinates are written in this format (the spec requires the X and Y formats to match)
+	if coordFormat,err := fsParam.GetCoordinateFormat(); err != nil {
+		return err
+	} else {
+		writer.coordFormat = *coordFormat
+	}
+
+	return writer.writeParameter(fmt.Sprintf("FS%s%sX%d%dY%d%d", zeroOmissionMode, coordinateNotation, fsParam.xNumDigits, fsParam.xNumDecimals, fsParam.yNumDigits, fsParam.yNumDecimals))
+}
+
+func (writer *gerberWriter) writeApertureDefinition(adParam *ApertureDefinitionParameter) error {
+	var template string
+	var modifiers []string
+
+	switch aperture := adParam.aperture.(type) {
+		case *CircleAperture:
+			template = "C"
+			modifiers = append(modifiers, formatDecimal(aperture.diameter))
+			modifiers = appendHoleModifiers(modifiers, aperture.Hole)
+
+		case *RectangleAperture:
+			template = "R"
+			modifiers = append(modifiers, formatDecimal(aperture.xSize), formatDecimal(aperture.ySize))
+			modifiers = appendHoleModifiers(modifiers, aperture.Hole)
+
+		case *ObroundAperture:
+			template = "O"
+			modifiers = append(modifiers, formatDecimal(aperture.xSize), formatDecimal(aperture.ySize))
+			modifiers = appendHoleModifiers(modifiers, aperture.Hole)
+
+		case *PolygonAperture:
+			template = "P"
+			modifiers = append(modifiers, formatDecimal(aperture.outerDiameter), strconv.Itoa(aperture.numVertices))
+			// The rotation is optional, but has to be there if there's a hole after it
+			if aperture.rotationDegrees != 0.0 || aperture.Hole != nil {
+				modifiers = append(modifiers, formatDecimal(aperture.rotationDegrees))
+			}
+			modifiers = appendHoleModifiers(modifiers, aperture.Hole)
+
+		case *MacroAperture:
+			template = aperture.macroName
+			for _,modifier := range aperture.modifiers {
+				modifiers = append(modifiers, formatDecimal(modifier))
+			}
+
+		default:
+			return fmt.Errorf("Unable to write aperture definition for unknown aperture type %T", adParam.aperture)
+	}
+
+	if len(modifiers) > 0 {
+		return writer.writeParameter(fmt.Sprintf("ADD%d%s,%s", adParam.apertureNumber, template, strings.Join(modifiers, "X")))
+	}
+
+	return writer.writeParameter(fmt.Sprintf("ADD%d%s", adParam.apertureNumber, template))
+}
+
+func appendHoleModifiers(modifiers []string, hole Hole) []string {
+	switch holeValue := hole.(type) {
+		case *CircularHole:
+			return append(modifiers, formatDecimal(holeValue.holeDiameter))
+
+		case *RectangularHole:
+			return append(modifiers, formatDecimal(holeValue.holeXSize), formatDecimal(holeValue.holeYSize))
+
+		default:
+			return modifiers
+	}
+}
+
+func (writer *gerberWriter) writeApertureMacro(amParam *ApertureMacroParameter) error {
+	// Each block of the macro goes on its own line, to keep the output readable
+	blocks := make([]string, 0, len(amParam.dataBlocks) + 1)
+	blocks = append(blocks, "AM" + amParam.macroName)
+
+	for _,dataBlock := range amParam.dataBlocks {
+		switch dataBlockValue := dataBlock.(type) {
+			case *ApertureMacroComment:
+				blocks = append(blocks, "0 " + dataBlockValue.comment)
+
+			case *ApertureMacroVariableDefinition:
+				if value,err := formatExpression(dataBlockValue.value, false); err != nil {
+					return err
+				} else {
+					blocks = append(blocks, fmt.Sprintf("$%d=%s", dataBlockValue.variableNumber, value))
+				}
+
+			case AperturePrimitive:
+				if primitive,err := formatAperturePrimitive(dataBlockValue); err != nil {
+					return err
+				} else {
+					blocks = append(blocks, primitive)
+				}
+
+			default:
+				return fmt.Errorf("Unable to write unknown aperture macro data block type %T", dataBlock)
+		}
+	}
+
+	return writer.writeParameter(strings.Join(blocks, "*" + writer.lineEnding))
+}
+
+func formatAperturePrimitive(primitive AperturePrimitive) (string, error) {
+	var code string
+	var modifiers []ApertureMacroExpression
+
+	switch primitiveValue := primitive.(type) {
+		case *CirclePrimitive:
+			code = "1"
+			modifiers = []ApertureMacroExpression{primitiveValue.exposure, primitiveValue.diameter, primitiveValue.centerX, primitiveValue.centerY}
+
+		case *VectorLinePrimitive:
+			// Code 2 is a deprecated synonym for 20, so we always write 20
+			code = "20"
+			modifiers = []ApertureMacroExpression{primitiveValue.exposure, primitiveValue.lineWidth, primitiveValue.startX, primitiveValue.startY, primitiveValue.endX, primitiveValue.endY, primitiveValue.rotationAngle}
+
+		case *CenterLinePrimitive:
+			code = "21"
+			modifiers = []ApertureMacroExpression{primitiveValue.exposure, primitiveValue.width, primitiveValue.height, primitiveValue.centerX, primitiveValue.centerY, primitiveValue.rotationAngle}
+
+		case *LowerLeftLinePrimitive:
+			code = "22"
+			modifiers = []ApertureMacroExpression{primitiveValue.exposure, primitiveValue.width, primitiveValue.height, primitiveValue.lowerLeftX, primitiveValue.lowerLeftY, primitiveValue.rotationAngle}
+
+		case *OutlinePrimitive:
+			code = "4"
+			modifiers = []ApertureMacroExpression{primitiveValue.exposure, primitiveValue.nPoints, primitiveValue.startX, primitiveValue.startY}
+			for point := range primitiveValue.subsequentX {
+				modifiers = append(modifiers, primitiveValue.subsequentX[point], primitiveValue.subsequentY[point])
+			}
+			modifiers = append(modifiers, primitiveValue.rotationAngle)
+
+		case *PolygonPrimitive:
+			code = "5"
+			modifiers = []ApertureMacroExpression{primitiveValue.exposure, primitiveValue.nVertices, primitiveValue.centerX, primitiveValue.centerY, primitiveValue.diameter, primitiveValue.rotationAngle}
+
+		case *MoirePrimitive:
+			code = "6"
+			modifiers = []ApertureMacroExpression{primitiveValue.centerX, primitiveValue.centerY, primitiveValue.outerDiameter, primitiveValue.ringThickness, primitiveValue.ringGap, primitiveValue.maxRings, primitiveValue.crosshairThickness, primitiveValue.crosshairLength, primitiveValue.rotationAngle}
+
+		case *ThermalPrimitive:
+			code = "7"
+			modifiers = []ApertureMacroExpression{primitiveValue.centerX, primitiveValue.centerY, primitiveValue.outerDiameter, primitiveValue.innerDiameter, primitiveValue.gapThickness, primitiveValue.rotationAngle}
+
+		default:
+			return "",fmt.Errorf("Unable to write unknown aperture primitive type %T", primitive)
+	}
+
+	parts := make([]string, 0, len(modifiers) + 1)
+	parts = append(parts, code)
+	for _,modifier := range modifiers {
+		if formatted,err := formatExpression(modifier, false); err != nil {
+			return "",err
+		} else {
+			parts = append(parts, formatted)
+		}
+	}
+
+	return strings.Join(parts, ","),nil
+}
+
+// Writes an expression tree back out in infix form.  Every nested arithmetic expression is wrapped in parentheses,
+// so that the tree that comes back out of the parser has exactly the same shape, regardless of operator precedence
+func formatExpression(expr ApertureMacroExpression, nested bool) (string, error) {
+	switch exprValue := expr.(type) {
+		case *LiteralExpression:
+			return formatDecimal(exprValue.value),nil
+
+		case *VariableExpression:
+			return fmt.Sprintf("$%d", exprValue.variableNumber),nil
+
+		case *ArithmeticExpression:
+			var operator string
+			switch exprValue.operator {
+				case OPERATOR_ADD:
+					operator = "+"
+
+				case OPERATOR_SUBTRACT:
+					operator = "-"
+
+				case OPERATOR_MULTIPLY:
+					operator = "x"
+
+				case OPERATOR_DIVIDE:
+					operator = "/"
+
+				default:
+					return "",fmt.Errorf("Unable to write aperture macro expression with unknown operator %d", exprValue.operator)
+			}
+
+			lhs,err := formatExpression(exprValue.lhs, true)
+			if err != nil {
+				return "",err
+			}
+			rhs,err := formatExpression(exprValue.rhs, true)
+			if err != nil {
+				return "",err
+			}
+
+			formatted := lhs + operator + rhs
+			if nested {
+				return "(" + formatted + ")",nil
+			}
+			return formatted,nil
+
+		default:
+			return "",fmt.Errorf("Unable to write unknown aperture macro expression type %T", expr)
+	}
+}
+
+func (writer *gerberWriter) writeInterpolation(interpolation *Interpolation) error {
+	var block strings.Builder
+
+	if interpolation.fnCodeValid {
+		if code,err := functionCodeString(interpolation.fnCode); err != nil {
+			return err
+		} else {
+			block.WriteString(code)
+		}
+	}
+
+	if interpolation.xValid {
+		if err := writer.writeCoordinate(&block, "X", interpolation.x); err != nil {
+			return err
+		}
+	}
+
+	if interpolation.yValid {
+		if err := writer.writeCoordinate(&block, "Y", interpolation.y); err != nil {
+			return err
+		}
+	}
+
+	// Offsets that aren't given are parsed as 0, so we only need to write the non-zero ones
+	if interpolation.i != 0.0 {
+		if err := writer.writeCoordinate(&block, "I", interpolation.i); err != nil {
+			return err
+		}
+	}
+
+	if interpolation.j != 0.0 {
+		if err := writer.writeCoordinate(&block, "J", interpolation.j); err != nil {
+			return err
+		}
+	}
+
+	if interpolation.opCodeValid {
+		switch interpolation.opCode {
+			case INTERPOLATE_OPERATION:
+				block.WriteString("D01")
+
+			case MOVE_OPERATION:
+				block.WriteString("D02")
+
+			case FLASH_OPERATION:
+				block.WriteString("D03")
+
+			default:
+				return fmt.Errorf("Unable to write interpolation with unknown operation code %d", interpolation.opCode)
+		}
+	}
+
+	return writer.writeBlock(block.String())
+}
+
+func (writer *gerberWriter) writeCoordinate(block *strings.Builder, axis string, value float64) error {
+	if !writer.coordFormat.isSet {
+		return fmt.Errorf("Unable to write coordinate data before the coordinate format has been set")
+	}
+
+	if number,err := writer.coordFormat.Encode(value); err != nil {
+		return fmt.Errorf("Unable to write %s coordinate: %v", axis, err)
+	} else {
+		block.WriteString(axis)
+		block.WriteString(number)
+	}
+
+	return nil
+}
+
+func (writer *gerberWriter) writeParameter(parameter string) error {
+	_,err := io.WriteString(writer.out, "%" + parameter + "*%" + writer.lineEnding)
+	return err
+}
+
+func (writer *gerberWriter) writeBlock(block string) error {
+	_,err := io.WriteString(writer.out, block + "*" + writer.lineEnding)
+	return err
+}
+
+func functionCodeString(fnCode FunctionCode) (string, error) {
+	switch fnCode {
+		case LINEAR_INTERPOLATION:
+			return "G01",nil
+
+		case CIRCULAR_INTERPOLATION_CLOCKWISE:
+			return "G02",nil
+
+		case CIRCULAR_INTERPOLATION_COUNTER_CLOCKWISE:
+			return "G03",nil
+
+		case REGION_MODE_ON:
+			return "G36",nil
+
+		case REGION_MODE_OFF:
+			return "G37",nil
+
+		case SINGLE_QUADRANT_MODE:
+			return "G74",nil
+
+		case MULTI_QUADRANT_MODE:
+			return "G75",nil
+
+		case SET_UNIT_INCH:
+			return "G70",nil
+
+		case SET_UNIT_MM:
+			return "G71",nil
+
+		case SET_NOTATION_ABSOLUTE:
+			return "G90",nil
+
+		case SET_NOTATION_INCREMENTAL:
+			return "G91",nil
+
+		case PREPARE_FOR_FLASH:
+			return "G55",nil
+
+		case PROGRAM_STOP:
+			return "M00",nil
+
+		case OPTIONAL_STOP:
+			return "M01",nil
+
+		case END_OF_FILE:
+			return "M02",nil
+
+		default:
+			return "",fmt.Errorf("Unable to write unknown function code %d", fnCode)
+	}
+}
+
+func formatDecimal(value float64) string {
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
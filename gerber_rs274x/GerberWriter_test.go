@@ -0,0 +1,93 @@
+package gerber_rs274x
+
+import (
+	"path/filepath"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// Writes the data blocks out and parses them back in, which should give back exactly the same data blocks
+func checkRoundTrip(t *testing.T, name string, dataBlocks []DataBlock) {
+	t.Helper()
+
+	var written strings.Builder
+	if err := WriteGerber(&written, dataBlocks, WriteOptions{}); err != nil {
+		t.Errorf("%s: error writing: %v", name, err)
+		return
+	}
+
+	if reparsed,err := ParseGerberFileWithOptions(strings.NewReader(written.String()), ParseOptions{}); err != nil {
+		t.Errorf("%s: error parsing the written file: %v\n%s", name, err, written.String())
+	} else if !reflect.DeepEqual(dataBlocks, reparsed.DataBlocks) {
+		for index := range dataBlocks {
+			if (index >= len(reparsed.DataBlocks)) || !reflect.DeepEqual(dataBlocks[index], reparsed.DataBlocks[index]) {
+				t.Errorf("%s: data blocks differ from block %d after writing\n%s", name, index, written.String())
+				return
+			}
+		}
+		t.Errorf("%s: written file has %d data blocks, expected %d\n%s", name, len(reparsed.DataBlocks), len(dataBlocks), written.String())
+	}
+}
+
+func TestWriteFixtures(t *testing.T) {
+	fileNames,err := filepath.Glob("../testing/gerber-ex*.gbr")
+	if err != nil || len(fileNames) == 0 {
+		t.Fatalf("Unable to find the test fixtures: %v", err)
+	}
+
+	for _,fileName := range fileNames {
+		inputFile,err := os.Open(fileName)
+		if err != nil {
+			t.Fatalf("Error opening %s: %v", fileName, err)
+		}
+
+		// Some of the fixtures use deprecated codes, so they're parsed the same way as the written files are
+		parseResult,err := ParseGerberFileWithOptions(inputFile, ParseOptions{})
+		inputFile.Close()
+		if err != nil {
+			t.Errorf("Error parsing %s: %v", fileName, err)
+			continue
+		}
+
+		checkRoundTrip(t, filepath.Base(fileName), parseResult.DataBlocks)
+	}
+}
+
+func TestWriteRoundTrip(t *testing.T) {
+	testCases := []struct {
+		name string
+		contents string
+	}{
+		{"Leading zero omission", "%FSLAX24Y24*%%MOIN*%%ADD10C,0.01*%D10*X-12Y5D02*X123456Y-120000D01*M02*"},
+		{"Trailing zero omission", "%FSTAX24Y24*%%MOIN*%%ADD10C,0.01*%D10*X-12Y5D02*X123456Y-000001D01*M02*"},
+		{"One integer digit and no decimals", "%FSLAX10Y10*%%MOMM*%%ADD10C,0.5*%D10*X1Y-9D02*X0Y0D01*M02*"},
+		{"Seven integer and decimal digits", "%FSTAX77Y77*%%MOMM*%%ADD10C,0.5*%D10*X-12345671234567Y00000010000001D02*X1Y-1D01*M02*"},
+		{"Incremental notation", "%FSLIX36Y36*%%MOMM*%%ADD10C,0.5*%D10*X1000Y1000D02*X-500D01*Y-500D01*M02*"},
+		{"Arcs", "%FSLAX24Y24*%%MOIN*%%ADD10C,0.01*%D10*G75*X10000Y0D02*G03X-10000Y0I-10000J0D01*G74*G02X0Y-10000I10000J0D01*M02*"},
+		{"Macro expressions", "%AMTEST*0 Parentheses and negation*$3=-($1+$2)x2*$4=-0.5*1,1,$1x(2-$2),-$3,$4/-2*4,1,3,0,0,$1,-$2,($1+$2)/2,-(0.5x$1),0,0,-45*%%FSLAX24Y24*%%MOIN*%%ADD10TEST,0.1X0.02*%D10*X0Y0D03*M02*"},
+		{"Step and repeat", "%FSLAX24Y24*%%MOIN*%%ADD10R,0.1X0.1*%%SRX2Y3I0.5J0.4*%%LPD*%D10*X0Y0D03*%LPC*%X100Y100D03*%SR*%M02*"},
+		{"Deprecated unit codes", "%FSLAX24Y24*%G71*%ADD10C,0.5*%D10*X0Y0D02*X10000D01*G70*X20000Y10000D01*M02*"},
+	}
+
+	for _,testCase := range testCases {
+		if parseResult,err := ParseGerberFileWithOptions(strings.NewReader(testCase.contents), ParseOptions{}); err != nil {
+			t.Errorf("%s: error parsing: %v", testCase.name, err)
+		} else {
+			checkRoundTrip(t, testCase.name, parseResult.DataBlocks)
+		}
+	}
+}
+
+// Expressions that the parser could never have produced can't be written either
+func TestWriteUnknownExpression(t *testing.T) {
+	macro := &ApertureMacroParameter{paramCode: AM_PARAMETER, macroName: "BAD", dataBlocks: []ApertureMacroDataBlock{
+		&ApertureMacroVariableDefinition{variableNumber: 1, value: &ParenthesisExpression{LEFT_PARENTHESIS}},
+	}}
+
+	var written strings.Builder
+	if err := WriteGerber(&written, []DataBlock{macro}, WriteOptions{}); err == nil {
+		t.Errorf("Writing a macro with an unknown expression type succeeded, and wrote %q", written.String())
+	}
+}
@@ -1,16 +1,14 @@
 package gerber_rs274x
 
 import (
+	"errors"
 	"io"
-	"bufio"
-	"fmt"
+	"log/slog"
 	"regexp"
 	"math"
-	cairo "github.com/ungerik/go-cairo"
 )
 
 var coordDataBlockRegex *regexp.Regexp
-var parameterOrDataBlockRegex *regexp.Regexp
 var dataBlockRegex *regexp.Regexp
 var dCodeDataBlockRegex *regexp.Regexp
 var coordinateDataBlockRegex *regexp.Regexp
@@ -18,6 +16,7 @@ var fsParameterRegex *regexp.Regexp
 var srParameterRegex *regexp.Regexp
 var adParameterRegex *regexp.Regexp
 var amVariableDefinitionRegex *regexp.Regexp
+var attributeNameRegex *regexp.Regexp
 
 const ONE_HALF_PI = (math.Pi / 2.0)
 const THREE_HALVES_PI = ((math.Pi * 3.0) / 2.0)
@@ -32,17 +31,24 @@ const (
 	PARSING_PARAMETER
 )
 
-type CoordinateFormat struct {
-	numDigits int
-	numDecimals int
-	suppressTrailingZeros bool
-	isSet bool
-}
-
 type ParseEnvironment struct {
 	coordFormat CoordinateFormat
 	unitsSet bool
 	aperturesDefined map[int]bool
+	// Data blocks following a step and repeat parameter belong to that step and repeat block,
+	// until it is closed by an empty SR parameter, another SR parameter, or the end of the file
+	openStepAndRepeat *StepAndRepeatParameter
+	options ParseOptions
+	logger *slog.Logger
+	// Problems recovered from in lenient mode while parsing the current block
+	warnings []*categorizedError
+	// Set when lenient mode had to assume a coordinate format or units because the FS or MO parameter was missing
+	coordFormatAssumed bool
+	unitsAssumed bool
+	// Follows the attribute dictionaries, so that each draw and flash can carry its attributes
+	attributes *attributeTracker
+	// Converts each data block into the units the caller asked for, or nil if they didn't ask
+	unitConverter *unitConverter
 }
 
 type ScalingParms struct {
@@ -55,19 +61,13 @@ func init() {
 	// We compile all regular expressions we'll need for parsing into package global variables, so that we only have to compile
 	// them once, not every time they are needed
 
-	coordDataBlockRegex = regexp.MustCompile(`(?:X(?P<xCoord>-?[[:digit:]]*))?(?:Y(?P<yCoord>-?[[:digit:]]*))?(?:I(?P<iOffset>-?[[:digit:]]*))?(?:J(?P<jOffset>-?[[:digit:]]*))?`)
-	
-	// Regular expression that matches either a parameter block in between "%" characters, or a data block ended by a "*" character
-	// NOTE: The parameter matching part is tricky, since parameters can have multiple embedded data blocks (meaning multiple embedded "*" characters)
-	// but we still want to match the optional "*" character at the end of the parameter block but not capture it, so we use a non-greedy "*" matching clause
-	// inside the capturing expression, but a greedy "*" matching clause at the end of the parameter matching section
-	parameterOrDataBlockRegex = regexp.MustCompile("(?:%(?P<paramBlock>(?:[[:alnum:] -\\$&-\\)\\+-/:<-@[-`{-~]*\\**?)*)\\*?%)|(?:(?P<dataBlock>[[:alnum:] -\\$&-\\)\\+-/:<-@[-`{-~]*)\\*)")
+	coordDataBlockRegex = regexp.MustCompile(`(?:X(?P<xCoord>[+-]?[[:digit:].]*))?(?:Y(?P<yCoord>[+-]?[[:digit:].]*))?(?:I(?P<iOffset>[+-]?[[:digit:].]*))?(?:J(?P<jOffset>[+-]?[[:digit:].]*))?`)
 	
 	dataBlockRegex = regexp.MustCompile(`(?:(?P<fnLetter>G|M)(?P<fnCode>[[:digit:]]{1,2}))?(?P<restOfBlock>[[:alnum:][:punct:] ]*)`)
 	
-	dCodeDataBlockRegex = regexp.MustCompile(`(?P<restOfBlock>[XYIJ\-[:digit:]]*)(?:D(?P<dCode>[[:digit:]]{1,2}))?`)
+	dCodeDataBlockRegex = regexp.MustCompile(`(?P<restOfBlock>[XYIJ+\-.[:digit:]]*)(?:D(?P<dCode>[[:digit:]]{1,2}))?`)
 	
-	coordinateDataBlockRegex = regexp.MustCompile(`(?:X(?P<xCoord>-?[[:digit:]]*))?(?:Y(?P<yCoord>-?[[:digit:]]*))?(?:I(?P<iOffset>-?[[:digit:]]*))?(?:J(?P<jOffset>-?[[:digit:]]*))?`)
+	coordinateDataBlockRegex = regexp.MustCompile(`(?:X(?P<xCoord>[+-]?[[:digit:].]*))?(?:Y(?P<yCoord>[+-]?[[:digit:].]*))?(?:I(?P<iOffset>[+-]?[[:digit:].]*))?(?:J(?P<jOffset>[+-]?[[:digit:].]*))?`)
 	
 	fsParameterRegex = regexp.MustCompile(`(?P<zeroOmissionMode>L|T)(?P<coordinateNotation>A|I)X(?P<xIntPositions>[[:digit:]]{1})(?P<xDecPositions>[[:digit:]]{1})Y(?P<yIntPositions>[[:digit:]]{1})(?P<yDecPositions>[[:digit:]]{1})`)
 	
@@ -75,124 +75,83 @@ func init() {
 	
 	adParameterRegex = regexp.MustCompile(`D(?P<dCode>[[:digit:]]*)(?P<apertureType>[[:alnum:]_\+\-/\!\?<>"'\(\){}\.\\\|\&@# ]+),?(?P<modifiers>[[:digit:]\.X]*)`)
 	
-	amVariableDefinitionRegex = regexp.MustCompile(`\$(?P<varNum>[[:digit:]]+)=(?P<varExp>[[:digit:]$.+-x/]+)`)
+	amVariableDefinitionRegex = regexp.MustCompile(`\$(?P<varNum>[[:digit:]]+)=(?P<varExp>[[:digit:]$.()+-x/]+)`)
+	
+	attributeNameRegex = regexp.MustCompile(`^[._$[:alpha:]][._$[:alnum:]]*$`)
 }
 
+// Parses a whole gerber file in lenient mode.  Blocks that fail to parse are left out of the returned slice, and every
+// problem found is returned together as a ParseErrors, so callers get back everything that could be parsed,
+// along with the position of each broken block.  Use errors.As to get at the individual *ParseError values.
+// Lenient mode recovers from problems by guessing: a missing FS parameter is assumed to be LAX24Y24, missing units
+// are assumed to be inches, and deprecated parameters that change the image (AS, IN, IP, IR, LN, MI, OF and SF) are
+// skipped.  The warnings saying so are thrown away here, so the result can look right while being wrong.
+// Use ParseGerberFileWithOptions to get the warnings back in the result, or to parse in strict mode instead
 func ParseGerberFile(in io.Reader) (parsedFile []DataBlock, err error) {
-	scanner := bufio.NewScanner(in)
-	scanner.Split(bufio.ScanLines)
-	fileString := ""
-	for scanner.Scan() {
-		fileString += scanner.Text()
-	}
-	
-	if err := scanner.Err(); err != nil {
-		return nil,fmt.Errorf("Error encountered while reading file: %v\n", err)
-	} 
+	result,err := ParseGerberFileWithOptions(in, ParseOptions{})
 	
-	results := parameterOrDataBlockRegex.FindAllStringSubmatch(fileString, -1)
+	return result.DataBlocks,err
+}
+
+// Parses a whole gerber file with the given options.  The returned result is never nil, and holds everything that
+// could be parsed, along with any warnings, even if errors were found.  Errors are returned the same way as ParseGerberFile
+func ParseGerberFileWithOptions(in io.Reader, options ParseOptions) (*ParseResult, error) {
+	decoder := NewDecoderWithOptions(in, options)
+	var parseErrors ParseErrors
 	
-	// Set up the variables we'll need for parsing
+	result := new(ParseResult)
 	// We'll start with a default size of 100 for now
 	// The slice will grow as necessary during parsing
-	parseEnv := newParseEnv()
-	parsedFile = make([]DataBlock, 0, 100)
-	
-	for index,submatch := range results {
-		if len(submatch) != 3 {
-			return nil,fmt.Errorf("Error (token %d): Parse error on command %v\n", index, submatch)
-		}
-		
-		if len(submatch[1]) > 0 {
-			// Parsing Parameter
-			fmt.Printf("Token %d, Parsed parameter: %s\n", index, submatch[1])
-			if parameter,err := parseParameter(submatch[1], parseEnv); err != nil {
-				fmt.Printf("Parse error for parameter %s: %s\n", submatch[1], err.Error())
+	result.DataBlocks = make([]DataBlock, 0, 100)
+	
+	for {
+		dataBlock,err := decoder.Next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			var parseError *ParseError
+			if errors.As(err, &parseError) {
+				parseErrors = append(parseErrors, parseError)
 			} else {
-				parsedFile = append(parsedFile, parameter)
+				// The decoder should only ever return ParseErrors, but if anything else gets through,
+				// we can't tell where it came from, so we give up on reading any more of the file
+				parseErrors = append(parseErrors, &ParseError{
+					TokenIndex: decoder.tokenIndex,
+					Line: decoder.tokenizer.line,
+					Column: decoder.tokenizer.column,
+					Category: READ_ERROR,
+					Err: err,
+				})
+				break
 			}
-		} else if len(submatch[2]) > 0 {
-			// Parsing non-parameter data block
-			if dataBlock,err := parseDataBlock(submatch[2], parseEnv); err != nil {
-				fmt.Printf("Parse Error for block %s: %s\n", submatch[2], err.Error())
-			} else {
-				parsedFile = append(parsedFile, dataBlock)
+			if decoder.readErr != nil {
+				// Nothing more can be read from the file
+				break
 			}
 		} else {
-			return nil,fmt.Errorf("Error (token %d): Not parameter or data block: %v\n", index, submatch)
-		}
-	}
-	
-	for index,dataBlock := range parsedFile {
-		fmt.Printf("Parsed data block %3d: %v\n", index, dataBlock)
-	}
-	
-	return parsedFile,nil
-}
-
-func GenerateSurface(outFileName string, parsedFile []DataBlock) error {
-	
-	width := 800
-	height := 800
-	
-	// First, need to do a full render of the file, just keeping track of the bounds
-	// of the generated image, so we can do the proper scaling when we render it for real
-	gfxStateBounds := newGraphicsState(nil, 0, 0)
-	bounds := newImageBounds()
-	
-	for _,dataBlock := range parsedFile {
-		if err := dataBlock.ProcessDataBlockBoundsCheck(bounds, gfxStateBounds); err != nil {
-			return err
+			result.DataBlocks = append(result.DataBlocks, dataBlock)
 		}
 	}
 	
-	fmt.Printf("X Bounds: (%f %f) Y Bounds: (%f %f)\n", bounds.xMin, bounds.xMax, bounds.yMin, bounds.yMax)
-	
-	// Set up the graphics state for the actual drawing
-	gfxState := newGraphicsState(bounds, width, height)
-	
-	// Construct the surface we're drawing to
-	surface := cairo.NewSurface(cairo.FORMAT_ARGB32, width, height)
-	surface.SetAntialias(cairo.ANTIALIAS_NONE)
+	result.Warnings = decoder.Warnings()
+	result.FileAttributes = decoder.FileAttributes()
 	
-	// This is important for regions with cut-ins.  If we leave the fill rule the default (winding),
-	// cut-ins don't render correctly
-	surface.SetFillRule(cairo.FILL_RULE_EVEN_ODD)
-	// Invert the Y-axis.  This is to correct for the difference in coordinate frames between the gerber file and cairo
-	surface.Scale(1.0, -1.0)
-	surface.Translate(0.0, float64(-height))
-	// Apply the x and y offsets as translations to the surface
-	surface.Translate(gfxState.xOffset, gfxState.yOffset)
-	
-	// Push the surface state onto the stack before we scale it, so we can selectively remove the scaling later
-	// (used for drawing apertures onto the surface, because apertures are pre-rendered to their own surfaces
-	// and are already scaled, so we don't want to apply scaling twice)
-	surface.Save()
-	surface.Scale(gfxState.scaleFactor, gfxState.scaleFactor)
-	
-	for _,dataBlock := range parsedFile {
-		if err := dataBlock.ProcessDataBlockSurface(surface, gfxState); err != nil {
-			gfxState.releaseRenderedSurfaces()
-			surface.Finish()
-			return err
-		}
+	if len(parseErrors) > 0 {
+		return result,parseErrors
 	}
-	gfxState.releaseRenderedSurfaces()
-	
-	surface.WriteToPNG(outFileName)
-	surface.Finish()
 	
-	// Make sure that the entire file was rendered
-	if !gfxState.fileComplete {
-		return fmt.Errorf("Render of file completed without reaching end of file code (M02)")
-	}
-	
-	return nil
+	return result,nil
 }
 
-func newParseEnv() *ParseEnvironment {
+func newParseEnv(options ParseOptions) *ParseEnvironment {
 	parseEnv := new(ParseEnvironment)
+	parseEnv.options = options
+	parseEnv.logger = loggerOrDiscard(options.Logger)
 	parseEnv.aperturesDefined = make(map[int]bool, 10) // We'll start with an initial capacity of 10, it will grow as necessary
+	parseEnv.attributes = newAttributeTracker()
+	if options.NormalizeUnits {
+		parseEnv.unitConverter = newUnitConverter(options.Units)
+	}
 	
 	return parseEnv
 }
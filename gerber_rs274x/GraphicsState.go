@@ -1,8 +1,7 @@
 package gerber_rs274x
 
 import (
-	"math"
-	cairo "github.com/ungerik/go-cairo"
+	"log/slog"
 )
 
 type GraphicsState struct {
@@ -13,12 +12,13 @@ type GraphicsState struct {
 	currentY float64
 	currentLevelPolarity Polarity
 	regionModeOn bool
-	xImageSize int
-	yImageSize int
+	// Whether the current region contour has been started in the renderer's path yet
+	contourStarted bool
 	fileComplete bool
 	coordinateNotation CoordinateNotation
+	// Inches until an MO parameter says otherwise (the same assumption lenient parsing makes)
+	units Units
 	filePrecision float64
-	ScalingParms
 	
 	// As we encounter aperture definitions, we save them
 	// for later use while drawing
@@ -26,16 +26,6 @@ type GraphicsState struct {
 	// We also need to remember aperture macro definitions, so that we can recall them when they are
 	// referenced in aperture definition parameters
 	apertureMacros map[string][]ApertureMacroDataBlock
-	// The first time an aperture is rendered, we render it to a cairo surface
-	// Then, we can just look up the rendered aperture the next time we need it
-	// This should provide for some optimization, since the same aperture will
-	// get used over and over to stroke a path
-	renderedApertures map[int]*cairo.Surface
-	// We also need to save apertures rendered without their holes (if they have holes), for use in certain
-	// optimized stroke drawing routines.  If a particular aperture doesn't have a hole, a reference to the same
-	// surface as is stored in the renderedApertures map is stored here, to save on memory.  Else, a new rendered
-	// surface without the hole is stored here
-	renderedAperturesNoHoles map[int]*cairo.Surface
 	
 	// Some of these default to undefined,
 	// so we also need to keep track of when they get defined
@@ -43,44 +33,34 @@ type GraphicsState struct {
 	quadrantModeSet bool
 	interpolationModeSet bool
 	coordinateNotationSet bool
+	
+	// The file attributes set so far, and the attributes of the draw or flash being processed
+	fileAttributes Attributes
+	objectAttributes Attributes
+	
+	logger *slog.Logger
+	// If set, rendered apertures are written out here for debugging
+	apertureDebugDir string
 }
 
-func newGraphicsState(bounds *ImageBounds, xImageSize int, yImageSize int) *GraphicsState {
+func newGraphicsState() *GraphicsState {
 	graphicsState := new(GraphicsState)
 	
 	graphicsState.currentLevelPolarity = DARK_POLARITY
+	graphicsState.logger = loggerOrDiscard(nil)
 	graphicsState.apertures = make(map[int]Aperture, 10) // Start with an initial capacity of 10 apertures, will grow as needed
-	graphicsState.renderedApertures = make(map[int]*cairo.Surface, 10) // Same as above
-	graphicsState.renderedAperturesNoHoles = make(map[int]*cairo.Surface, 10) // Same as above
 	graphicsState.apertureMacros = make(map[string][]ApertureMacroDataBlock, 10) // Same as above
 	
-	if bounds != nil {
-		// If bounds are provided, compute the necessary scaling information
-		xSpan := bounds.xMax - bounds.xMin
-		ySpan := bounds.yMax - bounds.yMin
-		
-		// Build 5% margin on each side into the scaling
-		xMargin := float64(xImageSize) * 0.1
-		yMargin := float64(yImageSize) * 0.1
-		
-		// Compute the appropriate scaling factor
-		xScale := (float64(xImageSize) - xMargin) / xSpan
-		yScale := (float64(yImageSize) - yMargin) / ySpan
-		graphicsState.scaleFactor = math.Min(xScale, yScale)
-		
-		// Compute offsets to apply to all coordinates to start them at zero and account for margins
-		graphicsState.xOffset = -(bounds.xMin * graphicsState.scaleFactor) + (xMargin / 2.0)
-		graphicsState.yOffset = -(bounds.yMin * graphicsState.scaleFactor) + (yMargin / 2.0)
-	}
-	
 	// All other settings are fine with their go defaults
 	// Current aperture: Doesn't matter since it's undefined by default
 	// Current quadrant mode: Doesn't matter since it's undefined by default
 	// Current interpolation mode: Doesn't matter since it's undefined by default
 	// Coordinate notation: Doesn't matter since it's undefined by default
+	// Units: inches is correct
 	// Current x: 0 is correct
 	// Current y: 0 is correct
 	// Region mode on: false is correct
+	// Contour started: false is correct
 	// Aperture set: false is correct
 	// Quadrant mode set: false is correct
 	// Interpolation mode set: false is correct
@@ -96,30 +76,17 @@ func (gfxState *GraphicsState) updateCurrentCoordinate(newX float64, newY float6
 	gfxState.currentY = newY
 }
 
-func (gfxState *GraphicsState) releaseRenderedSurfaces() {
+// The units the coordinates and aperture sizes are in, as set by the MO parameter (or the deprecated G70 and G71 codes)
+func (gfxState *GraphicsState) GetUnits() Units {
+	return gfxState.units
+}
+
+// The file attributes (TF parameters) processed so far
+func (gfxState *GraphicsState) GetFileAttributes() Attributes {
+	return gfxState.fileAttributes
+}
 
-	// NOTE: For the purposes of this iteration, we assume that the keyset of renderedApertures is a strict superset
-	// of the keyset of renderedAperturesNoHoles (which should always be the case).  If this assumption is broken,
-	// the iteration may miss releasing some surfaces in the renderedAperturesNoHoles map
-	for apertureNumber,surface1 := range gfxState.renderedApertures {
-		if surface2,found := gfxState.renderedAperturesNoHoles[apertureNumber]; found {
-			// This aperture has been rendered into both the holes and no-holes map
-			// Next, we need to check whether both surfaces actually refer to the same object,
-			// and if so, only release it once
-			if surface1 == surface2 {
-				surface1.Finish()
-				surface1.Destroy()
-			} else {
-				// Else, they're two separate objects and we must release them both
-				surface1.Finish()
-				surface1.Destroy()
-				surface2.Finish()
-				surface2.Destroy()
-			}
-		} else {
-			// This aperture was only rendered into the holes map
-			surface1.Finish()
-			surface1.Destroy()
-		}
-	}
+// The attributes of the draw or flash currently being processed
+func (gfxState *GraphicsState) GetObjectAttributes() Attributes {
+	return gfxState.objectAttributes
 }
\ No newline at end of file
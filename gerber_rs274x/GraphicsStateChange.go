@@ -2,7 +2,6 @@ package gerber_rs274x
 
 import (
 	"fmt"
-	cairo "github.com/ungerik/go-cairo"
 )
 
 type GraphicsStateChange struct {
@@ -13,6 +12,10 @@ func (graphicsStateChange *GraphicsStateChange) DataBlockPlaceholder() {
 
 }
 
+func (graphicsStateChange *GraphicsStateChange) Accept(visitor Visitor) error {
+	return visitor.VisitGraphicsStateChange(graphicsStateChange)
+}
+
 func (graphicsStateChange *GraphicsStateChange) ProcessDataBlockBoundsCheck(imageBounds *ImageBounds, gfxState *GraphicsState) error {
 	switch graphicsStateChange.fnCode {
 		case SINGLE_QUADRANT_MODE, MULTI_QUADRANT_MODE:
@@ -27,13 +30,26 @@ func (graphicsStateChange *GraphicsStateChange) ProcessDataBlockBoundsCheck(imag
 		case END_OF_FILE:
 			gfxState.fileComplete = true
 			
+		case SET_UNIT_INCH:
+			gfxState.units = UNITS_IN
+			
+		case SET_UNIT_MM:
+			gfxState.units = UNITS_MM
+			
+		case SET_NOTATION_ABSOLUTE:
+			gfxState.coordinateNotation = ABSOLUTE_NOTATION
+			
+		case SET_NOTATION_INCREMENTAL:
+			// From here on, coordinates are offsets from the current point (until a G90 switches back)
+			gfxState.coordinateNotation = INCREMENTAL_NOTATION
+			
 		// For now, we're not going to do anything with any of the other ones
 	}
 	
 	return nil
 }
 
-func (graphicsStateChange *GraphicsStateChange) ProcessDataBlockSurface(surface *cairo.Surface, gfxState *GraphicsState) error {
+func (graphicsStateChange *GraphicsStateChange) ProcessDataBlockRender(renderer Renderer, gfxState *GraphicsState) error {
 	switch graphicsStateChange.fnCode {
 		case SINGLE_QUADRANT_MODE, MULTI_QUADRANT_MODE:
 			gfxState.currentQuadrantMode = graphicsStateChange.fnCode
@@ -44,18 +60,25 @@ func (graphicsStateChange *GraphicsStateChange) ProcessDataBlockSurface(surface
 		case REGION_MODE_OFF:
 			gfxState.regionModeOn = false
 			// If we're turning region mode off, we need to close and draw any contours in progress
-			switch gfxState.currentLevelPolarity {
-				case DARK_POLARITY:
-					surface.SetSourceRGBA(0.0, 0.0, 0.0, 1.0)
-				
-				case CLEAR_POLARITY:
-					surface.SetSourceRGBA(1.0, 1.0, 1.0, 1.0)
-			}
-			surface.Fill()
+			renderer.Fill()
+			gfxState.contourStarted = false
 			
 		case END_OF_FILE:
 			gfxState.fileComplete = true
 			
+		case SET_UNIT_INCH:
+			gfxState.units = UNITS_IN
+			
+		case SET_UNIT_MM:
+			gfxState.units = UNITS_MM
+			
+		case SET_NOTATION_ABSOLUTE:
+			gfxState.coordinateNotation = ABSOLUTE_NOTATION
+			
+		case SET_NOTATION_INCREMENTAL:
+			// From here on, coordinates are offsets from the current point (until a G90 switches back)
+			gfxState.coordinateNotation = INCREMENTAL_NOTATION
+			
 		// For now, we're not going to do anything with any of the other ones
 	}
 	
@@ -104,4 +127,8 @@ func (graphicsStateChange *GraphicsStateChange) String() string {
 	}
 	
 	return fmt.Sprintf("{STATE CHANGE, Function: %s}", function)
-}
\ No newline at end of file
+}
+
+func (graphicsStateChange *GraphicsStateChange) GetFunctionCode() FunctionCode {
+	return graphicsStateChange.fnCode
+}
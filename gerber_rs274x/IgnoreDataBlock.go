@@ -2,7 +2,6 @@ package gerber_rs274x
 
 import (
 	"fmt"
-	cairo "github.com/ungerik/go-cairo"
 )
 
 type IgnoreDataBlock struct {
@@ -13,16 +12,25 @@ func (ignoreDataBlock *IgnoreDataBlock) DataBlockPlaceholder() {
 
 }
 
+func (ignoreDataBlock *IgnoreDataBlock) Accept(visitor Visitor) error {
+	return visitor.VisitComment(ignoreDataBlock)
+}
+
 func (ignoreDataBlock *IgnoreDataBlock) ProcessDataBlockBoundsCheck(imageBounds *ImageBounds, gfxState *GraphicsState) error {
 	// This is a comment, so it doesn't change the graphics state or draw anything
 	return nil
 }
 
-func (ignoreDataBlock *IgnoreDataBlock) ProcessDataBlockSurface(surface *cairo.Surface, gfxState *GraphicsState) error {
+func (ignoreDataBlock *IgnoreDataBlock) ProcessDataBlockRender(renderer Renderer, gfxState *GraphicsState) error {
 	// This is a comment, so it doesn't change the graphics state or draw anything
 	return nil
 }
 
 func (ignoreDataBlock *IgnoreDataBlock) String() string {
 	return fmt.Sprintf("{COMMENT, %s}", ignoreDataBlock.comment)
-}
\ No newline at end of file
+}
+
+// The text of the comment, including any whitespace that followed the G04 code
+func (ignoreDataBlock *IgnoreDataBlock) GetComment() string {
+	return ignoreDataBlock.comment
+}
@@ -42,12 +42,19 @@ func (bounds *ImageBounds) updateBounds(xMin float64, xMax float64, yMin float64
 	}
 }
 
-func (bounds *ImageBounds) updateBoundsAperture(currentX float64, currentY float64, apertureMinSize float64) {
-	// Calculate the extents from the current point and the aperture min size
-	xMin := currentX - apertureMinSize
-	xMax := currentX + apertureMinSize
-	yMin := currentY - apertureMinSize
-	yMax := currentY + apertureMinSize
+// Updates the bounds with an aperture placed at the current point, where the aperture extent is the bounds of the
+// aperture when it's flashed at the origin.  If the extent is nil, only the point itself is included
+func (bounds *ImageBounds) updateBoundsAperture(currentX float64, currentY float64, apertureExtent *ImageBounds) {
+	if apertureExtent == nil {
+		bounds.updateBounds(currentX, currentX, currentY, currentY)
+		return
+	}
+	
+	// Calculate the extents from the current point and the aperture extent (the mins are never positive)
+	xMin := currentX + apertureExtent.xMin
+	xMax := currentX + apertureExtent.xMax
+	yMin := currentY + apertureExtent.yMin
+	yMax := currentY + apertureExtent.yMax
 	
 	// Use update bounds to do the actual work
 	bounds.updateBounds(xMin, xMax, yMin, yMax)
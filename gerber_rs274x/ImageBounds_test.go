@@ -0,0 +1,99 @@
+package gerber_rs274x
+
+import (
+	"io"
+	"math"
+	"os"
+	"strings"
+	"testing"
+)
+
+// The bounds of a region made of arcs have to reach out to wherever the arcs cross the axes through their centers,
+// not just to the ends of the arcs
+func TestArcRegionBounds(t *testing.T) {
+	testCases := []struct {
+		fileName string
+		xMin, xMax, yMin, yMax float64
+	}{
+		// One full circle in multi quadrant mode, starting and ending on the positive x axis
+		{"gerber-ex16.gbr", -50.0, 50.0, -50.0, 50.0},
+		// Four quarter circles in single quadrant mode, each one ending where the next axis is crossed
+		{"gerber-ex17.gbr", -50.0, 50.0, -50.0, 50.0},
+		// A half circle in multi quadrant mode, whose ends are both on the y axis but which bulges out across the x axis
+		{"gerber-ex18.gbr", 0.0, 1.0, -1.0, 1.0},
+	}
+
+	for _,testCase := range testCases {
+		bounds := parseAndComputeBounds(t, "../testing/" + testCase.fileName)
+
+		got := []float64{bounds.xMin, bounds.xMax, bounds.yMin, bounds.yMax}
+		expected := []float64{testCase.xMin, testCase.xMax, testCase.yMin, testCase.yMax}
+		for index := range got {
+			if math.Abs(got[index] - expected[index]) > 1e-9 {
+				t.Errorf("%s: bounds are (xMin, xMax, yMin, yMax) %v, expected %v", testCase.fileName, got, expected)
+				break
+			}
+		}
+	}
+}
+
+// Draws reach out as far as the aperture does in each direction, which for anything but a circle is further than its
+// smallest size
+func TestStrokeBounds(t *testing.T) {
+	testCases := []struct {
+		name string
+		contents string
+		xMin, xMax, yMin, yMax float64
+	}{
+		{"Obround line", "%FSLAX24Y24*%%MOIN*%%ADD10O,0.1000X0.0200*%D10*X0Y0D02*X10000D01*M02*", -0.05, 1.05, -0.01, 0.01},
+		{"Rectangle line", "%FSLAX24Y24*%%MOIN*%%ADD10R,0.0200X0.1000*%D10*X0Y0D02*Y10000D01*M02*", -0.01, 0.01, -0.05, 1.05},
+		// A quarter circle from the positive x axis to the positive y axis, which doesn't cross any axes in between
+		{"Obround arc", "%FSLAX24Y24*%%MOIN*%%ADD10O,0.1000X0.0200*%D10*G75*X10000Y0D02*G03X0Y10000I-10000J0D01*M02*", -0.05, 1.05, -0.01, 1.01},
+		// A half circle from the positive x axis to the negative x axis, over the top of the positive y axis
+		{"Rectangle arc", "%FSLAX24Y24*%%MOIN*%%ADD10R,0.1000X0.0400*%D10*G75*X10000Y0D02*G03X-10000Y0I-10000J0D01*M02*", -1.05, 1.05, -0.02, 1.02},
+		{"Rectangle flash", "%FSLAX24Y24*%%MOIN*%%ADD10R,0.1000X0.0400*%D10*X10000Y10000D03*M02*", 0.95, 1.05, 0.98, 1.02},
+		// Outlines with vertices on both sides of the origin, turned a quarter turn counter-clockwise about the origin
+		{"Rotated outline flash", "%FSLAX24Y24*%%MOIN*%%AMBOX*4,1,4,-0.1,-0.05,0.1,-0.05,0.1,0.05,-0.1,0.05,-0.1,-0.05,90*%%ADD10BOX*%D10*X10000Y10000D03*M02*", 0.95, 1.05, 0.9, 1.1},
+		{"Rotated triangle flash", "%FSLAX24Y24*%%MOIN*%%AMTRI*4,1,3,-0.3,-0.1,0.1,-0.1,0.1,0.2,-0.3,-0.1,90*%%ADD10TRI*%D10*X0Y0D03*M02*", -0.2, 0.1, -0.3, 0.1},
+		{"Rotated outline draw", "%FSLAX24Y24*%%MOIN*%%AMTRI*4,1,3,-0.3,-0.1,0.1,-0.1,0.1,0.2,-0.3,-0.1,90*%%ADD10TRI*%D10*X0Y0D02*X10000D01*M02*", -0.2, 1.1, -0.3, 0.1},
+	}
+
+	for _,testCase := range testCases {
+		bounds := parseReaderAndComputeBounds(t, testCase.name, strings.NewReader(testCase.contents))
+
+		got := []float64{bounds.xMin, bounds.xMax, bounds.yMin, bounds.yMax}
+		expected := []float64{testCase.xMin, testCase.xMax, testCase.yMin, testCase.yMax}
+		for index := range got {
+			if math.Abs(got[index] - expected[index]) > 1e-9 {
+				t.Errorf("%s: bounds are (xMin, xMax, yMin, yMax) %v, expected %v", testCase.name, got, expected)
+				break
+			}
+		}
+	}
+}
+
+func parseAndComputeBounds(t *testing.T, fileName string) *ImageBounds {
+	t.Helper()
+
+	inputFile,err := os.Open(fileName)
+	if err != nil {
+		t.Fatalf("Error opening %s: %v", fileName, err)
+	}
+	defer inputFile.Close()
+
+	return parseReaderAndComputeBounds(t, fileName, inputFile)
+}
+
+func parseReaderAndComputeBounds(t *testing.T, fileName string, reader io.Reader) *ImageBounds {
+	t.Helper()
+
+	if parseResult,err := ParseGerberFileWithOptions(reader, ParseOptions{Strict: true}); err != nil {
+		t.Fatalf("Error parsing %s: %v", fileName, err)
+	} else if bounds,_,err := computeImageBounds(parseResult.DataBlocks, RenderOptions{}); err != nil {
+		t.Fatalf("Error computing the bounds of %s: %v", fileName, err)
+	} else {
+		return bounds
+	}
+
+	return nil
+}
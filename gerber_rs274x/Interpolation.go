@@ -3,7 +3,6 @@ package gerber_rs274x
 import (
 	"fmt"
 	"math"
-	cairo "github.com/ungerik/go-cairo"
 )
 
 type Interpolation struct {
@@ -17,13 +16,21 @@ type Interpolation struct {
 	opCodeValid bool
 	xValid bool
 	yValid bool
+	// The aperture and object attributes in effect for a draw or flash, attached while parsing
+	attributes Attributes
 }
 
 func (interpolation *Interpolation) DataBlockPlaceholder() {
 
 }
 
+func (interpolation *Interpolation) Accept(visitor Visitor) error {
+	return visitor.VisitInterpolation(interpolation)
+}
+
 func (interpolation *Interpolation) ProcessDataBlockBoundsCheck(bounds *ImageBounds, gfxState *GraphicsState) error {
+	gfxState.objectAttributes = interpolation.attributes
+	
 	// First, if this interpolation has a valid function code, update the graphics state
 	if interpolation.fnCodeValid {
 		switch interpolation.fnCode {
@@ -40,282 +47,49 @@ func (interpolation *Interpolation) ProcessDataBlockBoundsCheck(bounds *ImageBou
 		} else {
 			switch interpolation.opCode {
 				case INTERPOLATE_OPERATION:
-					if !gfxState.apertureSet {
-						return fmt.Errorf("Attempt to check interpolation bounds before aperture set")
-					}
-					
-					if aperture,found := gfxState.apertures[gfxState.currentAperture]; !found {
-						return fmt.Errorf("Attempt to use aperture %d in bounds check before it has been defined", gfxState.currentAperture)
+					if gfxState.regionModeOn {
+						// Region contours are filled rather than drawn with the aperture, so only the contour itself
+						// counts towards the bounds (and there doesn't need to be an aperture set at all)
+						updateBoundsSegment(bounds, gfxState, move, nil)
+						
+						// Update the graphics state with the new end coordinate
+						gfxState.updateCurrentCoordinate(move.newX, move.newY)
 					} else {
-						apertureMinSize := aperture.GetMinSize(gfxState)
+						if !gfxState.apertureSet {
+							return fmt.Errorf("Attempt to check interpolation bounds before aperture set")
+						}
 						
-						if gfxState.regionModeOn {
-							//TODO: Do a better job than this, this is just a quick hack
-							//It works for linear segments, but not for arcs
-							xMin := math.Min(gfxState.currentX, move.newX)
-							xMax := math.Max(gfxState.currentX, move.newX)
-							yMin := math.Min(gfxState.currentY, move.newY)
-							yMax := math.Max(gfxState.currentY, move.newY)
-							bounds.updateBounds(xMin, xMax, yMin, yMax)
+						if aperture,found := gfxState.apertures[gfxState.currentAperture]; !found {
+							return fmt.Errorf("Attempt to use aperture %d in bounds check before it has been defined", gfxState.currentAperture)
+						} else if apertureExtent,err := getApertureExtent(aperture, gfxState); err != nil {
+							return err
+						} else {
+							// The aperture isn't always round, so it can reach further out in some directions than others,
+							// and it's placed at every point along the segment without turning
+							updateBoundsSegment(bounds, gfxState, move, apertureExtent)
 							
-							// Update the graphics state with the new end coordinate
+							// Finally, update the graphics state with the new end coordinate
 							gfxState.updateCurrentCoordinate(move.newX, move.newY)
-						} else {
-							switch gfxState.currentInterpolationMode {
-								case LINEAR_INTERPOLATION:
-									// Update the bounds with both endpoints
-									bounds.updateBoundsAperture(gfxState.currentX, gfxState.currentY, apertureMinSize)
-									bounds.updateBoundsAperture(move.newX, move.newY, apertureMinSize)
-									
-									// Finally, update the graphics state with the new end coordinate
-									gfxState.updateCurrentCoordinate(move.newX, move.newY)
-									
-								case CIRCULAR_INTERPOLATION_CLOCKWISE, CIRCULAR_INTERPOLATION_COUNTER_CLOCKWISE:
-									radius := math.Hypot(move.newX - move.centerX, move.newY - move.centerY)
-									
-									// Update the bounds with both endpoints
-									bounds.updateBoundsAperture(gfxState.currentX, gfxState.currentY, apertureMinSize)
-									bounds.updateBoundsAperture(move.newX, move.newY, apertureMinSize)
-									
-									// Special case, if the angles are equal, and we're in multi quadrant mode, we're drawing a full circle,
-									// so the arc spans all of the axes
-									if epsilonEquals(move.startAngle, move.endAngle, gfxState.filePrecision) && (gfxState.currentQuadrantMode == MULTI_QUADRANT_MODE) {
-										bounds.updateBoundsAperture(move.centerX, move.centerY + radius, apertureMinSize) // positive y-axis
-										bounds.updateBoundsAperture(move.centerX + radius, move.centerY, apertureMinSize) // positive x-axis
-										bounds.updateBoundsAperture(move.centerX, move.centerY - radius, apertureMinSize) // negative y-axis
-										bounds.updateBoundsAperture(move.centerX - radius, move.centerY, apertureMinSize) // negative x-axis
-									} else {
-										// Otherwise, if the two angles span one (or more, depending on quadrant mode) of the axes, also update the bounds with the point
-										// along that axis at a distance of the radius of the arc (the max distance in that direction that the arc will cover)
-										switch gfxState.currentQuadrantMode {
-											case SINGLE_QUADRANT_MODE:
-												if gfxState.currentInterpolationMode == CIRCULAR_INTERPOLATION_CLOCKWISE {
-													if inQuadrant(move.startAngle, QUADRANT_2) && inQuadrant(move.endAngle, QUADRANT_1) {
-														// The angle spans the positive y-axis
-														bounds.updateBoundsAperture(move.centerX, move.centerY + radius, apertureMinSize)
-													}
-													
-													if inQuadrant(move.startAngle, QUADRANT_1) && inQuadrant(move.endAngle, QUADRANT_4) {
-														// The angle spans the positive x-axis
-														bounds.updateBoundsAperture(move.centerX + radius, move.centerY, apertureMinSize)
-													}
-													
-													if inQuadrant(move.startAngle, QUADRANT_4) && inQuadrant(move.endAngle, QUADRANT_3) {
-														// The angle spans the negative y-axis
-														bounds.updateBoundsAperture(move.centerX, move.centerY - radius, apertureMinSize)
-													}
-													
-													if inQuadrant(move.startAngle, QUADRANT_3) && inQuadrant(move.endAngle, QUADRANT_2) {
-														// The angle spans the negative x-axis
-														bounds.updateBoundsAperture(move.centerX - radius, move.centerY, apertureMinSize)
-													}
-												} else {
-													if inQuadrant(move.startAngle, QUADRANT_1) && inQuadrant(move.endAngle, QUADRANT_2) {
-														// The angle spans the positive y-axis
-														bounds.updateBoundsAperture(move.centerX, move.centerY + radius, apertureMinSize)
-													}
-													
-													if inQuadrant(move.startAngle, QUADRANT_4) && inQuadrant(move.endAngle, QUADRANT_1) {
-														// The angle spans the positive x-axis
-														bounds.updateBoundsAperture(move.centerX + radius, move.centerY, apertureMinSize)
-													}
-													
-													if inQuadrant(move.startAngle, QUADRANT_3) && inQuadrant(move.endAngle, QUADRANT_4) {
-														// The angle spans the negative y-axis
-														bounds.updateBoundsAperture(move.centerX, move.centerY - radius, apertureMinSize)
-													}
-													
-													if inQuadrant(move.startAngle, QUADRANT_2) && inQuadrant(move.endAngle, QUADRANT_3) {
-														// The angle spans the negative x-axis
-														bounds.updateBoundsAperture(move.centerX - radius, move.centerY, apertureMinSize)
-													}
-												}
-											
-											case MULTI_QUADRANT_MODE:
-												if gfxState.currentInterpolationMode == CIRCULAR_INTERPOLATION_CLOCKWISE {
-													if inQuadrant(move.startAngle, QUADRANT_1) {
-														if inQuadrant(move.endAngle, QUADRANT_4) {
-															// The angle spans the positive x-axis
-															bounds.updateBoundsAperture(move.centerX + radius, move.centerY, apertureMinSize)
-														} else if inQuadrant(move.endAngle, QUADRANT_3) {
-															// The angle spans the positive x-axis and negative y-axis
-															bounds.updateBoundsAperture(move.centerX + radius, move.centerY, apertureMinSize)
-															bounds.updateBoundsAperture(move.centerX, move.centerY - radius, apertureMinSize)
-														} else if inQuadrant(move.endAngle, QUADRANT_4) {
-															// The angle spans the positive x-axis, negative y-axis, and negative x-axis
-															bounds.updateBoundsAperture(move.centerX + radius, move.centerY, apertureMinSize)
-															bounds.updateBoundsAperture(move.centerX, move.centerY - radius, apertureMinSize)
-															bounds.updateBoundsAperture(move.centerX - radius, move.centerY, apertureMinSize)
-														} else if inQuadrant(move.endAngle, QUADRANT_1) && (move.endAngle > move.startAngle) {
-															// The angle spans all 4 axes
-															bounds.updateBoundsAperture(move.centerX + radius, move.centerY, apertureMinSize)
-															bounds.updateBoundsAperture(move.centerX, move.centerY - radius, apertureMinSize)
-															bounds.updateBoundsAperture(move.centerX - radius, move.centerY, apertureMinSize)
-															bounds.updateBoundsAperture(move.centerX, move.centerY + radius, apertureMinSize)
-														}
-													} else if inQuadrant(move.startAngle, QUADRANT_2) {
-														if inQuadrant(move.endAngle, QUADRANT_1) {
-															// The angle spans the positive y-axis
-															bounds.updateBoundsAperture(move.centerX, move.centerY + radius, apertureMinSize)
-														} else if inQuadrant(move.endAngle, QUADRANT_4) {
-															// The angle spans the positive y-axis and positive x-axis
-															bounds.updateBoundsAperture(move.centerX, move.centerY + radius, apertureMinSize)
-															bounds.updateBoundsAperture(move.centerX + radius, move.centerY, apertureMinSize)
-														} else if inQuadrant(move.endAngle, QUADRANT_3) {
-															// The angle spans the positive y-axis, positive x-axis, and negative y-axis
-															bounds.updateBoundsAperture(move.centerX, move.centerY + radius, apertureMinSize)
-															bounds.updateBoundsAperture(move.centerX + radius, move.centerY, apertureMinSize)
-															bounds.updateBoundsAperture(move.centerX, move.centerY - radius, apertureMinSize)
-														} else if inQuadrant(move.endAngle, QUADRANT_2) && (move.endAngle > move.startAngle) {
-															// The angle spans all 4 axes
-															bounds.updateBoundsAperture(move.centerX, move.centerY + radius, apertureMinSize)
-															bounds.updateBoundsAperture(move.centerX + radius, move.centerY, apertureMinSize)
-															bounds.updateBoundsAperture(move.centerX, move.centerY - radius, apertureMinSize)
-															bounds.updateBoundsAperture(move.centerX - radius, move.centerY, apertureMinSize)
-														}
-													} else if inQuadrant(move.startAngle, QUADRANT_3) {
-														if inQuadrant(move.endAngle, QUADRANT_2) {
-															// The angle spans the negative x-axis
-															bounds.updateBoundsAperture(move.centerX - radius, move.centerY, apertureMinSize)
-														} else if inQuadrant(move.endAngle, QUADRANT_1) {
-															// The angle spans the negative x-axis and positive y-axis
-															bounds.updateBoundsAperture(move.centerX - radius, move.centerY, apertureMinSize)
-															bounds.updateBoundsAperture(move.centerX, move.centerY + radius, apertureMinSize)
-														} else if inQuadrant(move.endAngle, QUADRANT_4) {
-															// The angle spans the negative x-axis, positive y-axis, and positive x-axis
-															bounds.updateBoundsAperture(move.centerX - radius, move.centerY, apertureMinSize)
-															bounds.updateBoundsAperture(move.centerX, move.centerY + radius, apertureMinSize)
-															bounds.updateBoundsAperture(move.centerX + radius, move.centerY, apertureMinSize)
-														} else if inQuadrant(move.endAngle, QUADRANT_3) && (move.endAngle > move.startAngle) {
-															// The angle spans all 4 axes
-															bounds.updateBoundsAperture(move.centerX - radius, move.centerY, apertureMinSize)
-															bounds.updateBoundsAperture(move.centerX, move.centerY + radius, apertureMinSize)
-															bounds.updateBoundsAperture(move.centerX + radius, move.centerY, apertureMinSize)
-															bounds.updateBoundsAperture(move.centerX, move.centerY - radius, apertureMinSize)
-														}
-													} else if inQuadrant(move.startAngle, QUADRANT_4) {
-														if inQuadrant(move.endAngle, QUADRANT_3) {
-															// The angle spans the negative y-axis
-															bounds.updateBoundsAperture(move.centerX, move.centerY - radius, apertureMinSize)
-														} else if inQuadrant(move.endAngle, QUADRANT_2) {
-															// The angle spans the negative y-axis and negative x-axis
-															bounds.updateBoundsAperture(move.centerX, move.centerY - radius, apertureMinSize)
-															bounds.updateBoundsAperture(move.centerX - radius, move.centerY, apertureMinSize)
-														} else if inQuadrant(move.endAngle, QUADRANT_1) {
-															// The angle spans the negative y-axis, negative x-axis, and positive y-axis
-															bounds.updateBoundsAperture(move.centerX, move.centerY - radius, apertureMinSize)
-															bounds.updateBoundsAperture(move.centerX - radius, move.centerY, apertureMinSize)
-															bounds.updateBoundsAperture(move.centerX, move.centerY + radius, apertureMinSize)
-														} else if inQuadrant(move.endAngle, QUADRANT_4) && (move.endAngle > move.startAngle) {
-															// The angle spans all 4 axes
-															bounds.updateBoundsAperture(move.centerX, move.centerY - radius, apertureMinSize)
-															bounds.updateBoundsAperture(move.centerX - radius, move.centerY, apertureMinSize)
-															bounds.updateBoundsAperture(move.centerX, move.centerY + radius, apertureMinSize)
-															bounds.updateBoundsAperture(move.centerX + radius, move.centerY, apertureMinSize)
-														}
-													}
-												} else {
-													if inQuadrant(move.startAngle, QUADRANT_1) {
-														if inQuadrant(move.endAngle, QUADRANT_2) {
-															// The angle spans the positive y-axis
-															bounds.updateBoundsAperture(move.centerX, move.centerY + radius, apertureMinSize)
-														} else if inQuadrant(move.endAngle, QUADRANT_3) {
-															// The angle spans the positive y-axis and negative x-axis
-															bounds.updateBoundsAperture(move.centerX, move.centerY + radius, apertureMinSize)
-															bounds.updateBoundsAperture(move.centerX - radius, move.centerY, apertureMinSize)
-														} else if inQuadrant(move.endAngle, QUADRANT_4) {
-															// The angle spans the positive y-axis, negative x-axis, and negative y-axis
-															bounds.updateBoundsAperture(move.centerX, move.centerY + radius, apertureMinSize)
-															bounds.updateBoundsAperture(move.centerX - radius, move.centerY, apertureMinSize)
-															bounds.updateBoundsAperture(move.centerX, move.centerY - radius, apertureMinSize)
-														} else if inQuadrant(move.endAngle, QUADRANT_1) && (move.endAngle < move.startAngle) {
-															// The angle spans all 4 axes
-															bounds.updateBoundsAperture(move.centerX, move.centerY + radius, apertureMinSize)
-															bounds.updateBoundsAperture(move.centerX - radius, move.centerY, apertureMinSize)
-															bounds.updateBoundsAperture(move.centerX, move.centerY - radius, apertureMinSize)
-															bounds.updateBoundsAperture(move.centerX + radius, move.centerY, apertureMinSize)
-														}
-													} else if inQuadrant(move.startAngle, QUADRANT_2) {
-														if inQuadrant(move.endAngle, QUADRANT_3) {
-															// The angle spans the negative x-axis
-															bounds.updateBoundsAperture(move.centerX - radius, move.centerY, apertureMinSize)
-														} else if inQuadrant(move.endAngle, QUADRANT_4) {
-															// The angle spans the negative x-axis and negative y-axis
-															bounds.updateBoundsAperture(move.centerX - radius, move.centerY, apertureMinSize)
-															bounds.updateBoundsAperture(move.centerX, move.centerY - radius, apertureMinSize)
-														} else if inQuadrant(move.endAngle, QUADRANT_1) {
-															// The angle spans the negative x-axis, negative y-axis, and positive x-axis
-															bounds.updateBoundsAperture(move.centerX - radius, move.centerY, apertureMinSize)
-															bounds.updateBoundsAperture(move.centerX, move.centerY - radius, apertureMinSize)
-															bounds.updateBoundsAperture(move.centerX + radius, move.centerY, apertureMinSize)
-														} else if inQuadrant(move.endAngle, QUADRANT_2) && (move.endAngle < move.startAngle) {
-															// The angle spans all 4 axes
-															bounds.updateBoundsAperture(move.centerX - radius, move.centerY, apertureMinSize)
-															bounds.updateBoundsAperture(move.centerX, move.centerY - radius, apertureMinSize)
-															bounds.updateBoundsAperture(move.centerX + radius, move.centerY, apertureMinSize)
-															bounds.updateBoundsAperture(move.centerX, move.centerY + radius, apertureMinSize)
-														}
-													} else if inQuadrant(move.startAngle, QUADRANT_3) {
-														if inQuadrant(move.endAngle, QUADRANT_4) {
-															// The angle spans the negative y-axis
-															bounds.updateBoundsAperture(move.centerX, move.centerY - radius, apertureMinSize)
-														} else if inQuadrant(move.endAngle, QUADRANT_1) {
-															// The angle spans the negative y-axis and positive x-axis
-															bounds.updateBoundsAperture(move.centerX, move.centerY - radius, apertureMinSize)
-															bounds.updateBoundsAperture(move.centerX + radius, move.centerY, apertureMinSize)
-														} else if inQuadrant(move.endAngle, QUADRANT_2) {
-															// The angle spans the negative y-axis, positive x-axis, and positive y-axis
-															bounds.updateBoundsAperture(move.centerX, move.centerY - radius, apertureMinSize)
-															bounds.updateBoundsAperture(move.centerX + radius, move.centerY, apertureMinSize)
-															bounds.updateBoundsAperture(move.centerX, move.centerY + radius, apertureMinSize)
-														} else if inQuadrant(move.endAngle, QUADRANT_3) && (move.endAngle < move.startAngle) {
-															// The angle spans all 4 axes
-															bounds.updateBoundsAperture(move.centerX, move.centerY - radius, apertureMinSize)
-															bounds.updateBoundsAperture(move.centerX + radius, move.centerY, apertureMinSize)
-															bounds.updateBoundsAperture(move.centerX, move.centerY + radius, apertureMinSize)
-															bounds.updateBoundsAperture(move.centerX - radius, move.centerY, apertureMinSize)
-														}
-													} else if inQuadrant(move.startAngle, QUADRANT_4) {
-														if inQuadrant(move.endAngle, QUADRANT_1) {
-															// The angle spans the positive x-axis
-															bounds.updateBoundsAperture(move.centerX + radius, move.centerY, apertureMinSize)
-														} else if inQuadrant(move.endAngle, QUADRANT_2) {
-															// The angle spans the positive x-axis and positive y-axis
-															bounds.updateBoundsAperture(move.centerX + radius, move.centerY, apertureMinSize)
-															bounds.updateBoundsAperture(move.centerX, move.centerY + radius, apertureMinSize)
-														} else if inQuadrant(move.endAngle, QUADRANT_3) {
-															// The angle spans the positive x-axis, positive y-axis, and negative x-axis
-															bounds.updateBoundsAperture(move.centerX + radius, move.centerY, apertureMinSize)
-															bounds.updateBoundsAperture(move.centerX, move.centerY + radius, apertureMinSize)
-															bounds.updateBoundsAperture(move.centerX - radius, move.centerY, apertureMinSize)
-														} else if inQuadrant(move.endAngle, QUADRANT_4) && (move.endAngle < move.startAngle) {
-															// The angle spans all 4 axes
-															bounds.updateBoundsAperture(move.centerX + radius, move.centerY, apertureMinSize)
-															bounds.updateBoundsAperture(move.centerX, move.centerY + radius, apertureMinSize)
-															bounds.updateBoundsAperture(move.centerX - radius, move.centerY, apertureMinSize)
-															bounds.updateBoundsAperture(move.centerX, move.centerY - radius, apertureMinSize)
-														}
-													}
-												}
-										}
-									}
-									
-									// Finally, update the graphics state with the new end coordinate
-									gfxState.updateCurrentCoordinate(move.newX, move.newY)
-							}
 						}
 					}
 				
 				case MOVE_OPERATION, FLASH_OPERATION:
-					// For bounds checking, we treat moves and flashes the same
+					if gfxState.regionModeOn && (interpolation.opCode == MOVE_OPERATION) {
+						// A move in region mode just starts a new contour, which will be included in the bounds as it's drawn
+						gfxState.updateCurrentCoordinate(move.newX, move.newY)
+						return nil
+					}
+					
+					// Otherwise, for bounds checking, we treat moves and flashes the same
 					if !gfxState.apertureSet {
 						return fmt.Errorf("Attempt to check interpolation bounds before aperture set")
 					}
 					
 					if aperture,found := gfxState.apertures[gfxState.currentAperture]; !found {
 						return fmt.Errorf("Attempt to use aperture %d in bounds check before it has been defined", gfxState.currentAperture)
+					} else if err := aperture.DrawApertureBoundsCheck(bounds, gfxState, move.newX, move.newY); err != nil {
+						return err
 					} else {
-						bounds.updateBoundsAperture(move.newX, move.newY, aperture.GetMinSize(gfxState))
 						gfxState.updateCurrentCoordinate(move.newX, move.newY)	
 					}
 			}
@@ -324,7 +98,262 @@ func (interpolation *Interpolation) ProcessDataBlockBoundsCheck(bounds *ImageBou
 	return nil
 }
 
-func (interpolation *Interpolation) ProcessDataBlockSurface(surface *cairo.Surface, gfxState *GraphicsState) error {
+// Updates the bounds with everything covered by a segment from the current point to the end of the move, with the given
+// aperture extent around it (nil for region contours).  For arcs, this includes the extreme points of the circle along any
+// axes the arc crosses, since those can be well outside of the endpoints
+func updateBoundsSegment(bounds *ImageBounds, gfxState *GraphicsState, move *InterpolationMove, apertureExtent *ImageBounds) {
+	switch gfxState.currentInterpolationMode {
+		case LINEAR_INTERPOLATION:
+			// Update the bounds with both endpoints
+			bounds.updateBoundsAperture(gfxState.currentX, gfxState.currentY, apertureExtent)
+			bounds.updateBoundsAperture(move.newX, move.newY, apertureExtent)
+			
+		case CIRCULAR_INTERPOLATION_CLOCKWISE, CIRCULAR_INTERPOLATION_COUNTER_CLOCKWISE:
+			radius := math.Hypot(move.newX - move.centerX, move.newY - move.centerY)
+			
+			// Update the bounds with both endpoints
+			bounds.updateBoundsAperture(gfxState.currentX, gfxState.currentY, apertureExtent)
+			bounds.updateBoundsAperture(move.newX, move.newY, apertureExtent)
+			
+			// Special case, if the angles are equal, and we're in multi quadrant mode, we're drawing a full circle,
+			// so the arc spans all of the axes
+			if epsilonEquals(move.startAngle, move.endAngle, gfxState.filePrecision) && (gfxState.currentQuadrantMode == MULTI_QUADRANT_MODE) {
+				bounds.updateBoundsAperture(move.centerX, move.centerY + radius, apertureExtent) // positive y-axis
+				bounds.updateBoundsAperture(move.centerX + radius, move.centerY, apertureExtent) // positive x-axis
+				bounds.updateBoundsAperture(move.centerX, move.centerY - radius, apertureExtent) // negative y-axis
+				bounds.updateBoundsAperture(move.centerX - radius, move.centerY, apertureExtent) // negative x-axis
+			} else {
+				// Otherwise, if the two angles span one (or more, depending on quadrant mode) of the axes, also update the bounds with the point
+				// along that axis at a distance of the radius of the arc (the max distance in that direction that the arc will cover)
+				switch gfxState.currentQuadrantMode {
+					case SINGLE_QUADRANT_MODE:
+						if gfxState.currentInterpolationMode == CIRCULAR_INTERPOLATION_CLOCKWISE {
+							if inQuadrant(move.startAngle, QUADRANT_2) && inQuadrant(move.endAngle, QUADRANT_1) {
+								// The angle spans the positive y-axis
+								bounds.updateBoundsAperture(move.centerX, move.centerY + radius, apertureExtent)
+							}
+							
+							if inQuadrant(move.startAngle, QUADRANT_1) && inQuadrant(move.endAngle, QUADRANT_4) {
+								// The angle spans the positive x-axis
+								bounds.updateBoundsAperture(move.centerX + radius, move.centerY, apertureExtent)
+							}
+							
+							if inQuadrant(move.startAngle, QUADRANT_4) && inQuadrant(move.endAngle, QUADRANT_3) {
+								// The angle spans the negative y-axis
+								bounds.updateBoundsAperture(move.centerX, move.centerY - radius, apertureExtent)
+							}
+							
+							if inQuadrant(move.startAngle, QUADRANT_3) && inQuadrant(move.endAngle, QUADRANT_2) {
+								// The angle spans the negative x-axis
+								bounds.updateBoundsAperture(move.centerX - radius, move.centerY, apertureExtent)
+							}
+						} else {
+							if inQuadrant(move.startAngle, QUADRANT_1) && inQuadrant(move.endAngle, QUADRANT_2) {
+								// The angle spans the positive y-axis
+								bounds.updateBoundsAperture(move.centerX, move.centerY + radius, apertureExtent)
+							}
+							
+							if inQuadrant(move.startAngle, QUADRANT_4) && inQuadrant(move.endAngle, QUADRANT_1) {
+								// The angle spans the positive x-axis
+								bounds.updateBoundsAperture(move.centerX + radius, move.centerY, apertureExtent)
+							}
+							
+							if inQuadrant(move.startAngle, QUADRANT_3) && inQuadrant(move.endAngle, QUADRANT_4) {
+								// The angle spans the negative y-axis
+								bounds.updateBoundsAperture(move.centerX, move.centerY - radius, apertureExtent)
+							}
+							
+							if inQuadrant(move.startAngle, QUADRANT_2) && inQuadrant(move.endAngle, QUADRANT_3) {
+								// The angle spans the negative x-axis
+								bounds.updateBoundsAperture(move.centerX - radius, move.centerY, apertureExtent)
+							}
+						}
+					
+					case MULTI_QUADRANT_MODE:
+						if gfxState.currentInterpolationMode == CIRCULAR_INTERPOLATION_CLOCKWISE {
+							if inQuadrant(move.startAngle, QUADRANT_1) {
+								if inQuadrant(move.endAngle, QUADRANT_4) {
+									// The angle spans the positive x-axis
+									bounds.updateBoundsAperture(move.centerX + radius, move.centerY, apertureExtent)
+								} else if inQuadrant(move.endAngle, QUADRANT_3) {
+									// The angle spans the positive x-axis and negative y-axis
+									bounds.updateBoundsAperture(move.centerX + radius, move.centerY, apertureExtent)
+									bounds.updateBoundsAperture(move.centerX, move.centerY - radius, apertureExtent)
+								} else if inQuadrant(move.endAngle, QUADRANT_2) {
+									// The angle spans the positive x-axis, negative y-axis, and negative x-axis
+									bounds.updateBoundsAperture(move.centerX + radius, move.centerY, apertureExtent)
+									bounds.updateBoundsAperture(move.centerX, move.centerY - radius, apertureExtent)
+									bounds.updateBoundsAperture(move.centerX - radius, move.centerY, apertureExtent)
+								} else if inQuadrant(move.endAngle, QUADRANT_1) && (move.endAngle > move.startAngle) {
+									// The angle spans all 4 axes
+									bounds.updateBoundsAperture(move.centerX + radius, move.centerY, apertureExtent)
+									bounds.updateBoundsAperture(move.centerX, move.centerY - radius, apertureExtent)
+									bounds.updateBoundsAperture(move.centerX - radius, move.centerY, apertureExtent)
+									bounds.updateBoundsAperture(move.centerX, move.centerY + radius, apertureExtent)
+								}
+							} else if inQuadrant(move.startAngle, QUADRANT_2) {
+								if inQuadrant(move.endAngle, QUADRANT_1) {
+									// The angle spans the positive y-axis
+									bounds.updateBoundsAperture(move.centerX, move.centerY + radius, apertureExtent)
+								} else if inQuadrant(move.endAngle, QUADRANT_4) {
+									// The angle spans the positive y-axis and positive x-axis
+									bounds.updateBoundsAperture(move.centerX, move.centerY + radius, apertureExtent)
+									bounds.updateBoundsAperture(move.centerX + radius, move.centerY, apertureExtent)
+								} else if inQuadrant(move.endAngle, QUADRANT_3) {
+									// The angle spans the positive y-axis, positive x-axis, and negative y-axis
+									bounds.updateBoundsAperture(move.centerX, move.centerY + radius, apertureExtent)
+									bounds.updateBoundsAperture(move.centerX + radius, move.centerY, apertureExtent)
+									bounds.updateBoundsAperture(move.centerX, move.centerY - radius, apertureExtent)
+								} else if inQuadrant(move.endAngle, QUADRANT_2) && (move.endAngle > move.startAngle) {
+									// The angle spans all 4 axes
+									bounds.updateBoundsAperture(move.centerX, move.centerY + radius, apertureExtent)
+									bounds.updateBoundsAperture(move.centerX + radius, move.centerY, apertureExtent)
+									bounds.updateBoundsAperture(move.centerX, move.centerY - radius, apertureExtent)
+									bounds.updateBoundsAperture(move.centerX - radius, move.centerY, apertureExtent)
+								}
+							} else if inQuadrant(move.startAngle, QUADRANT_3) {
+								if inQuadrant(move.endAngle, QUADRANT_2) {
+									// The angle spans the negative x-axis
+									bounds.updateBoundsAperture(move.centerX - radius, move.centerY, apertureExtent)
+								} else if inQuadrant(move.endAngle, QUADRANT_1) {
+									// The angle spans the negative x-axis and positive y-axis
+									bounds.updateBoundsAperture(move.centerX - radius, move.centerY, apertureExtent)
+									bounds.updateBoundsAperture(move.centerX, move.centerY + radius, apertureExtent)
+								} else if inQuadrant(move.endAngle, QUADRANT_4) {
+									// The angle spans the negative x-axis, positive y-axis, and positive x-axis
+									bounds.updateBoundsAperture(move.centerX - radius, move.centerY, apertureExtent)
+									bounds.updateBoundsAperture(move.centerX, move.centerY + radius, apertureExtent)
+									bounds.updateBoundsAperture(move.centerX + radius, move.centerY, apertureExtent)
+								} else if inQuadrant(move.endAngle, QUADRANT_3) && (move.endAngle > move.startAngle) {
+									// The angle spans all 4 axes
+									bounds.updateBoundsAperture(move.centerX - radius, move.centerY, apertureExtent)
+									bounds.updateBoundsAperture(move.centerX, move.centerY + radius, apertureExtent)
+									bounds.updateBoundsAperture(move.centerX + radius, move.centerY, apertureExtent)
+									bounds.updateBoundsAperture(move.centerX, move.centerY - radius, apertureExtent)
+								}
+							} else if inQuadrant(move.startAngle, QUADRANT_4) {
+								if inQuadrant(move.endAngle, QUADRANT_3) {
+									// The angle spans the negative y-axis
+									bounds.updateBoundsAperture(move.centerX, move.centerY - radius, apertureExtent)
+								} else if inQuadrant(move.endAngle, QUADRANT_2) {
+									// The angle spans the negative y-axis and negative x-axis
+									bounds.updateBoundsAperture(move.centerX, move.centerY - radius, apertureExtent)
+									bounds.updateBoundsAperture(move.centerX - radius, move.centerY, apertureExtent)
+								} else if inQuadrant(move.endAngle, QUADRANT_1) {
+									// The angle spans the negative y-axis, negative x-axis, and positive y-axis
+									bounds.updateBoundsAperture(move.centerX, move.centerY - radius, apertureExtent)
+									bounds.updateBoundsAperture(move.centerX - radius, move.centerY, apertureExtent)
+									bounds.updateBoundsAperture(move.centerX, move.centerY + radius, apertureExtent)
+								} else if inQuadrant(move.endAngle, QUADRANT_4) && (move.endAngle > move.startAngle) {
+									// The angle spans all 4 axes
+									bounds.updateBoundsAperture(move.centerX, move.centerY - radius, apertureExtent)
+									bounds.updateBoundsAperture(move.centerX - radius, move.centerY, apertureExtent)
+									bounds.updateBoundsAperture(move.centerX, move.centerY + radius, apertureExtent)
+									bounds.updateBoundsAperture(move.centerX + radius, move.centerY, apertureExtent)
+								}
+							}
+						} else {
+							if inQuadrant(move.startAngle, QUADRANT_1) {
+								if inQuadrant(move.endAngle, QUADRANT_2) {
+									// The angle spans the positive y-axis
+									bounds.updateBoundsAperture(move.centerX, move.centerY + radius, apertureExtent)
+								} else if inQuadrant(move.endAngle, QUADRANT_3) {
+									// The angle spans the positive y-axis and negative x-axis
+									bounds.updateBoundsAperture(move.centerX, move.centerY + radius, apertureExtent)
+									bounds.updateBoundsAperture(move.centerX - radius, move.centerY, apertureExtent)
+								} else if inQuadrant(move.endAngle, QUADRANT_4) {
+									// The angle spans the positive y-axis, negative x-axis, and negative y-axis
+									bounds.updateBoundsAperture(move.centerX, move.centerY + radius, apertureExtent)
+									bounds.updateBoundsAperture(move.centerX - radius, move.centerY, apertureExtent)
+									bounds.updateBoundsAperture(move.centerX, move.centerY - radius, apertureExtent)
+								} else if inQuadrant(move.endAngle, QUADRANT_1) && (move.endAngle < move.startAngle) {
+									// The angle spans all 4 axes
+									bounds.updateBoundsAperture(move.centerX, move.centerY + radius, apertureExtent)
+									bounds.updateBoundsAperture(move.centerX - radius, move.centerY, apertureExtent)
+									bounds.updateBoundsAperture(move.centerX, move.centerY - radius, apertureExtent)
+									bounds.updateBoundsAperture(move.centerX + radius, move.centerY, apertureExtent)
+								}
+							} else if inQuadrant(move.startAngle, QUADRANT_2) {
+								if inQuadrant(move.endAngle, QUADRANT_3) {
+									// The angle spans the negative x-axis
+									bounds.updateBoundsAperture(move.centerX - radius, move.centerY, apertureExtent)
+								} else if inQuadrant(move.endAngle, QUADRANT_4) {
+									// The angle spans the negative x-axis and negative y-axis
+									bounds.updateBoundsAperture(move.centerX - radius, move.centerY, apertureExtent)
+									bounds.updateBoundsAperture(move.centerX, move.centerY - radius, apertureExtent)
+								} else if inQuadrant(move.endAngle, QUADRANT_1) {
+									// The angle spans the negative x-axis, negative y-axis, and positive x-axis
+									bounds.updateBoundsAperture(move.centerX - radius, move.centerY, apertureExtent)
+									bounds.updateBoundsAperture(move.centerX, move.centerY - radius, apertureExtent)
+									bounds.updateBoundsAperture(move.centerX + radius, move.centerY, apertureExtent)
+								} else if inQuadrant(move.endAngle, QUADRANT_2) && (move.endAngle < move.startAngle) {
+									// The angle spans all 4 axes
+									bounds.updateBoundsAperture(move.centerX - radius, move.centerY, apertureExtent)
+									bounds.updateBoundsAperture(move.centerX, move.centerY - radius, apertureExtent)
+									bounds.updateBoundsAperture(move.centerX + radius, move.centerY, apertureExtent)
+									bounds.updateBoundsAperture(move.centerX, move.centerY + radius, apertureExtent)
+								}
+							} else if inQuadrant(move.startAngle, QUADRANT_3) {
+								if inQuadrant(move.endAngle, QUADRANT_4) {
+									// The angle spans the negative y-axis
+									bounds.updateBoundsAperture(move.centerX, move.centerY - radius, apertureExtent)
+								} else if inQuadrant(move.endAngle, QUADRANT_1) {
+									// The angle spans the negative y-axis and positive x-axis
+									bounds.updateBoundsAperture(move.centerX, move.centerY - radius, apertureExtent)
+									bounds.updateBoundsAperture(move.centerX + radius, move.centerY, apertureExtent)
+								} else if inQuadrant(move.endAngle, QUADRANT_2) {
+									// The angle spans the negative y-axis, positive x-axis, and positive y-axis
+									bounds.updateBoundsAperture(move.centerX, move.centerY - radius, apertureExtent)
+									bounds.updateBoundsAperture(move.centerX + radius, move.centerY, apertureExtent)
+									bounds.updateBoundsAperture(move.centerX, move.centerY + radius, apertureExtent)
+								} else if inQuadrant(move.endAngle, QUADRANT_3) && (move.endAngle < move.startAngle) {
+									// The angle spans all 4 axes
+									bounds.updateBoundsAperture(move.centerX, move.centerY - radius, apertureExtent)
+									bounds.updateBoundsAperture(move.centerX + radius, move.centerY, apertureExtent)
+									bounds.updateBoundsAperture(move.centerX, move.centerY + radius, apertureExtent)
+									bounds.updateBoundsAperture(move.centerX - radius, move.centerY, apertureExtent)
+								}
+							} else if inQuadrant(move.startAngle, QUADRANT_4) {
+								if inQuadrant(move.endAngle, QUADRANT_1) {
+									// The angle spans the positive x-axis
+									bounds.updateBoundsAperture(move.centerX + radius, move.centerY, apertureExtent)
+								} else if inQuadrant(move.endAngle, QUADRANT_2) {
+									// The angle spans the positive x-axis and positive y-axis
+									bounds.updateBoundsAperture(move.centerX + radius, move.centerY, apertureExtent)
+									bounds.updateBoundsAperture(move.centerX, move.centerY + radius, apertureExtent)
+								} else if inQuadrant(move.endAngle, QUADRANT_3) {
+									// The angle spans the positive x-axis, positive y-axis, and negative x-axis
+									bounds.updateBoundsAperture(move.centerX + radius, move.centerY, apertureExtent)
+									bounds.updateBoundsAperture(move.centerX, move.centerY + radius, apertureExtent)
+									bounds.updateBoundsAperture(move.centerX - radius, move.centerY, apertureExtent)
+								} else if inQuadrant(move.endAngle, QUADRANT_4) && (move.endAngle < move.startAngle) {
+									// The angle spans all 4 axes
+									bounds.updateBoundsAperture(move.centerX + radius, move.centerY, apertureExtent)
+									bounds.updateBoundsAperture(move.centerX, move.centerY + radius, apertureExtent)
+									bounds.updateBoundsAperture(move.centerX - radius, move.centerY, apertureExtent)
+									bounds.updateBoundsAperture(move.centerX, move.centerY - radius, apertureExtent)
+								}
+							}
+						}
+				}
+			}
+	}
+}
+
+// The bounds of the aperture when it's flashed at the origin
+func getApertureExtent(aperture Aperture, gfxState *GraphicsState) (*ImageBounds, error) {
+	apertureExtent := newImageBounds()
+	if err := aperture.DrawApertureBoundsCheck(apertureExtent, gfxState, 0.0, 0.0); err != nil {
+		return nil,err
+	}
+	
+	return apertureExtent,nil
+}
+
+func (interpolation *Interpolation) ProcessDataBlockRender(renderer Renderer, gfxState *GraphicsState) error {
+	// Renderers can look up the attributes of whatever they're asked to draw in the graphics state
+	gfxState.objectAttributes = interpolation.attributes
+	
 	// First, if this interpolation has a valid function code, update the graphics state
 	if interpolation.fnCodeValid {
 		switch interpolation.fnCode {
@@ -337,16 +366,16 @@ func (interpolation *Interpolation) ProcessDataBlockSurface(surface *cairo.Surfa
 	// Next, if this interpolation has a valid operation code, perform the operation
 	if interpolation.opCodeValid {
 		if gfxState.regionModeOn {
-			 return interpolation.performDrawRegionOn(surface, gfxState)
+			 return interpolation.performDrawRegionOn(renderer, gfxState)
 		} else {
-			return interpolation.performDrawRegionOff(surface, gfxState)
+			return interpolation.performDrawRegionOff(renderer, gfxState)
 		}
 	}
 	
 	return nil
 }
 
-func (interpolation *Interpolation) performDrawRegionOff(surface *cairo.Surface, gfxState *GraphicsState) error {
+func (interpolation *Interpolation) performDrawRegionOff(renderer Renderer, gfxState *GraphicsState) error {
 	if move,err := interpolation.getNewCoordinate(gfxState); err != nil {
 		return err
 	} else {
@@ -384,7 +413,9 @@ func (interpolation *Interpolation) performDrawRegionOff(surface *cairo.Surface,
 								return err
 							}
 							*/
-							aperture.StrokeApertureLinear(surface, gfxState, gfxState.currentX, gfxState.currentY, move.newX, move.newY)
+							if err := strokeLinear(renderer, aperture, gfxState, gfxState.currentX, gfxState.currentY, move.newX, move.newY); err != nil {
+								return err
+							}
 							
 							// Finally, update the graphics state with the new end coordinate
 							gfxState.updateCurrentCoordinate(move.newX, move.newY)
@@ -421,7 +452,9 @@ func (interpolation *Interpolation) performDrawRegionOff(surface *cairo.Surface,
 							}
 							*/
 							radius := math.Hypot(move.newX - move.centerX, move.newY - move.centerY)
-							aperture.StrokeApertureClockwise(surface, gfxState, move.centerX, move.centerY, radius, move.startAngle, move.endAngle)
+							if err := strokeArc(renderer, aperture, gfxState, move.centerX, move.centerY, radius, move.startAngle, move.endAngle, true); err != nil {
+								return err
+							}
 							
 							// Finally, update the graphics state with the new end coordinate
 							gfxState.updateCurrentCoordinate(move.newX, move.newY)
@@ -458,7 +491,9 @@ func (interpolation *Interpolation) performDrawRegionOff(surface *cairo.Surface,
 							}
 							*/
 							radius := math.Hypot(move.newX - move.centerX, move.newY - move.centerY)
-							aperture.StrokeApertureCounterClockwise(surface, gfxState, move.centerX, move.centerY, radius, move.startAngle, move.endAngle)
+							if err := strokeArc(renderer, aperture, gfxState, move.centerX, move.centerY, radius, move.startAngle, move.endAngle, false); err != nil {
+								return err
+							}
 							
 							// Finally, update the graphics state with the new end coordinate
 							gfxState.updateCurrentCoordinate(move.newX, move.newY)
@@ -477,7 +512,7 @@ func (interpolation *Interpolation) performDrawRegionOff(surface *cairo.Surface,
 					return fmt.Errorf("Attempt to use aperture %d before it has been defined", gfxState.currentAperture)
 				} else {
 					gfxState.updateCurrentCoordinate(move.newX, move.newY)
-					return aperture.DrawApertureSurface(surface, gfxState, gfxState.currentX, gfxState.currentY)	
+					return renderer.Flash(aperture, gfxState, gfxState.currentX, gfxState.currentY)	
 				}
 		}
 		
@@ -485,16 +520,22 @@ func (interpolation *Interpolation) performDrawRegionOff(surface *cairo.Surface,
 	}
 }
 
-func (interpolation *Interpolation) performDrawRegionOn(surface *cairo.Surface, gfxState *GraphicsState) error {
+func (interpolation *Interpolation) performDrawRegionOn(renderer Renderer, gfxState *GraphicsState) error {
 	if move,err := interpolation.getNewCoordinate(gfxState); err != nil {
 		return err
 	} else {
 		switch interpolation.opCode {
 			case INTERPOLATE_OPERATION:
-				// Add the new segment to the current surface path
+				// The first segment of a contour starts from the current point
+				if !gfxState.contourStarted {
+					renderer.MoveTo(gfxState.currentX, gfxState.currentY)
+					gfxState.contourStarted = true
+				}
+				
+				// Add the new segment to the current path
 				switch gfxState.currentInterpolationMode {
 					case LINEAR_INTERPOLATION:
-						surface.LineTo(move.newX, move.newY)
+						renderer.LineTo(move.newX, move.newY)
 					
 					case CIRCULAR_INTERPOLATION_CLOCKWISE:
 						radius := math.Hypot(gfxState.currentX - move.centerX, gfxState.currentY - move.centerY)
@@ -505,8 +546,8 @@ func (interpolation *Interpolation) performDrawRegionOn(surface *cairo.Surface,
 						}
 						
 						// NOTE: The arc direction is relative to the gerber file coordinate frame
-						// The conversion to the cairo coordinate frame is inherent in the y-axis mirror transformation of the surface
-						surface.ArcNegative(move.centerX, move.centerY, radius, move.startAngle, move.endAngle)
+						// Converting to the coordinate frame of the output is up to the renderer
+						renderer.ArcNegative(move.centerX, move.centerY, radius, move.startAngle, move.endAngle)
 					
 					case CIRCULAR_INTERPOLATION_COUNTER_CLOCKWISE:
 						radius := math.Hypot(gfxState.currentX - move.centerX, gfxState.currentY - move.centerY)
@@ -517,23 +558,16 @@ func (interpolation *Interpolation) performDrawRegionOn(surface *cairo.Surface,
 						}
 						
 						// NOTE: The arc direction is relative to the gerber file coordinate frame
-						// The conversion to the cairo coordinate frame is inherent in the y-axis mirror transformation of the surface
-						surface.Arc(move.centerX, move.centerY, radius, move.startAngle, move.endAngle)
+						// Converting to the coordinate frame of the output is up to the renderer
+						renderer.Arc(move.centerX, move.centerY, radius, move.startAngle, move.endAngle)
 				}
 			
 				gfxState.updateCurrentCoordinate(move.newX, move.newY)
 				
 			case MOVE_OPERATION:
-				// If we're in region mode, this means we're closing off a contour.  First, set the proper polarity,
-				// then perform the actual draw
-				switch gfxState.currentLevelPolarity {
-					case DARK_POLARITY:
-						surface.SetSourceRGBA(0.0, 0.0, 0.0, 1.0)
-					
-					case CLEAR_POLARITY:
-						surface.SetSourceRGBA(1.0, 1.0, 1.0, 1.0)
-				}
-				surface.Fill()
+				// If we're in region mode, this means we're closing off a contour, so perform the actual draw
+				renderer.Fill()
+				gfxState.contourStarted = false
 				
 				// Now, update the current point
 				gfxState.updateCurrentCoordinate(move.newX, move.newY)
@@ -612,7 +646,7 @@ func (interpolation *Interpolation) getNewCoordinate(gfxState *GraphicsState) (*
 							
 							// Now, make sure the the candidate center produces an arc with the correct direction that is <= 90 degrees
 							// NOTE: All of the comparisons are done in the gerber-file coordinate frame
-							// The conversion to the cairo coordinate frame is inherent in the y-axis mirror transformation of the surface
+							// Converting to the coordinate frame of the output is up to the renderer
 							switch gfxState.currentInterpolationMode {
 								case CIRCULAR_INTERPOLATION_CLOCKWISE:
 									if (startAngle >= endAngle) && ((startAngle - endAngle) <= ONE_HALF_PI) {
@@ -729,4 +763,42 @@ func (interpolation *Interpolation) String() string {
 						interpolation.y,
 						interpolation.i,
 						interpolation.j)
-}
\ No newline at end of file
+}
+
+// The function code, if the block had one (otherwise, the current interpolation mode applies)
+func (interpolation *Interpolation) GetFunctionCode() (FunctionCode, bool) {
+	return interpolation.fnCode,interpolation.fnCodeValid
+}
+
+// The operation code, if the block had one
+func (interpolation *Interpolation) GetOperationCode() (OperationCode, bool) {
+	return interpolation.opCode,interpolation.opCodeValid
+}
+
+// The X coordinate, if the block had one (otherwise, the current X coordinate is unchanged).  In incremental notation,
+// this is the offset from the current X coordinate instead
+func (interpolation *Interpolation) GetX() (float64, bool) {
+	return interpolation.x,interpolation.xValid
+}
+
+// The Y coordinate, if the block had one (otherwise, the current Y coordinate is unchanged).  In incremental notation,
+// this is the offset from the current Y coordinate instead
+func (interpolation *Interpolation) GetY() (float64, bool) {
+	return interpolation.y,interpolation.yValid
+}
+
+// The offset of the arc center from the start point in X (0 if the block didn't have one)
+func (interpolation *Interpolation) GetI() float64 {
+	return interpolation.i
+}
+
+// The offset of the arc center from the start point in Y (0 if the block didn't have one)
+func (interpolation *Interpolation) GetJ() float64 {
+	return interpolation.j
+}
+
+// The attributes of the draw or flash: the attributes the current aperture was defined with (or for region contours,
+// the aperture attributes in effect), together with the object attributes in effect.  Nil if there aren't any, and always nil for moves
+func (interpolation *Interpolation) GetAttributes() Attributes {
+	return interpolation.attributes
+}
@@ -0,0 +1,104 @@
+package gerber_rs274x
+
+import (
+	"math"
+	"os"
+	"strings"
+	"testing"
+)
+
+type point struct {
+	x, y float64
+}
+
+// Runs the data blocks through both the bounds pass and the render pass, and returns where the current point is after
+// each of the top level operations (D01, D02 and D03) in each pass
+func interpolationEndpoints(t *testing.T, name string, dataBlocks []DataBlock) ([]point, []point) {
+	t.Helper()
+
+	var boundsEndpoints []point
+	gfxState := newGraphicsState()
+	bounds := newImageBounds()
+	for _,dataBlock := range dataBlocks {
+		if err := dataBlock.ProcessDataBlockBoundsCheck(bounds, gfxState); err != nil {
+			t.Fatalf("%s: error in the bounds pass: %v", name, err)
+		}
+		if interpolation,isInterpolation := dataBlock.(*Interpolation); isInterpolation && interpolation.opCodeValid {
+			boundsEndpoints = append(boundsEndpoints, point{gfxState.currentX, gfxState.currentY})
+		}
+	}
+
+	var renderEndpoints []point
+	gfxState = newGraphicsState()
+	renderer := newGeometryRenderer(gfxState, 0.0)
+	for _,dataBlock := range dataBlocks {
+		if err := dataBlock.ProcessDataBlockRender(renderer, gfxState); err != nil {
+			t.Fatalf("%s: error in the render pass: %v", name, err)
+		}
+		if interpolation,isInterpolation := dataBlock.(*Interpolation); isInterpolation && interpolation.opCodeValid {
+			renderEndpoints = append(renderEndpoints, point{gfxState.currentX, gfxState.currentY})
+		}
+	}
+
+	return boundsEndpoints,renderEndpoints
+}
+
+func checkEndpoints(t *testing.T, name string, pass string, got []point, expected []point) {
+	t.Helper()
+
+	if len(got) != len(expected) {
+		t.Errorf("%s: %s pass has %d endpoints %v, expected %d", name, pass, len(got), got, len(expected))
+		return
+	}
+
+	for index := range got {
+		if (math.Abs(got[index].x - expected[index].x) > 1e-9) || (math.Abs(got[index].y - expected[index].y) > 1e-9) {
+			t.Errorf("%s: %s pass endpoint %d is %v, expected %v", name, pass, index, got[index], expected[index])
+		}
+	}
+}
+
+func TestCoordinateNotationChanges(t *testing.T) {
+	inputFile,err := os.Open("../testing/gerber-ex24.gbr")
+	if err != nil {
+		t.Fatalf("Error opening gerber-ex24.gbr: %v", err)
+	}
+	defer inputFile.Close()
+
+	// The file starts out incremental from its FS parameter, switches to absolute with G90 and back to incremental with G91
+	dataBlocks,err := ParseGerberFile(inputFile)
+	if err != nil {
+		t.Fatalf("Error parsing gerber-ex24.gbr: %v", err)
+	}
+
+	expected := []point{
+		{0.0, 0.0}, {1.0, 0.0}, {1.0, 0.5}, {0.0, 0.5}, {0.0, 0.0},
+		{2.0, 0.0}, {2.5, 0.5},
+		{3.0, 0.5}, {3.2, 0.3},
+		{3.7, 0.3}, {3.7, 0.8}, {4.2, 0.8}, {4.2, 0.3}, {3.7, 0.3},
+		// G90
+		{0.0, 2.0}, {1.0, 2.0},
+		// G91
+		{1.0, 2.5},
+	}
+
+	boundsEndpoints,renderEndpoints := interpolationEndpoints(t, "gerber-ex24.gbr", dataBlocks)
+	checkEndpoints(t, "gerber-ex24.gbr", "bounds", boundsEndpoints, expected)
+	checkEndpoints(t, "gerber-ex24.gbr", "render", renderEndpoints, expected)
+}
+
+// A G90 inside a step and repeat only lasts until the end of the step and repeat, so the coordinates after it
+// are still incremental
+func TestCoordinateNotationAfterStepAndRepeat(t *testing.T) {
+	contents := "%FSLAX24Y24*%%MOIN*%%ADD10C,0.01*%D10*X10000Y10000D02*G91*%SRX2Y1I1J0*%X1000D03*G90*X5000Y5000D03*%SR*%X1000Y1000D02*M02*"
+	dataBlocks,err := ParseGerberFile(strings.NewReader(contents))
+	if err != nil {
+		t.Fatalf("Error parsing step and repeat: %v", err)
+	}
+
+	expected := []point{{1.0, 1.0}, {1.1, 1.1}}
+
+	boundsEndpoints,renderEndpoints := interpolationEndpoints(t, "Step and repeat", dataBlocks)
+	checkEndpoints(t, "Step and repeat", "bounds", boundsEndpoints, expected)
+	checkEndpoints(t, "Step and repeat", "render", renderEndpoints, expected)
+}
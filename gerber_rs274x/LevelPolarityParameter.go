@@ -2,7 +2,6 @@ package gerber_rs274x
 
 import (
 	"fmt"
-	cairo "github.com/ungerik/go-cairo"
 )
 
 type LevelPolarityParameter struct {
@@ -14,14 +13,19 @@ func (levelPolarity *LevelPolarityParameter) DataBlockPlaceholder() {
 
 }
 
+func (levelPolarity *LevelPolarityParameter) Accept(visitor Visitor) error {
+	return visitor.VisitLevelPolarity(levelPolarity)
+}
+
 func (levelPolarity *LevelPolarityParameter) ProcessDataBlockBoundsCheck(imageBounds *ImageBounds, gfxState *GraphicsState) error {
 	gfxState.currentLevelPolarity = levelPolarity.polarity
 	
 	return nil
 }
 
-func (levelPolarity *LevelPolarityParameter) ProcessDataBlockSurface(surface *cairo.Surface, gfxState *GraphicsState) error {
+func (levelPolarity *LevelPolarityParameter) ProcessDataBlockRender(renderer Renderer, gfxState *GraphicsState) error {
 	gfxState.currentLevelPolarity = levelPolarity.polarity
+	renderer.SetPolarity(levelPolarity.polarity)
 	
 	return nil
 }
@@ -41,4 +45,8 @@ func (lpParam *LevelPolarityParameter) String() string {
 	}
 	
 	return fmt.Sprintf("{LP, Polarity: %s}", levelPolarity)
-}
\ No newline at end of file
+}
+
+func (lpParam *LevelPolarityParameter) GetPolarity() Polarity {
+	return lpParam.polarity
+}
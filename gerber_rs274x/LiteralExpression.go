@@ -14,4 +14,8 @@ func (expr *LiteralExpression) EvaluateExpression(env *ExpressionEnvironment) fl
 
 func (expr *LiteralExpression) String() string {
 	return fmt.Sprintf("{LiteralExpr, Value: %f}", expr.value)
-}
\ No newline at end of file
+}
+
+func (expr *LiteralExpression) GetValue() float64 {
+	return expr.value
+}
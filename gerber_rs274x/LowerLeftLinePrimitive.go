@@ -2,7 +2,6 @@ package gerber_rs274x
 
 import (
 	"fmt"
-	cairo "github.com/ungerik/go-cairo"
 )
 
 type LowerLeftLinePrimitive struct {
@@ -23,15 +22,29 @@ func (primitive *LowerLeftLinePrimitive) ApertureMacroDataBlockPlaceholder() {
 }
 
 func (primitive *LowerLeftLinePrimitive) GetPrimitiveBounds(env *ExpressionEnvironment) (xMin float64, xMax float64, yMin float64, yMax float64) {
-	//TODO: Implement
-	return 0.0,0.0,0.0,0.0
+	xs,ys,rotation := primitive.outline(env)
+	return primitiveOutlineBounds(xs, ys, rotation)
 }
 
-func (primitive *LowerLeftLinePrimitive) DrawPrimitiveToSurface(surface *cairo.Surface, env *ExpressionEnvironment) error {
-	//TODO: Implement
+func (primitive *LowerLeftLinePrimitive) DrawPrimitive(renderer Renderer, env *ExpressionEnvironment) error {
+	xs,ys,rotation := primitive.outline(env)
+	fillPrimitiveOutline(renderer, xs, ys, rotation)
 	return nil
 }
 
+// The corners of the rectangle, before it is rotated
+func (primitive *LowerLeftLinePrimitive) outline(env *ExpressionEnvironment) ([]float64, []float64, float64) {
+	width := primitive.width.EvaluateExpression(env)
+	height := primitive.height.EvaluateExpression(env)
+	lowerLeftX := primitive.lowerLeftX.EvaluateExpression(env)
+	lowerLeftY := primitive.lowerLeftY.EvaluateExpression(env)
+
+	xs := []float64{lowerLeftX, lowerLeftX + width, lowerLeftX + width, lowerLeftX}
+	ys := []float64{lowerLeftY, lowerLeftY, lowerLeftY + height, lowerLeftY + height}
+
+	return xs,ys,primitive.rotationAngle.EvaluateExpression(env)
+}
+
 func (primitive *LowerLeftLinePrimitive) String() string {
 	return fmt.Sprintf("{Lower Left Line, Exposure %v, Width %v, Height %v, Lower Left X %v, Lower Left Y %v, Rotation %v}",
 						primitive.exposure,
@@ -40,4 +53,28 @@ func (primitive *LowerLeftLinePrimitive) String() string {
 						primitive.lowerLeftX,
 						primitive.lowerLeftY,
 						primitive.rotationAngle)
-}
\ No newline at end of file
+}
+
+func (primitive *LowerLeftLinePrimitive) GetExposure() ApertureMacroExpression {
+	return primitive.exposure
+}
+
+func (primitive *LowerLeftLinePrimitive) GetWidth() ApertureMacroExpression {
+	return primitive.width
+}
+
+func (primitive *LowerLeftLinePrimitive) GetHeight() ApertureMacroExpression {
+	return primitive.height
+}
+
+func (primitive *LowerLeftLinePrimitive) GetLowerLeftX() ApertureMacroExpression {
+	return primitive.lowerLeftX
+}
+
+func (primitive *LowerLeftLinePrimitive) GetLowerLeftY() ApertureMacroExpression {
+	return primitive.lowerLeftY
+}
+
+func (primitive *LowerLeftLinePrimitive) GetRotationAngle() ApertureMacroExpression {
+	return primitive.rotationAngle
+}
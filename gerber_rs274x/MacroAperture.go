@@ -3,18 +3,25 @@ package gerber_rs274x
 import (
 	"fmt"
 	"math"
-	cairo "github.com/ungerik/go-cairo"
+	"gerber_rs274x/polygon"
 )
 
 type MacroAperture struct {
 	apertureNumber int
 	macroName string
+	// The modifiers from the aperture definition, in order ($1, $2, etc.)
+	modifiers []float64
 	env *ExpressionEnvironment
 	xMin float64
 	xMax float64
 	yMin float64
 	yMax float64
 	boundsCalculated bool
+	// The shape of the aperture broken up into convex pieces, which is worked out the first time the aperture is swept
+	// and reused for every draw after that (as long as the tolerance is the same)
+	pieces []polygon.Ring
+	piecesTolerance float64
+	piecesCalculated bool
 }
 
 func (aperture *MacroAperture) AperturePlaceholder() {
@@ -59,9 +66,10 @@ func (aperture *MacroAperture) DrawApertureBoundsCheck(bounds *ImageBounds, gfxS
 		}
 	}
 	
-	xMin := x - aperture.xMin
+	// The mins are offsets from the origin of the macro, so they're added too (they're never positive)
+	xMin := x + aperture.xMin
 	xMax := x + aperture.xMax
-	yMin := y - aperture.yMin
+	yMin := y + aperture.yMin
 	yMax := y + aperture.yMax
 	
 	bounds.updateBounds(xMin, xMax, yMin, yMax)
@@ -69,102 +77,121 @@ func (aperture *MacroAperture) DrawApertureBoundsCheck(bounds *ImageBounds, gfxS
 	return nil
 }
 
-func (aperture *MacroAperture) DrawApertureSurface(surface *cairo.Surface, gfxState *GraphicsState, x float64, y float64) error {
-	radiusX := (aperture.xMax - aperture.xMin) / 2.0
-	radiusY := (aperture.yMax - aperture.yMin) / 2.0
-	
-	correctedX := x - radiusX
-	correctedY := y - radiusY
-	
-	return renderApertureToSurface(aperture, surface, gfxState, correctedX, correctedY)
-}
-
-func (aperture *MacroAperture) DrawApertureSurfaceNoHole(surface *cairo.Surface, gfxState *GraphicsState, x float64, y float64) error {
-	radiusX := (aperture.xMax - aperture.xMin) / 2.0
-	radiusY := (aperture.yMax - aperture.yMin) / 2.0
-	
-	correctedX := x - radiusX
-	correctedY := y - radiusY
-	
-	return renderApertureNoHoleToSurface(aperture, surface, gfxState, correctedX, correctedY)
+func (aperture *MacroAperture) StrokeApertureLinear(renderer Renderer, gfxState *GraphicsState, startX float64, startY float64, endX float64, endY float64) error {
+	return aperture.sweep(renderer, gfxState, linearSweepPath(startX, startY, endX, endY))
 }
 
-func (aperture *MacroAperture) StrokeApertureLinear(surface *cairo.Surface, gfxState *GraphicsState, startX float64, startY float64, endX float64, endY float64) error {
-	return nil
+func (aperture *MacroAperture) StrokeApertureClockwise(renderer Renderer, gfxState *GraphicsState, centerX float64, centerY float64, radius float64, startAngle float64, endAngle float64) error {
+	return aperture.sweep(renderer, gfxState, arcSweepPath(renderer, aperture, gfxState, centerX, centerY, radius, startAngle, endAngle, true))
 }
 
-func (aperture *MacroAperture) StrokeApertureClockwise(surface *cairo.Surface, gfxState *GraphicsState, centerX float64, centerY float64, radius float64, startAngle float64, endAngle float64) error {
-	return nil
+func (aperture *MacroAperture) StrokeApertureCounterClockwise(renderer Renderer, gfxState *GraphicsState, centerX float64, centerY float64, radius float64, startAngle float64, endAngle float64) error {
+	return aperture.sweep(renderer, gfxState, arcSweepPath(renderer, aperture, gfxState, centerX, centerY, radius, startAngle, endAngle, false))
 }
 
-func (aperture *MacroAperture) StrokeApertureCounterClockwise(surface *cairo.Surface, gfxState *GraphicsState, centerX float64, centerY float64, radius float64, startAngle float64, endAngle float64) error {
-	return nil
-}
-
-func (aperture *MacroAperture) renderApertureToGraphicsState(gfxState *GraphicsState) {
-	// This will render the aperture to a cairo surface the first time it is needed, then
-	// cache it in the graphics state.  Subsequent draws of the aperture will used the cached surface
-	
-	rangeX := aperture.xMax - aperture.xMin
-	rangeY := aperture.yMax - aperture.yMin
-	radiusX := rangeX / 2.0
-	radiusY := rangeY / 2.0
-	
-	// Construct the surface we're drawing to
-	imageSizeX := int(math.Ceil(rangeX * gfxState.scaleFactor))
-	imageSizeY := int(math.Ceil(rangeY * gfxState.scaleFactor))
-	surface := cairo.NewSurface(cairo.FORMAT_ARGB32, imageSizeX, imageSizeY)
-	// Scale the surface so we can use unscaled coordinates in the primitive rendering routines
-	surface.Scale(gfxState.scaleFactor, gfxState.scaleFactor)
-	// Apply an offset to the surface, so that the origin is actually in the center of the image
-	surface.Translate(radiusX, radiusY)
-	
-	// Set fill rule to Even/Odd so that rings render correctly
-	surface.SetFillRule(cairo.FILL_RULE_EVEN_ODD)
+// Macros can be any shape at all (thermals, donuts and so on), so the convex hull of the aperture isn't good enough.
+// Instead we work out the exact shape of the aperture, break it up into convex pieces, and sweep each of those along the path
+func (aperture *MacroAperture) sweep(renderer Renderer, gfxState *GraphicsState, path []polygon.Point) error {
+	tolerance := sweepTolerance(renderer, gfxState)
+	if !aperture.piecesCalculated || (aperture.piecesTolerance != tolerance) {
+		shape := newGeometryRenderer(gfxState, tolerance)
+		if err := aperture.RenderApertureShape(shape, gfxState, false); err != nil {
+			return err
+		}
+		
+		if polygons,err := shape.finish(); err != nil {
+			return err
+		} else {
+			aperture.pieces = polygon.ConvexPieces(polygons)
+			aperture.piecesTolerance = tolerance
+			aperture.piecesCalculated = true
+		}
+	}
 	
-	// Draw the aperture
-	if gfxState.currentLevelPolarity == DARK_POLARITY {
-		surface.SetSourceRGBA(0.0, 0.0, 0.0, 1.0)
+	if swept,err := polygon.SweepConvex(aperture.pieces, path); err != nil {
+		return err
 	} else {
-		surface.SetSourceRGBA(1.0, 1.0, 1.0, 1.0)
+		fillPolygons(renderer, swept)
 	}
 	
+	return nil
+}
+
+func (aperture *MacroAperture) RenderApertureShape(renderer Renderer, gfxState *GraphicsState, withHole bool) error {
 	// Retrieve the macro from the graphics state
 	if macro,found := gfxState.apertureMacros[aperture.macroName]; !found {
-		//TODO: Figure out the error behavior, just print a warning for now
-		fmt.Printf("Error: Attempt to render macro aperture %s before it has been defined\n", aperture.macroName)
+		return fmt.Errorf("Attempt to render macro aperture %s before it has been defined", aperture.macroName)
+	} else if aperture.hasExposureOff(macro) {
+		// Primitives with exposure off only erase what the macro itself drew, not whatever is underneath the aperture,
+		// so we work out the exact shape of the aperture first and then fill it in one go
+		shape := newGeometryRenderer(gfxState, 0.0)
+		aperture.drawPrimitives(shape, gfxState, macro, true)
+		if polygons,err := shape.finish(); err != nil {
+			return err
+		} else {
+			fillPolygons(renderer, polygons)
+		}
 	} else {
-		for _,dataBlock := range macro {
-			switch dataBlockValue := dataBlock.(type) {
-				case *ApertureMacroComment:
-					//Nothing to do here
-					
-				case *ApertureMacroVariableDefinition:
-					// Need to update the expression environment
-					aperture.env.setVariableValue(dataBlockValue.variableNumber, dataBlockValue.value.EvaluateExpression(aperture.env))
-					
-				case AperturePrimitive:
-					if err := dataBlockValue.DrawPrimitiveToSurface(surface, aperture.env); err != nil {
-						// TODO: Figure out the error behavior, just print a warning for now
-						fmt.Printf("Error while attempting to render primitive on macro aperture %s: %s\n", aperture.macroName, err.Error())
-					}		
-			}
+		aperture.drawPrimitives(renderer, gfxState, macro, false)
+	}
+	
+	return nil
+}
+
+// Draws each of the primitives of the macro in order.  If useExposure is set, the polarity is switched to dark or clear
+// before each primitive, depending on whether its exposure is on or off
+func (aperture *MacroAperture) drawPrimitives(renderer Renderer, gfxState *GraphicsState, macro []ApertureMacroDataBlock, useExposure bool) {
+	for _,dataBlock := range macro {
+		switch dataBlockValue := dataBlock.(type) {
+			case *ApertureMacroComment:
+				//Nothing to do here
+				
+			case *ApertureMacroVariableDefinition:
+				// Need to update the expression environment
+				aperture.env.setVariableValue(dataBlockValue.variableNumber, dataBlockValue.value.EvaluateExpression(aperture.env))
+				
+			case AperturePrimitive:
+				if useExposure {
+					if primitiveExposureOn(dataBlockValue, aperture.env) {
+						renderer.SetPolarity(DARK_POLARITY)
+					} else {
+						renderer.SetPolarity(CLEAR_POLARITY)
+					}
+				}
+				
+				if err := dataBlockValue.DrawPrimitive(renderer, aperture.env); err != nil {
+					// TODO: Figure out the error behavior, just log a warning for now
+					gfxState.logger.Warn("Error while attempting to render primitive on macro aperture", "macro", aperture.macroName, "error", err)
+				}
 		}
 	}
+}
+
+// Whether any of the primitives of the macro are drawn with exposure off
+func (aperture *MacroAperture) hasExposureOff(macroDataBlocks []ApertureMacroDataBlock) bool {
+	// Like calculating the size, this runs the macro, so it needs its own copy of the environment
+	exposureEnv := aperture.copyEnvironment()
 	
-	surface.WriteToPNG(fmt.Sprintf("Aperture-%d.png", aperture.apertureNumber))
+	for _,dataBlock := range macroDataBlocks {
+		switch dataBlockValue := dataBlock.(type) {
+			case *ApertureMacroVariableDefinition:
+				exposureEnv.setVariableValue(dataBlockValue.variableNumber, dataBlockValue.value.EvaluateExpression(exposureEnv))
+			
+			case AperturePrimitive:
+				if !primitiveExposureOn(dataBlockValue, exposureEnv) {
+					return true
+				}
+		}
+	}
 	
-	gfxState.renderedApertures[aperture.apertureNumber] = surface
+	return false
 }
 
 func (aperture *MacroAperture) calculateApertureSize(macroDataBlocks []ApertureMacroDataBlock) {
 	// We need to execute the entire macro to calculate the size, and this will pollute the enviroment
 	// for when we want to actually render the aperture, so we need to create a copy of the environment to use
 	// while calculating size
-	sizeEnv := NewExpressionEnvironment()
-	for key,value := range aperture.env.variables {
-		sizeEnv.setVariableValue(key,value)
-	}
+	sizeEnv := aperture.copyEnvironment()
 	
 	for _,dataBlock := range macroDataBlocks {
 		switch dataBlockValue := dataBlock.(type) {
@@ -194,6 +221,24 @@ func (aperture *MacroAperture) calculateApertureSize(macroDataBlocks []ApertureM
 	aperture.boundsCalculated = true
 }
 
+func (aperture *MacroAperture) copyEnvironment() *ExpressionEnvironment {
+	env := NewExpressionEnvironment()
+	for key,value := range aperture.env.variables {
+		env.setVariableValue(key,value)
+	}
+	
+	return env
+}
+
 func (aperture *MacroAperture) String() string {
 	return fmt.Sprintf("{MA, Name: %s}", aperture.macroName)
-}
\ No newline at end of file
+}
+
+func (aperture *MacroAperture) GetMacroName() string {
+	return aperture.macroName
+}
+
+// The modifiers passed to the macro by the aperture definition, in order, starting with $1
+func (aperture *MacroAperture) GetModifiers() []float64 {
+	return aperture.modifiers
+}
@@ -2,7 +2,6 @@ package gerber_rs274x
 
 import (
 	"fmt"
-	cairo "github.com/ungerik/go-cairo"
 )
 
 type ModeParameter struct {
@@ -14,13 +13,19 @@ func (mode *ModeParameter) DataBlockPlaceholder() {
 
 }
 
+func (mode *ModeParameter) Accept(visitor Visitor) error {
+	return visitor.VisitMode(mode)
+}
+
 func (mode *ModeParameter) ProcessDataBlockBoundsCheck(imageBounds *ImageBounds, gfxState *GraphicsState) error {
-	//TODO: For now this doesn't alter the graphics state or draw anything
+	gfxState.units = mode.units
+	
 	return nil
 }
 
-func (mode *ModeParameter) ProcessDataBlockSurface(surface *cairo.Surface, gfxState *GraphicsState) error {
-	//TODO: For now this doesn't alter the graphics state or draw anything
+func (mode *ModeParameter) ProcessDataBlockRender(renderer Renderer, gfxState *GraphicsState) error {
+	gfxState.units = mode.units
+	
 	return nil
 }
 
@@ -39,4 +44,8 @@ func (moParam *ModeParameter) String() string {
 	}
 	
 	return fmt.Sprintf("{MO, Units: %s}", units)
-}
\ No newline at end of file
+}
+
+func (moParam *ModeParameter) GetUnits() Units {
+	return moParam.units
+}
@@ -3,7 +3,6 @@ package gerber_rs274x
 import (
 	"fmt"
 	"math"
-	cairo "github.com/ungerik/go-cairo"
 )
 
 type MoirePrimitive struct {
@@ -36,7 +35,7 @@ func (primitive *MoirePrimitive) GetPrimitiveBounds(env *ExpressionEnvironment)
 	return centerX - maxRadius,centerX + maxRadius,centerY - maxRadius,centerY + maxRadius
 }
 
-func (primitive *MoirePrimitive) DrawPrimitiveToSurface(surface *cairo.Surface, env *ExpressionEnvironment) error {
+func (primitive *MoirePrimitive) DrawPrimitive(renderer Renderer, env *ExpressionEnvironment) error {
 	// If there is a rotation angle defined, first check that the center is at the origin
 	// (rotations are only allowed if the center is at the origin)
 	centerX := primitive.centerX.EvaluateExpression(env)
@@ -49,10 +48,7 @@ func (primitive *MoirePrimitive) DrawPrimitiveToSurface(surface *cairo.Surface,
 	
 	// Now that we've checked the center, first apply a translation to account for the offset,
 	// then apply the rotation
-	surface.Save()
-	surface.Rotate(rotation)
-	
-	surface.SetSourceRGBA(0.0, 0.0, 0.0, 1.0)
+	renderer.PushTransform(0.0, 0.0, rotation)
 	
 	// Start drawing the rings
 	maxRings := int(primitive.maxRings.EvaluateExpression(env))
@@ -64,15 +60,15 @@ func (primitive *MoirePrimitive) DrawPrimitiveToSurface(surface *cairo.Surface,
 		innerRadius := outerRadius - thickness
 		
 		// Draw the outer portion of the ring
-		surface.Arc(centerX, centerY, outerRadius, 0.0, TWO_PI)
+		renderer.Arc(centerX, centerY, outerRadius, 0.0, TWO_PI)
 		
 		if innerRadius > 0.0 {
 			// Draw the inner portion of the ring
-			surface.Arc(centerX, centerY, innerRadius, 0.0, TWO_PI)
-			surface.Fill()
+			renderer.Arc(centerX, centerY, innerRadius, 0.0, TWO_PI)
+			renderer.Fill()
 		} else {
 			// We've reached the center, so fill the surface and break out of the loop
-			surface.Fill()
+			renderer.Fill()
 			break
 		}
 	}
@@ -89,25 +85,22 @@ func (primitive *MoirePrimitive) DrawPrimitiveToSurface(surface *cairo.Surface,
 	vertTopY := centerY + crosshairHalfLength
 	vertBottomY := centerY - crosshairHalfLength
 	// Horizontal crosshair portion
-	surface.MoveTo(horzLeftX, horzTopY)
-	surface.LineTo(horzRightX, horzTopY)
-	surface.LineTo(horzRightX, horzBottomY)
-	surface.LineTo(horzLeftX, horzBottomY)
-	surface.LineTo(horzLeftX, horzTopY)
-	surface.Fill()
+	renderer.MoveTo(horzLeftX, horzTopY)
+	renderer.LineTo(horzRightX, horzTopY)
+	renderer.LineTo(horzRightX, horzBottomY)
+	renderer.LineTo(horzLeftX, horzBottomY)
+	renderer.LineTo(horzLeftX, horzTopY)
+	renderer.Fill()
 	// Vertical crosshair portion
-	surface.MoveTo(vertLeftX, vertTopY)
-	surface.LineTo(vertRightX, vertTopY)
-	surface.LineTo(vertRightX, vertBottomY)
-	surface.LineTo(vertLeftX, vertBottomY)
-	surface.LineTo(vertLeftX, vertTopY)
-	surface.Fill()
-	
-	fmt.Printf("Horizontal Top Left (%f %f) Bottom Right (%f %f)\n", horzLeftX, horzTopY, horzRightX, horzBottomY)
-	fmt.Printf("Vertical Top Left (%f %f) Bottom Right (%f %f)\n", vertLeftX, vertTopY, vertRightX, vertBottomY)
+	renderer.MoveTo(vertLeftX, vertTopY)
+	renderer.LineTo(vertRightX, vertTopY)
+	renderer.LineTo(vertRightX, vertBottomY)
+	renderer.LineTo(vertLeftX, vertBottomY)
+	renderer.LineTo(vertLeftX, vertTopY)
+	renderer.Fill()
 	
-	// Finally, undo the transformations to the surface
-	surface.Restore()
+	// Finally, undo the transformations
+	renderer.PopTransform()
 	
 	return nil
 }
@@ -123,4 +116,40 @@ func (primitive *MoirePrimitive) String() string {
 						primitive.crosshairThickness,
 						primitive.crosshairLength,
 						primitive.rotationAngle)
-}
\ No newline at end of file
+}
+
+func (primitive *MoirePrimitive) GetCenterX() ApertureMacroExpression {
+	return primitive.centerX
+}
+
+func (primitive *MoirePrimitive) GetCenterY() ApertureMacroExpression {
+	return primitive.centerY
+}
+
+func (primitive *MoirePrimitive) GetOuterDiameter() ApertureMacroExpression {
+	return primitive.outerDiameter
+}
+
+func (primitive *MoirePrimitive) GetRingThickness() ApertureMacroExpression {
+	return primitive.ringThickness
+}
+
+func (primitive *MoirePrimitive) GetRingGap() ApertureMacroExpression {
+	return primitive.ringGap
+}
+
+func (primitive *MoirePrimitive) GetMaxRings() ApertureMacroExpression {
+	return primitive.maxRings
+}
+
+func (primitive *MoirePrimitive) GetCrosshairThickness() ApertureMacroExpression {
+	return primitive.crosshairThickness
+}
+
+func (primitive *MoirePrimitive) GetCrosshairLength() ApertureMacroExpression {
+	return primitive.crosshairLength
+}
+
+func (primitive *MoirePrimitive) GetRotationAngle() ApertureMacroExpression {
+	return primitive.rotationAngle
+}
@@ -0,0 +1,43 @@
+package gerber_rs274x
+
+import (
+	"fmt"
+)
+
+// A TO parameter, which adds an attribute (e.g. .N, the net name) to the object dictionary.  Draws and flashes after it carry the attribute
+type ObjectAttributeParameter struct {
+	paramCode ParameterCode
+	name string
+	values []string
+}
+
+func (objectAttribute *ObjectAttributeParameter) DataBlockPlaceholder() {
+
+}
+
+func (objectAttribute *ObjectAttributeParameter) Accept(visitor Visitor) error {
+	return visitor.VisitObjectAttribute(objectAttribute)
+}
+
+func (objectAttribute *ObjectAttributeParameter) ProcessDataBlockBoundsCheck(imageBounds *ImageBounds, gfxState *GraphicsState) error {
+	// The attribute was attached to the draws and flashes after it when the file was parsed
+	return nil
+}
+
+func (objectAttribute *ObjectAttributeParameter) ProcessDataBlockRender(renderer Renderer, gfxState *GraphicsState) error {
+	// The attribute was attached to the draws and flashes after it when the file was parsed
+	return nil
+}
+
+func (objectAttribute *ObjectAttributeParameter) String() string {
+	return fmt.Sprintf("{TO, Name: %s, Values: %v}", objectAttribute.name, objectAttribute.values)
+}
+
+// The name of the attribute, including the leading "." for standard attributes
+func (objectAttribute *ObjectAttributeParameter) GetName() string {
+	return objectAttribute.name
+}
+
+func (objectAttribute *ObjectAttributeParameter) GetValues() []string {
+	return objectAttribute.values
+}
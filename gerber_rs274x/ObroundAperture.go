@@ -3,7 +3,7 @@ package gerber_rs274x
 import (
 	"fmt"
 	"math"
-	cairo "github.com/ungerik/go-cairo"
+	"gerber_rs274x/polygon"
 )
 
 type ObroundAperture struct {
@@ -47,92 +47,62 @@ func (aperture *ObroundAperture) DrawApertureBoundsCheck(bounds *ImageBounds, gf
 	return nil
 }
 
-func (aperture *ObroundAperture) DrawApertureSurface(surface *cairo.Surface, gfxState *GraphicsState, x float64, y float64) error {
-	correctedX := x - (aperture.xSize / 2.0)
-	correctedY := y - (aperture.ySize / 2.0)
-	
-	return renderApertureToSurface(aperture, surface, gfxState, correctedX, correctedY)
+func (aperture *ObroundAperture) StrokeApertureLinear(renderer Renderer, gfxState *GraphicsState, startX float64, startY float64, endX float64, endY float64) error {
+	return aperture.sweep(renderer, gfxState, linearSweepPath(startX, startY, endX, endY))
 }
 
-func (aperture *ObroundAperture) DrawApertureSurfaceNoHole(surface *cairo.Surface, gfxState *GraphicsState, x float64, y float64) error {
-	correctedX := x - (aperture.xSize / 2.0)
-	correctedY := y - (aperture.ySize / 2.0)
-	
-	return renderApertureNoHoleToSurface(aperture, surface, gfxState, correctedX, correctedY)
+func (aperture *ObroundAperture) StrokeApertureClockwise(renderer Renderer, gfxState *GraphicsState, centerX float64, centerY float64, radius float64, startAngle float64, endAngle float64) error {
+	return aperture.sweep(renderer, gfxState, arcSweepPath(renderer, aperture, gfxState, centerX, centerY, radius, startAngle, endAngle, true))
 }
 
-func (aperture *ObroundAperture) StrokeApertureLinear(surface *cairo.Surface, gfxState *GraphicsState, startX float64, startY float64, endX float64, endY float64) error {
-	return nil
+func (aperture *ObroundAperture) StrokeApertureCounterClockwise(renderer Renderer, gfxState *GraphicsState, centerX float64, centerY float64, radius float64, startAngle float64, endAngle float64) error {
+	return aperture.sweep(renderer, gfxState, arcSweepPath(renderer, aperture, gfxState, centerX, centerY, radius, startAngle, endAngle, false))
 }
 
-func (aperture *ObroundAperture) StrokeApertureClockwise(surface *cairo.Surface, gfxState *GraphicsState, centerX float64, centerY float64, radius float64, startAngle float64, endAngle float64) error {
-	return nil
-}
-
-func (aperture *ObroundAperture) StrokeApertureCounterClockwise(surface *cairo.Surface, gfxState *GraphicsState, centerX float64, centerY float64, radius float64, startAngle float64, endAngle float64) error {
-	return nil
-}
-
-func (aperture *ObroundAperture) renderApertureToGraphicsState(gfxState *GraphicsState) {
-	// This will render the aperture to a cairo surface the first time it is needed, then
-	// cache it in the graphics state.  Subsequent draws of the aperture will used the cached surface
-	
+func (aperture *ObroundAperture) RenderApertureShape(renderer Renderer, gfxState *GraphicsState, withHole bool) error {
 	radiusX := aperture.xSize / 2.0
 	radiusY := aperture.ySize / 2.0
 	
-	// Construct the surface we're drawing to
-	imageWidth := int(math.Ceil(aperture.xSize * gfxState.scaleFactor))
-	imageHeight := int(math.Ceil(aperture.ySize * gfxState.scaleFactor))
-	surface := cairo.NewSurface(cairo.FORMAT_ARGB32, imageWidth, imageHeight)
-	// Scale the surface so we can use unscaled coordinates while rendering the aperture
-	surface.Scale(gfxState.scaleFactor, gfxState.scaleFactor)
-	// Translate the surface so that the origin is actually the center of the image
-	surface.Translate(radiusX, radiusY)
-	
-	// Draw the aperture
-	if gfxState.currentLevelPolarity == DARK_POLARITY {
-		surface.SetSourceRGBA(0.0, 0.0, 0.0, 1.0)
-	} else {
-		surface.SetSourceRGBA(1.0, 1.0, 1.0, 1.0)
-	}
-	
 	if aperture.xSize < aperture.ySize {
 		rectRadiusY := (aperture.ySize - aperture.xSize) / 2.0
-		surface.MoveTo(-radiusX, -rectRadiusY)
-		surface.Arc(0.0, -rectRadiusY, radiusX, math.Pi, 0)
-		surface.LineTo(radiusX, rectRadiusY)
-		surface.Arc(0.0, rectRadiusY, radiusX, 0, math.Pi)
-		surface.LineTo(-radiusX, -rectRadiusY)  
+		renderer.MoveTo(-radiusX, -rectRadiusY)
+		renderer.Arc(0.0, -rectRadiusY, radiusX, math.Pi, TWO_PI)
+		renderer.LineTo(radiusX, rectRadiusY)
+		renderer.Arc(0.0, rectRadiusY, radiusX, 0, math.Pi)
 	} else {
 		rectRadiusX := (aperture.xSize - aperture.ySize) / 2.0
-		surface.MoveTo(-rectRadiusX, -radiusY)
-		surface.LineTo(rectRadiusX, -radiusY)
-		surface.Arc(rectRadiusX, 0.0, radiusY, THREE_HALVES_PI, ONE_HALF_PI)
-		surface.LineTo(-rectRadiusX, radiusY)
-		surface.Arc(-rectRadiusX, 0.0, radiusY, ONE_HALF_PI, THREE_HALVES_PI)
+		renderer.MoveTo(-rectRadiusX, -radiusY)
+		renderer.LineTo(rectRadiusX, -radiusY)
+		renderer.Arc(rectRadiusX, 0.0, radiusY, -ONE_HALF_PI, ONE_HALF_PI)
+		renderer.LineTo(-rectRadiusX, radiusY)
+		renderer.Arc(-rectRadiusX, 0.0, radiusY, ONE_HALF_PI, THREE_HALVES_PI)
 	}
+	renderer.ClosePath()
 	
-	surface.Fill()
-	
-	// Save the aperture reference before the hole (if any) is rendered, to the no-holes aperture map
-	gfxState.renderedAperturesNoHoles[aperture.apertureNumber] = surface
-	
-	// If present, remove the hole
-	if aperture.Hole != nil {
-		// If there's a hole, we need to create a copy surface and draw the hole on the copy
-		newSurface := copyApertureSurface(surface, gfxState, cairo.ANTIALIAS_DEFAULT, gfxState.scaleFactor, radiusX, radiusY)
-		aperture.DrawHoleSurface(newSurface)
-		
-		// Then, we save the rendered aperture with the hole to the graphics state
-		gfxState.renderedApertures[aperture.apertureNumber] = newSurface
-	} else {
-		// If there wasn't a hole, we can save the same surface reference as the no-hole aperture in the aperture map
-		gfxState.renderedApertures[aperture.apertureNumber] = surface
+	return fillApertureShape(aperture, renderer, withHole)
+}
+
+// An obround is the line between the centers of its ends, rounded off by the radius of the ends, so it's swept as a convex shape
+func (aperture *ObroundAperture) sweep(renderer Renderer, gfxState *GraphicsState, path []polygon.Point) error {
+	if aperture.xSize < aperture.ySize {
+		rectRadiusY := (aperture.ySize - aperture.xSize) / 2.0
+		corners := []polygon.Point{{X: 0.0, Y: -rectRadiusY}, {X: 0.0, Y: rectRadiusY}}
+		return sweepConvexAperture(renderer, aperture, gfxState, path, corners, aperture.xSize / 2.0)
 	}
 	
-	gfxState.renderedApertures[aperture.apertureNumber].WriteToPNG(fmt.Sprintf("Aperture-%d.png", aperture.apertureNumber))
+	rectRadiusX := (aperture.xSize - aperture.ySize) / 2.0
+	corners := []polygon.Point{{X: -rectRadiusX, Y: 0.0}, {X: rectRadiusX, Y: 0.0}}
+	return sweepConvexAperture(renderer, aperture, gfxState, path, corners, aperture.ySize / 2.0)
 }
 
 func (aperture *ObroundAperture) String() string {
 	return fmt.Sprintf("{OA, X: %f, Y: %f, Hole: %v}", aperture.xSize, aperture.ySize, aperture.Hole)
-}
\ No newline at end of file
+}
+
+func (aperture *ObroundAperture) GetXSize() float64 {
+	return aperture.xSize
+}
+
+func (aperture *ObroundAperture) GetYSize() float64 {
+	return aperture.ySize
+}
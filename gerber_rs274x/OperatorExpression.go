@@ -6,6 +6,8 @@ import (
 
 type OperatorExpression struct {
 	operator ArithmeticOperator
+	// Set for a minus sign that negates the operand after it, rather than subtracting it
+	unary bool
 }
 
 func (expr *OperatorExpression) EvaluateExpression(env *ExpressionEnvironment) float64 {
@@ -19,7 +21,11 @@ func (expr *OperatorExpression) String() string {
 			operator = "Add"
 		
 		case OPERATOR_SUBTRACT:
-			operator = "Subtract"
+			if expr.unary {
+				operator = "Negate"
+			} else {
+				operator = "Subtract"
+			}
 		
 		case OPERATOR_MULTIPLY:
 			operator = "Multiply"
@@ -29,4 +35,19 @@ func (expr *OperatorExpression) String() string {
 	}
 	
 	return fmt.Sprintf("{OperatorExpr, Operator: %s}", operator)
+}
+
+// How tightly the operator binds to its operands.  Unary minus binds tightest, then multiplication and division,
+// then addition and subtraction
+func (expr *OperatorExpression) precedence() int {
+	switch {
+		case expr.unary:
+			return 3
+		
+		case expr.operator == OPERATOR_MULTIPLY || expr.operator == OPERATOR_DIVIDE:
+			return 2
+		
+		default:
+			return 1
+	}
 }
\ No newline at end of file
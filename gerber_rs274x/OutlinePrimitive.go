@@ -2,7 +2,6 @@ package gerber_rs274x
 
 import (
 	"fmt"
-	cairo "github.com/ungerik/go-cairo"
 )
 
 type OutlinePrimitive struct {
@@ -24,15 +23,32 @@ func (primitive *OutlinePrimitive) ApertureMacroDataBlockPlaceholder() {
 }
 
 func (primitive *OutlinePrimitive) GetPrimitiveBounds(env *ExpressionEnvironment) (xMin float64, xMax float64, yMin float64, yMax float64) {
-	//TODO: Implement
-	return 0.0,0.0,0.0,0.0
+	xs,ys,rotation := primitive.outline(env)
+	return primitiveOutlineBounds(xs, ys, rotation)
 }
 
-func (primitive *OutlinePrimitive) DrawPrimitiveToSurface(surface *cairo.Surface, env *ExpressionEnvironment) error {
-	//TODO: Implement
+func (primitive *OutlinePrimitive) DrawPrimitive(renderer Renderer, env *ExpressionEnvironment) error {
+	xs,ys,rotation := primitive.outline(env)
+	fillPrimitiveOutline(renderer, xs, ys, rotation)
 	return nil
 }
 
+// The points of the outline, starting with the start point, before it is rotated.  The last point should be the same
+// as the start point, but the outline is closed either way
+func (primitive *OutlinePrimitive) outline(env *ExpressionEnvironment) ([]float64, []float64, float64) {
+	xs := make([]float64, 0, len(primitive.subsequentX) + 1)
+	ys := make([]float64, 0, len(primitive.subsequentY) + 1)
+
+	xs = append(xs, primitive.startX.EvaluateExpression(env))
+	ys = append(ys, primitive.startY.EvaluateExpression(env))
+	for i := range primitive.subsequentX {
+		xs = append(xs, primitive.subsequentX[i].EvaluateExpression(env))
+		ys = append(ys, primitive.subsequentY[i].EvaluateExpression(env))
+	}
+
+	return xs,ys,primitive.rotationAngle.EvaluateExpression(env)
+}
+
 func (primitive *OutlinePrimitive) String() string {
 	return fmt.Sprintf("{Outline, Exposure %v, Num Points %v, Start X %v, Start Y %v, Subsequent X %v, Subsequent Y %v, Rotation %v}",
 						primitive.exposure,
@@ -42,4 +58,35 @@ func (primitive *OutlinePrimitive) String() string {
 						primitive.subsequentX,
 						primitive.subsequentY,
 						primitive.rotationAngle)
-}
\ No newline at end of file
+}
+
+func (primitive *OutlinePrimitive) GetExposure() ApertureMacroExpression {
+	return primitive.exposure
+}
+
+// The number of points in the outline after the start point
+func (primitive *OutlinePrimitive) GetNumPoints() ApertureMacroExpression {
+	return primitive.nPoints
+}
+
+func (primitive *OutlinePrimitive) GetStartX() ApertureMacroExpression {
+	return primitive.startX
+}
+
+func (primitive *OutlinePrimitive) GetStartY() ApertureMacroExpression {
+	return primitive.startY
+}
+
+// X coordinates of the points after the start point, in order
+func (primitive *OutlinePrimitive) GetSubsequentX() []ApertureMacroExpression {
+	return primitive.subsequentX
+}
+
+// Y coordinates of the points after the start point, in order
+func (primitive *OutlinePrimitive) GetSubsequentY() []ApertureMacroExpression {
+	return primitive.subsequentY
+}
+
+func (primitive *OutlinePrimitive) GetRotationAngle() ApertureMacroExpression {
+	return primitive.rotationAngle
+}
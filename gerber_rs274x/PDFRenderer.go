@@ -0,0 +1,94 @@
+//go:build cairo
+
+package gerber_rs274x
+
+import (
+	"fmt"
+	cairo "github.com/ungerik/go-cairo"
+)
+
+const (
+	POINTS_PER_INCH float64 = 72.0
+)
+
+// Renders one or more parsed files as a PDF, one page per file.  Everything is kept as vectors (regions are filled
+// paths, draws with circular apertures are stroked paths, and flashes are drawn as paths), and the page is sized from
+// the bounds of the files in their own units, so the PDF prints at the real size of the board.
+// All of the pages are the same size, covering the bounds of every layer, so that the layers line up with each other
+func RenderPDF(outFileName string, layers [][]DataBlock, options RenderOptions) error {
+	if len(layers) == 0 {
+		return fmt.Errorf("No layers to render to %s", outFileName)
+	}
+
+	// First, need to run through every layer just keeping track of the bounds, so we can size the pages
+	pageBounds := newImageBounds()
+	layerUnits := make([]Units, len(layers))
+	for layerNum,layer := range layers {
+		bounds,units,err := computeImageBounds(layer, options)
+		if err != nil {
+			return fmt.Errorf("Layer %d: %w", layerNum, err)
+		}
+
+		// The units of a layer aren't known until its mode parameter has been processed, but we need the
+		// scale before we start drawing, so we keep them from the bounds pass
+		layerUnits[layerNum] = units
+
+		if !bounds.boundsSet {
+			// Nothing is drawn on this layer, so it doesn't contribute to the size of the page
+			continue
+		}
+
+		scaleFactor := scaleForUnits(POINTS_PER_INCH, units)
+		pageBounds.updateBounds(bounds.xMin * scaleFactor, bounds.xMax * scaleFactor, bounds.yMin * scaleFactor, bounds.yMax * scaleFactor)
+	}
+
+	if !pageBounds.boundsSet {
+		return fmt.Errorf("Nothing to render to %s", outFileName)
+	}
+
+	pageWidth := pageBounds.xMax - pageBounds.xMin
+	pageHeight := pageBounds.yMax - pageBounds.yMin
+
+	surface := cairo.NewPDFSurface(outFileName, pageWidth, pageHeight, cairo.PDF_VERSION_1_5)
+	// This is important for regions with cut-ins.  If we leave the fill rule the default (winding),
+	// cut-ins don't render correctly
+	surface.SetFillRule(cairo.FILL_RULE_EVEN_ODD)
+
+	gfxStates := make([]*GraphicsState, 0, len(layers))
+	for layerNum,layer := range layers {
+		gfxState := newGraphicsState()
+		gfxState.setRenderOptions(options)
+		scaleFactor := scaleForUnits(POINTS_PER_INCH, layerUnits[layerNum])
+
+		surface.Save()
+		// Invert the Y-axis.  This is to correct for the difference in coordinate frames between the gerber file and cairo
+		surface.Scale(1.0, -1.0)
+		surface.Translate(0.0, -pageHeight)
+		// Move the corner of the page bounds to the corner of the page
+		surface.Translate(-pageBounds.xMin, -pageBounds.yMin)
+		// Finally, scale the surface so that we can draw in the units of the file
+		surface.Scale(scaleFactor, scaleFactor)
+
+		renderer := newCairoRenderer(surface, scaleFactor)
+		renderer.vectorApertures = true
+		err := renderDataBlocks(renderer, layer, gfxState)
+		surface.Restore()
+		if err != nil {
+			surface.Finish()
+			return fmt.Errorf("Layer %d: %w", layerNum, err)
+		}
+
+		surface.ShowPage()
+		gfxStates = append(gfxStates, gfxState)
+	}
+
+	surface.Finish()
+
+	for layerNum,gfxState := range gfxStates {
+		if err := checkFileComplete(gfxState); err != nil {
+			return fmt.Errorf("Layer %d: %w", layerNum, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,99 @@
+package gerber_rs274x
+
+import (
+	"fmt"
+	"strings"
+)
+
+type ParseErrorCategory int
+
+const (
+	// The underlying reader returned an error, so nothing after this point could be read
+	READ_ERROR ParseErrorCategory = iota
+	// The file couldn't be split into blocks (unterminated blocks, parameters starting mid-block, etc.)
+	SYNTAX_ERROR
+	// A parameter block (everything between a pair of "%" characters) couldn't be parsed
+	PARAMETER_ERROR
+	// A data block couldn't be parsed
+	DATA_BLOCK_ERROR
+	// A code or parameter that has been deprecated in the current spec was used
+	DEPRECATED_CODE
+	// Coordinate data or an aperture definition was found before the FS or MO parameter it depends on
+	MISSING_PARAMETER
+	// A parameter code that isn't recognized at all was found
+	UNKNOWN_PARAMETER
+)
+
+// A ParseError describes a single problem found while parsing a gerber file, along with where in the file it was found
+type ParseError struct {
+	// Index of the token the error was found in (counting both parameter and data blocks, starting at 0)
+	TokenIndex int
+	// Position of the start of the block the error was found in (both start at 1)
+	Line int
+	Column int
+	// Raw text of the block the error was found in
+	Block string
+	Category ParseErrorCategory
+	Err error
+}
+
+func (parseError *ParseError) Error() string {
+	return fmt.Sprintf("Line %d, column %d (token %d): %v in block \"%s\": %v", parseError.Line, parseError.Column, parseError.TokenIndex, parseError.Category, parseError.Block, parseError.Err)
+}
+
+func (parseError *ParseError) Unwrap() error {
+	return parseError.Err
+}
+
+// ParseErrors collects every problem found while parsing a whole file, in the order they were found
+type ParseErrors []*ParseError
+
+func (parseErrors ParseErrors) Error() string {
+	var builder strings.Builder
+
+	fmt.Fprintf(&builder, "%d error(s) found while parsing file", len(parseErrors))
+	for _,parseError := range parseErrors {
+		builder.WriteString("\n")
+		builder.WriteString(parseError.Error())
+	}
+
+	return builder.String()
+}
+
+// Allows errors.Is and errors.As to look at each of the collected errors
+func (parseErrors ParseErrors) Unwrap() []error {
+	errs := make([]error, len(parseErrors))
+	for index,parseError := range parseErrors {
+		errs[index] = parseError
+	}
+
+	return errs
+}
+
+func (category ParseErrorCategory) String() string {
+	switch category {
+		case READ_ERROR:
+			return "Read error"
+
+		case SYNTAX_ERROR:
+			return "Syntax error"
+
+		case PARAMETER_ERROR:
+			return "Parameter error"
+
+		case DATA_BLOCK_ERROR:
+			return "Data block error"
+
+		case DEPRECATED_CODE:
+			return "Deprecated code"
+
+		case MISSING_PARAMETER:
+			return "Missing parameter"
+
+		case UNKNOWN_PARAMETER:
+			return "Unknown parameter"
+
+		default:
+			return fmt.Sprintf("Unknown error category %d", int(category))
+	}
+}
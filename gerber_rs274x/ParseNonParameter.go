@@ -3,8 +3,6 @@ package gerber_rs274x
 import (
 	"fmt"
 	"strconv"
-	"strings"
-	"math"
 )
 
 func parseDataBlock(dataBlock string, env *ParseEnvironment) (DataBlock, error) {
@@ -31,6 +29,12 @@ func parseNonCommentBlock(fnLetter string, fnCode string, restOfBlock string, en
 		case "G", "":
 			switch fnCode {
 				case "01", "02", "03", "54", "55", "": //NOTE: Codes 54 and 55 are deprecated, the empty function code is for coordinate data blocks with no function
+					if fnCode == "54" || fnCode == "55" {
+						if err := env.recoverable(DEPRECATED_CODE, "Deprecated function code G%s", fnCode); err != nil {
+							return nil,err
+						}
+					}
+					
 					// Parse the D code out of remainder of the block
 					parsedDataBlock := dCodeDataBlockRegex.FindAllStringSubmatch(restOfBlock, -1)
 					
@@ -132,9 +136,17 @@ func parseNonCommentBlock(fnLetter string, fnCode string, restOfBlock string, en
 					return &GraphicsStateChange{REGION_MODE_OFF},nil
 				
 				case "70": //NOTE: Deprecated
+					if err := env.recoverable(DEPRECATED_CODE, "Deprecated function code G70"); err != nil {
+						return nil,err
+					}
+					env.setUnitsFromDeprecatedCode()
 					return &GraphicsStateChange{SET_UNIT_INCH},nil
 				
 				case "71": //NOTE: Deprecated
+					if err := env.recoverable(DEPRECATED_CODE, "Deprecated function code G71"); err != nil {
+						return nil,err
+					}
+					env.setUnitsFromDeprecatedCode()
 					return &GraphicsStateChange{SET_UNIT_MM},nil
 				
 				case "74":
@@ -144,9 +156,15 @@ func parseNonCommentBlock(fnLetter string, fnCode string, restOfBlock string, en
 					return &GraphicsStateChange{MULTI_QUADRANT_MODE},nil
 				
 				case "90": //NOTE: Deprecated
+					if err := env.recoverable(DEPRECATED_CODE, "Deprecated function code G90"); err != nil {
+						return nil,err
+					}
 					return &GraphicsStateChange{SET_NOTATION_ABSOLUTE},nil
 				
 				case "91": //NOTE: Deprecated
+					if err := env.recoverable(DEPRECATED_CODE, "Deprecated function code G91"); err != nil {
+						return nil,err
+					}
 					return &GraphicsStateChange{SET_NOTATION_INCREMENTAL},nil
 				
 				default:
@@ -156,9 +174,15 @@ func parseNonCommentBlock(fnLetter string, fnCode string, restOfBlock string, en
 		case "M":
 			switch fnCode {
 				case "00": //NOTE: Deprecated
+					if err := env.recoverable(DEPRECATED_CODE, "Deprecated function code M00"); err != nil {
+						return nil,err
+					}
 					return &GraphicsStateChange{PROGRAM_STOP},nil
 				
 				case "01": //NOTE: Deprecated
+					if err := env.recoverable(DEPRECATED_CODE, "Deprecated function code M01"); err != nil {
+						return nil,err
+					}
 					return &GraphicsStateChange{OPTIONAL_STOP},nil
 			
 				case "02":
@@ -176,10 +200,13 @@ func parseNonCommentBlock(fnLetter string, fnCode string, restOfBlock string, en
 }
 
 func parseCoordinateDataBlock(restOfBlock string, interpolation *Interpolation, env *ParseEnvironment) (*Interpolation, error) {
-	// Make sure the coordinate format has been set
-	// It is an error to have a coordinate data block before the coordinate format has been set
-	if !env.coordFormat.isSet {
-		return nil,fmt.Errorf("Encountered coordinate data block before coordinate format has been set")
+	// Make sure the coordinate format and units have been set
+	// It is an error to have a coordinate data block before either of them has been set
+	if err := env.checkCoordinateFormatSet(); err != nil {
+		return nil,err
+	}
+	if err := env.checkUnitsSet(); err != nil {
+		return nil,err
 	}
 
 	// Parse the rest of the data block
@@ -194,7 +221,7 @@ func parseCoordinateDataBlock(restOfBlock string, interpolation *Interpolation,
 	
 	// Parse the coordinate data
 	if len(parsedDataBlock[0][1]) > 0 {
-		if x,err := parseAndScaleCoordinateData(parsedDataBlock[0][1], env); err != nil {
+		if x,err := env.coordFormat.Decode(parsedDataBlock[0][1]); err != nil {
 			return nil,err
 		} else {
 			interpolation.x = x
@@ -205,7 +232,7 @@ func parseCoordinateDataBlock(restOfBlock string, interpolation *Interpolation,
 	}
 	
 	if len(parsedDataBlock[0][2]) > 0 {
-		if y,err := parseAndScaleCoordinateData(parsedDataBlock[0][2], env); err != nil {
+		if y,err := env.coordFormat.Decode(parsedDataBlock[0][2]); err != nil {
 			return nil,err
 		} else {
 			interpolation.y = y
@@ -216,7 +243,7 @@ func parseCoordinateDataBlock(restOfBlock string, interpolation *Interpolation,
 	}
 	
 	if len(parsedDataBlock[0][3]) > 0 {
-		if i,err := parseAndScaleCoordinateData(parsedDataBlock[0][3], env); err != nil {
+		if i,err := env.coordFormat.Decode(parsedDataBlock[0][3]); err != nil {
 			return nil,err
 		} else {
 			interpolation.i = i
@@ -226,7 +253,7 @@ func parseCoordinateDataBlock(restOfBlock string, interpolation *Interpolation,
 	}
 	
 	if len(parsedDataBlock[0][4]) > 0 {
-		if j,err := parseAndScaleCoordinateData(parsedDataBlock[0][4], env); err != nil {
+		if j,err := env.coordFormat.Decode(parsedDataBlock[0][4]); err != nil {
 			return nil,err
 		} else {
 			interpolation.j = j
@@ -236,24 +263,4 @@ func parseCoordinateDataBlock(restOfBlock string, interpolation *Interpolation,
 	}
 	
 	return interpolation,nil
-}
-
-func parseAndScaleCoordinateData(coordinateData string, env *ParseEnvironment) (float64, error) {
-	// If suppress trailing zeros is enabled, we need to pad out the coordinate string
-	// to the max size of the coordinate format with trailing zeros
-	if env.coordFormat.suppressTrailingZeros {
-		coordMaxLength := env.coordFormat.numDigits + env.coordFormat.numDecimals
-		if len(coordinateData) < coordMaxLength {
-			coordinateData += strings.Repeat("0", coordMaxLength - len(coordinateData))
-		}
-	}
-
-	// Next, we parse the string into a double
-	if num,err := strconv.ParseFloat(coordinateData, 64); err != nil {
-		return 0.0,err
-	} else {
-		// Now, we scale the number by the coordinate format
-		num /= math.Pow10(env.coordFormat.numDecimals)
-		return num,nil
-	}
 }
\ No newline at end of file
@@ -0,0 +1,120 @@
+package gerber_rs274x
+
+import (
+	"fmt"
+	"log/slog"
+)
+
+// ParseOptions controls how forgiving the parser is with files that don't follow the current spec
+type ParseOptions struct {
+	// In strict mode, deprecated codes, coordinate data or aperture definitions with no FS or MO parameter before them,
+	// and unknown parameters are all errors.  Otherwise, they are recovered from, and reported back as warnings
+	Strict bool
+	// Diagnostics from the parser go here.  If nil, they are discarded
+	Logger *slog.Logger
+	// If set, every coordinate, aperture size and length in an aperture macro is converted into Units as the file is parsed,
+	// and the MO parameter (or deprecated G70/G71 code) is changed to match, so that files in different units can be
+	// overlaid and measured together.  The FS parameter is left alone, so it may not have enough digits to write the
+	// converted coordinates back out
+	NormalizeUnits bool
+	// The units to normalize to
+	Units Units
+}
+
+// ParseResult holds everything that came out of parsing a file with ParseGerberFileWithOptions
+type ParseResult struct {
+	DataBlocks []DataBlock
+	// Problems that were recovered from in lenient mode, in the order they were found
+	Warnings []*ParseError
+	// The standard file attributes (from the TF parameters), which identify the layer the file holds
+	FileAttributes *FileAttributes
+}
+
+// When the file doesn't have an FS parameter before its first coordinate, lenient mode assumes
+// leading zero omission with 2 integer and 4 decimal digits, which is what most old CAM tools emitted
+const (
+	ASSUMED_COORDINATE_NUM_DIGITS = 2
+	ASSUMED_COORDINATE_NUM_DECIMALS = 4
+)
+
+// An error from one of the parsing routines that needs to be reported with a more specific
+// category than just the kind of block it was found in
+type categorizedError struct {
+	category ParseErrorCategory
+	err error
+}
+
+func (catErr *categorizedError) Error() string {
+	return catErr.err.Error()
+}
+
+func (catErr *categorizedError) Unwrap() error {
+	return catErr.err
+}
+
+// Reports a problem that lenient mode can recover from.  In strict mode, the returned error should be returned
+// by the caller.  In lenient mode, the problem is saved as a warning, nil is returned, and the caller should carry on
+func (env *ParseEnvironment) recoverable(category ParseErrorCategory, format string, args ...interface{}) error {
+	catErr := &categorizedError{category, fmt.Errorf(format, args...)}
+
+	if env.options.Strict {
+		return catErr
+	}
+
+	env.warnings = append(env.warnings, catErr)
+	env.logger.Debug("Recovered from parse problem", "category", category, "error", catErr.err)
+	return nil
+}
+
+// Like recoverable, but for problems that lenient mode recovers from by assuming something.  The assumption
+// is only added to the message when the problem is saved as a warning, since strict mode doesn't assume anything
+func (env *ParseEnvironment) recoverableAssuming(category ParseErrorCategory, assumption string, format string, args ...interface{}) error {
+	if env.options.Strict {
+		return env.recoverable(category, format, args...)
+	}
+
+	return env.recoverable(category, "%s (assuming %s)", fmt.Sprintf(format, args...), assumption)
+}
+
+func (env *ParseEnvironment) checkCoordinateFormatSet() error {
+	if env.coordFormat.isSet {
+		return nil
+	}
+
+	if err := env.recoverableAssuming(MISSING_PARAMETER, fmt.Sprintf("LAX%d%dY%d%d", ASSUMED_COORDINATE_NUM_DIGITS, ASSUMED_COORDINATE_NUM_DECIMALS, ASSUMED_COORDINATE_NUM_DIGITS, ASSUMED_COORDINATE_NUM_DECIMALS), "Encountered coordinate data block before coordinate format has been set"); err != nil {
+		return err
+	}
+
+	if coordFormat,err := NewCoordinateFormat(ASSUMED_COORDINATE_NUM_DIGITS, ASSUMED_COORDINATE_NUM_DECIMALS, OMIT_LEADING_ZEROS); err != nil {
+		return err
+	} else {
+		env.coordFormat = *coordFormat
+		env.coordFormatAssumed = true
+	}
+
+	return nil
+}
+
+func (env *ParseEnvironment) checkUnitsSet() error {
+	if env.unitsSet {
+		return nil
+	}
+
+	if err := env.recoverableAssuming(MISSING_PARAMETER, "inches", "Encountered data that depends on units before the units have been set"); err != nil {
+		return err
+	}
+
+	env.unitsSet = true
+	env.unitsAssumed = true
+
+	return nil
+}
+
+// The deprecated G70 and G71 codes set the units just like an MO parameter, but old files sometimes have both,
+// so they don't stop an MO parameter from coming later
+func (env *ParseEnvironment) setUnitsFromDeprecatedCode() {
+	if !env.unitsSet {
+		env.unitsSet = true
+		env.unitsAssumed = true
+	}
+}
@@ -8,9 +8,10 @@ import (
 
 func parseParameter(parameter string, env *ParseEnvironment) (DataBlock, error) {
 	// All parameter blocks must have at least 3 characters (the two character parameter code, and at least one character of arguments)
-	// So we check for at least that length here, so we can slice to at least the third character below
-	if len(parameter) < 3 {
-		return nil,fmt.Errorf("Error: Unrecognized parameter string %s", parameter)
+	// So we check for at least that length here, so we can slice to at least the third character below.
+	// The only exception is TD, which deletes every attribute when it has no arguments
+	if len(parameter) < 3 && parameter != "TD" {
+		return nil,env.recoverable(UNKNOWN_PARAMETER, "Error: Unrecognized parameter string %s", parameter)
 	}
 
 	switch parameter[0:2] {
@@ -32,7 +33,7 @@ func parseParameter(parameter string, env *ParseEnvironment) (DataBlock, error)
 		case "AM":
 			newAMParam := new(ApertureMacroParameter)
 			newAMParam.paramCode = AM_PARAMETER
-			return parseAMParameter(newAMParam, parameter[2:])
+			return parseAMParameter(newAMParam, parameter[2:], env)
 		
 		case "SR":
 			newSRParam := new(StepAndRepeatParameter)
@@ -43,10 +44,35 @@ func parseParameter(parameter string, env *ParseEnvironment) (DataBlock, error)
 			newLPParam := new(LevelPolarityParameter)
 			newLPParam.paramCode = LP_PARAMETER
 			return parseLPParameter(newLPParam, parameter[2:])
-			
+		
+		case "TF", "TA", "TO":
+			if name,values,err := parseAttribute(parameter[0:2], parameter[2:]); err != nil {
+				return nil,err
+			} else {
+				switch parameter[0:2] {
+					case "TF":
+						return &FileAttributeParameter{TF_PARAMETER, name, values},nil
+					
+					case "TA":
+						return &ApertureAttributeParameter{TA_PARAMETER, name, values},nil
+					
+					default:
+						return &ObjectAttributeParameter{TO_PARAMETER, name, values},nil
+				}
+			}
+		
+		case "TD":
+			if len(parameter) > 2 && !attributeNameRegex.MatchString(parameter[2:]) {
+				return nil,fmt.Errorf("Invalid attribute name \"%s\" in TD parameter", parameter[2:])
+			}
+			return &DeleteAttributeParameter{TD_PARAMETER, parameter[2:]},nil
+		
+		case "AS", "IN", "IP", "IR", "LN", "MI", "OF", "SF":
+			// These parameters are all deprecated, and none of them are supported, so they can only be skipped over
+			return nil,env.recoverable(DEPRECATED_CODE, "Deprecated parameter %s is not supported", parameter[0:2])
 		
 		default:
-			return nil,fmt.Errorf("Error: Unrecognized parameter code %s", parameter[0:2])
+			return nil,env.recoverable(UNKNOWN_PARAMETER, "Error: Unrecognized parameter code %s", parameter[0:2])
 	}
 	
 	return nil,nil
@@ -56,8 +82,9 @@ func parseFSParameter(fsParameter *FormatSpecificationParameter, restOfParameter
 	parsedFS := fsParameterRegex.FindAllStringSubmatch(restOfParameter, -1)
 	
 	// Make sure we haven't already seen an FS parameter
-	// It's only legal to have one FS parameter per file
-	if env.coordFormat.isSet {
+	// It's only legal to have one FS parameter per file (if lenient mode had to assume a format,
+	// a late FS parameter replaces it from here on)
+	if env.coordFormat.isSet && !env.coordFormatAssumed {
 		return nil,fmt.Errorf("Illegal 2nd FS parameter encountered")
 	}
 	
@@ -97,8 +124,8 @@ func parseFSParameter(fsParameter *FormatSpecificationParameter, restOfParameter
 		if xIntPos,err := strconv.ParseInt(parsedFS[0][3], 10, 32); err != nil {
 			return nil,err
 		} else {
-			if (xIntPos < 0) || (xIntPos > 7) {
-				return nil,fmt.Errorf("X coordinate number of integer positions must be between 0 and 7.  Received %d", xIntPos)
+			if (xIntPos < 1) || (xIntPos > 7) {
+				return nil,fmt.Errorf("X coordinate number of integer positions must be between 1 and 7.  Received %d", xIntPos)
 			} else {
 				fsParameter.xNumDigits = int(xIntPos)
 			}
@@ -127,8 +154,8 @@ func parseFSParameter(fsParameter *FormatSpecificationParameter, restOfParameter
 		if yIntPos,err := strconv.ParseInt(parsedFS[0][5], 10, 32); err != nil {
 			return nil,err
 		} else {
-			if (yIntPos < 0) || (yIntPos > 7) {
-				return nil,fmt.Errorf("Y coordinate number of integer positions must be between 0 and 7.  Received %d", yIntPos)
+			if (yIntPos < 1) || (yIntPos > 7) {
+				return nil,fmt.Errorf("Y coordinate number of integer positions must be between 1 and 7.  Received %d", yIntPos)
 			} else {
 				fsParameter.yNumDigits = int(yIntPos)
 			}
@@ -158,18 +185,21 @@ func parseFSParameter(fsParameter *FormatSpecificationParameter, restOfParameter
 	}
 	
 	// If we're here, we've succesfully parsed the FS parameter.  Update the parse environment
-	env.coordFormat.numDigits = fsParameter.xNumDigits // We've already verified that x and y have the same coordinate format, so we can just use x here
-	env.coordFormat.numDecimals = fsParameter.xNumDecimals // Same as above
-	env.coordFormat.suppressTrailingZeros = (fsParameter.zeroOmissionMode == OMIT_TRAILING_ZEROS) 
-	env.coordFormat.isSet = true
+	if coordFormat,err := fsParameter.GetCoordinateFormat(); err != nil {
+		return nil,err
+	} else {
+		env.coordFormat = *coordFormat
+		env.coordFormatAssumed = false
+	}
 
 	return fsParameter,nil
 }
 
 func parseMOParameter(moParameter *ModeParameter, restOfParameter string, env *ParseEnvironment) (DataBlock, error) {
 	// Make sure we haven't already seen an MO parameter
-	// It's only legal to have one MO parameter per file
-	if env.unitsSet {
+	// It's only legal to have one MO parameter per file (if lenient mode had to assume units,
+	// a late MO parameter replaces them from here on)
+	if env.unitsSet && !env.unitsAssumed {
 		return nil,fmt.Errorf("Illegal 2nd MO parameter encountered")
 	}
 
@@ -190,11 +220,17 @@ func parseMOParameter(moParameter *ModeParameter, restOfParameter string, env *P
 	
 	// If we're here, we've successfully parsed the MO parameter, so update the parse environment
 	env.unitsSet = true
+	env.unitsAssumed = false
 	
 	return moParameter,nil
 }
 
 func parseADParameter(adParameter *ApertureDefinitionParameter, restOfParameter string, env *ParseEnvironment) (DataBlock, error) {
+	// Aperture sizes are in the file's units, so they need to have been set
+	if err := env.checkUnitsSet(); err != nil {
+		return nil,err
+	}
+	
 	parsedAD := adParameterRegex.FindAllStringSubmatch(restOfParameter, -1)
 	
 	// Make sure we captured the number of subexpressions we expected
@@ -466,6 +502,7 @@ func parseMacroAperture(adParameter *ApertureDefinitionParameter, name string, m
 			if parsedVal,err := strconv.ParseFloat(val, 64); err != nil {
 				return nil,err
 			} else {
+				aperture.modifiers = append(aperture.modifiers, parsedVal)
 				aperture.env.setVariableValue(num + 1, parsedVal)
 			}
 		}
@@ -518,11 +555,11 @@ func parseApertureHole(aperture Aperture, holeModifiers []string) error {
 	return nil
 }
 
-func parseAMParameter(amParameter *ApertureMacroParameter, restOfParameter string) (DataBlock, error) {
+func parseAMParameter(amParameter *ApertureMacroParameter, restOfParameter string, env *ParseEnvironment) (DataBlock, error) {
 	// First, split the various data blocks apart
 	blocks := strings.Split(restOfParameter, "*")
 	
-	fmt.Printf("Split AM blocks: %v\n", blocks)
+	env.logger.Debug("Split AM blocks", "blocks", blocks)
 	
 	// The aperture macro parameter must have at least one block (the name)
 	if len(blocks) < 1 {
@@ -3,7 +3,7 @@ package gerber_rs274x
 import (
 	"fmt"
 	"math"
-	cairo "github.com/ungerik/go-cairo"
+	"gerber_rs274x/polygon"
 )
 
 type PolygonAperture struct {
@@ -47,107 +47,64 @@ func (aperture *PolygonAperture) DrawApertureBoundsCheck(bounds *ImageBounds, gf
 	return nil
 }
 
-func (aperture *PolygonAperture) DrawApertureSurface(surface *cairo.Surface, gfxState *GraphicsState, x float64, y float64) error {
-
-	radius := aperture.outerDiameter / 2.0
-	correctedX := x - radius
-	correctedY := y - radius
-	
-	return renderApertureToSurface(aperture, surface, gfxState, correctedX, correctedY) 
+func (aperture *PolygonAperture) StrokeApertureLinear(renderer Renderer, gfxState *GraphicsState, startX float64, startY float64, endX float64, endY float64) error {
+	return aperture.sweep(renderer, gfxState, linearSweepPath(startX, startY, endX, endY))
 }
 
-func (aperture *PolygonAperture) DrawApertureSurfaceNoHole(surface *cairo.Surface, gfxState *GraphicsState, x float64, y float64) error {
-
-	radius := aperture.outerDiameter / 2.0
-	correctedX := x - radius
-	correctedY := y - radius
-	
-	return renderApertureNoHoleToSurface(aperture, surface, gfxState, correctedX, correctedY) 
+func (aperture *PolygonAperture) StrokeApertureClockwise(renderer Renderer, gfxState *GraphicsState, centerX float64, centerY float64, radius float64, startAngle float64, endAngle float64) error {
+	return aperture.sweep(renderer, gfxState, arcSweepPath(renderer, aperture, gfxState, centerX, centerY, radius, startAngle, endAngle, true))
 }
 
-func (aperture *PolygonAperture) StrokeApertureLinear(surface *cairo.Surface, gfxState *GraphicsState, startX float64, startY float64, endX float64, endY float64) error {
-	return nil
+func (aperture *PolygonAperture) StrokeApertureCounterClockwise(renderer Renderer, gfxState *GraphicsState, centerX float64, centerY float64, radius float64, startAngle float64, endAngle float64) error {
+	return aperture.sweep(renderer, gfxState, arcSweepPath(renderer, aperture, gfxState, centerX, centerY, radius, startAngle, endAngle, false))
 }
 
-func (aperture *PolygonAperture) StrokeApertureClockwise(surface *cairo.Surface, gfxState *GraphicsState, centerX float64, centerY float64, radius float64, startAngle float64, endAngle float64) error {
-	return nil
-}
-
-func (aperture *PolygonAperture) StrokeApertureCounterClockwise(surface *cairo.Surface, gfxState *GraphicsState, centerX float64, centerY float64, radius float64, startAngle float64, endAngle float64) error {
-	return nil
-}
-
-func (aperture *PolygonAperture) renderApertureToGraphicsState(gfxState *GraphicsState) {
-	// This will render the aperture to a cairo surface the first time it is needed, then
-	// cache it in the graphics state.  Subsequent draws of the aperture will used the cached surface
+func (aperture *PolygonAperture) RenderApertureShape(renderer Renderer, gfxState *GraphicsState, withHole bool) error {
 	radius := aperture.outerDiameter / 2.0
-	
-	// Construct the surface we're drawing to
-	imageSize := int(math.Ceil(aperture.outerDiameter * gfxState.scaleFactor))
-	surface := cairo.NewSurface(cairo.FORMAT_ARGB32, imageSize, imageSize)
-	// Scale the surface so we can use unscaled coordinates while rendering the aperture
-	surface.Scale(gfxState.scaleFactor, gfxState.scaleFactor)
-	// Translate the surface so that the origin is actually the center of the image
-	surface.Translate(radius, radius)
-	
-	// Draw the aperture
-	if gfxState.currentLevelPolarity == DARK_POLARITY {
-		surface.SetSourceRGBA(0.0, 0.0, 0.0, 1.0)
-	} else {
-		surface.SetSourceRGBA(1.0, 1.0, 1.0, 1.0)
-	}
-	
 	vertexAngle := TWO_PI / float64(aperture.numVertices)
+	// The first vertex is on the x-axis, before any rotation is applied
+	// (the rotation is applied to the vertices directly, because holes aren't affected by rotation)
+	rotation := aperture.rotationDegrees * (math.Pi / 180.0)
 	
-	// Save the current surface state so we can undo any
-	// rotations that we apply
-	surface.Save()
-	
-	// If there's a rotation, then apply it
-	if (aperture.rotationDegrees != 0.0) {
-		// Convert the angle to radians
-		correctedAngle := aperture.rotationDegrees * (math.Pi / 180.0)
-		
-		// Perform the rotation
-		surface.Rotate(correctedAngle) 
-	}
-	
-	// Move to the first vertex (on the x-axis)
-	surface.MoveTo(radius, 0.0)
+	renderer.MoveTo(radius * math.Cos(rotation), radius * math.Sin(rotation))
 	// Draw the edges
 	for i := 1; i < aperture.numVertices; i++ {
-		xOffset := radius * math.Cos(float64(i) * vertexAngle)
-		yOffset := radius * math.Sin(float64(i) * vertexAngle)
-		surface.LineTo(xOffset, yOffset)
+		xOffset := radius * math.Cos(rotation + (float64(i) * vertexAngle))
+		yOffset := radius * math.Sin(rotation + (float64(i) * vertexAngle))
+		renderer.LineTo(xOffset, yOffset)
 	}
-	// One final draw to the starting vertex to close the polygon
-	surface.LineTo(radius, 0.0)
-	
-	surface.Fill()
+	renderer.ClosePath()
 	
-	// Undo any rotations before we draw the hole
-	// (holes aren't affected by rotation)
-	surface.Restore()
-	
-	// Save the aperture reference before the hole (if any) is rendered, to the no-holes aperture map
-	gfxState.renderedAperturesNoHoles[aperture.apertureNumber] = surface
+	return fillApertureShape(aperture, renderer, withHole)
+}
+
+// A regular polygon is convex, so it's swept as the hull of its vertices
+func (aperture *PolygonAperture) sweep(renderer Renderer, gfxState *GraphicsState, path []polygon.Point) error {
+	radius := aperture.outerDiameter / 2.0
+	vertexAngle := TWO_PI / float64(aperture.numVertices)
+	rotation := aperture.rotationDegrees * (math.Pi / 180.0)
 	
-	// If present, remove the hole
-	if aperture.Hole != nil {
-		// If there's a hole, we need to create a copy surface and draw the hole on the copy
-		newSurface := copyApertureSurface(surface, gfxState, cairo.ANTIALIAS_DEFAULT, gfxState.scaleFactor, radius, radius)
-		aperture.DrawHoleSurface(newSurface)
-		
-		// Then, we save the rendered aperture with the hole to the graphics state
-		gfxState.renderedApertures[aperture.apertureNumber] = newSurface
-	} else {
-		// If there wasn't a hole, we can save the same surface reference as the no-hole aperture in the aperture map
-		gfxState.renderedApertures[aperture.apertureNumber] = surface
+	corners := make([]polygon.Point, aperture.numVertices)
+	for i := range corners {
+		corners[i] = polygon.Point{X: radius * math.Cos(rotation + (float64(i) * vertexAngle)), Y: radius * math.Sin(rotation + (float64(i) * vertexAngle))}
 	}
 	
-	gfxState.renderedApertures[aperture.apertureNumber].WriteToPNG(fmt.Sprintf("Aperture-%d.png", aperture.apertureNumber))
+	return sweepConvexAperture(renderer, aperture, gfxState, path, corners, 0.0)
 }
 
 func (aperture *PolygonAperture) String() string {
 	return fmt.Sprintf("{PA, Diameter: %f, Vertices: %d, Rotation: %f, Hole: %v", aperture.outerDiameter, aperture.numVertices, aperture.rotationDegrees, aperture.Hole)
-}
\ No newline at end of file
+}
+
+func (aperture *PolygonAperture) GetOuterDiameter() float64 {
+	return aperture.outerDiameter
+}
+
+func (aperture *PolygonAperture) GetNumVertices() int {
+	return aperture.numVertices
+}
+
+// Rotation of the polygon, counter-clockwise in degrees
+func (aperture *PolygonAperture) GetRotationDegrees() float64 {
+	return aperture.rotationDegrees
+}
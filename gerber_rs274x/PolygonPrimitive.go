@@ -2,7 +2,7 @@ package gerber_rs274x
 
 import (
 	"fmt"
-	cairo "github.com/ungerik/go-cairo"
+	"math"
 )
 
 type PolygonPrimitive struct {
@@ -23,16 +23,32 @@ func (primitive *PolygonPrimitive) ApertureMacroDataBlockPlaceholder() {
 }
 
 func (primitive *PolygonPrimitive) GetPrimitiveBounds(env *ExpressionEnvironment) (xMin float64, xMax float64, yMin float64, yMax float64) {
+	xs,ys,rotation := primitive.outline(env)
+	return primitiveOutlineBounds(xs, ys, rotation)
+}
+
+func (primitive *PolygonPrimitive) DrawPrimitive(renderer Renderer, env *ExpressionEnvironment) error {
+	xs,ys,rotation := primitive.outline(env)
+	fillPrimitiveOutline(renderer, xs, ys, rotation)
+	return nil
+}
+
+// The vertices of the polygon, before it is rotated.  The first vertex is on the positive x-axis from the center
+func (primitive *PolygonPrimitive) outline(env *ExpressionEnvironment) ([]float64, []float64, float64) {
+	nVertices := int(primitive.nVertices.EvaluateExpression(env))
 	centerX := primitive.centerX.EvaluateExpression(env)
 	centerY := primitive.centerY.EvaluateExpression(env)
 	radius := primitive.diameter.EvaluateExpression(env) / 2.0
 
-	return centerX - radius,centerX + radius,centerY - radius,centerY + radius
-}
+	xs := make([]float64, 0, max(nVertices, 0))
+	ys := make([]float64, 0, max(nVertices, 0))
+	for i := 0; i < nVertices; i++ {
+		angle := TWO_PI * float64(i) / float64(nVertices)
+		xs = append(xs, centerX + (radius * math.Cos(angle)))
+		ys = append(ys, centerY + (radius * math.Sin(angle)))
+	}
 
-func (primitive *PolygonPrimitive) DrawPrimitiveToSurface(surface *cairo.Surface, env *ExpressionEnvironment) error {
-	//TODO: Implement
-	return nil
+	return xs,ys,primitive.rotationAngle.EvaluateExpression(env)
 }
 
 func (primitive *PolygonPrimitive) String() string {
@@ -43,4 +59,28 @@ func (primitive *PolygonPrimitive) String() string {
 						primitive.centerY,
 						primitive.diameter,
 						primitive.rotationAngle)
-}
\ No newline at end of file
+}
+
+func (primitive *PolygonPrimitive) GetExposure() ApertureMacroExpression {
+	return primitive.exposure
+}
+
+func (primitive *PolygonPrimitive) GetNumVertices() ApertureMacroExpression {
+	return primitive.nVertices
+}
+
+func (primitive *PolygonPrimitive) GetCenterX() ApertureMacroExpression {
+	return primitive.centerX
+}
+
+func (primitive *PolygonPrimitive) GetCenterY() ApertureMacroExpression {
+	return primitive.centerY
+}
+
+func (primitive *PolygonPrimitive) GetDiameter() ApertureMacroExpression {
+	return primitive.diameter
+}
+
+func (primitive *PolygonPrimitive) GetRotationAngle() ApertureMacroExpression {
+	return primitive.rotationAngle
+}
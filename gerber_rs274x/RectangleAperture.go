@@ -3,7 +3,6 @@ package gerber_rs274x
 import (
 	"fmt"
 	"math"
-	cairo "github.com/ungerik/go-cairo"
 )
 
 type RectangleAperture struct {
@@ -47,21 +46,7 @@ func (aperture *RectangleAperture) DrawApertureBoundsCheck(bounds *ImageBounds,
 	return nil
 }
 
-func (aperture *RectangleAperture) DrawApertureSurface(surface *cairo.Surface, gfxState *GraphicsState, x float64, y float64) error {
-	correctedX := x - (aperture.xSize / 2.0)
-	correctedY := y - (aperture.ySize / 2.0)
-	
-	return renderApertureToSurface(aperture, surface, gfxState, correctedX, correctedY)
-}
-
-func (aperture *RectangleAperture) DrawApertureSurfaceNoHole(surface *cairo.Surface, gfxState *GraphicsState, x float64, y float64) error {
-	correctedX := x - (aperture.xSize / 2.0)
-	correctedY := y - (aperture.ySize / 2.0)
-	
-	return renderApertureNoHoleToSurface(aperture, surface, gfxState, correctedX, correctedY)
-}
-
-func (aperture *RectangleAperture) StrokeApertureLinear(surface *cairo.Surface, gfxState *GraphicsState, startX float64, startY float64, endX float64, endY float64) error {
+func (aperture *RectangleAperture) StrokeApertureLinear(renderer Renderer, gfxState *GraphicsState, startX float64, startY float64, endX float64, endY float64) error {
 	radiusX := aperture.xSize / 2.0
 	radiusY := aperture.ySize / 2.0
 	
@@ -97,87 +82,51 @@ func (aperture *RectangleAperture) StrokeApertureLinear(surface *cairo.Surface,
 		bottomLeftY = endY - radiusY
 		bottomRightY = startY - radiusY
 	}
-
-	if gfxState.currentLevelPolarity == DARK_POLARITY {
-		surface.SetSourceRGBA(0.0, 0.0, 0.0, 1.0)
-	} else {
-		surface.SetSourceRGBA(1.0, 1.0, 1.0, 1.0)
-	}
 	
 	// Draw the stroke, except for the endpoints
-	surface.MoveTo(topLeftX, topLeftY)
-	surface.LineTo(topRightX, topRightY)
-	surface.LineTo(bottomRightX, bottomRightY)
-	surface.LineTo(bottomLeftX, bottomLeftY)
-	surface.LineTo(topLeftX, topLeftY)
-	surface.Fill()
+	renderer.MoveTo(topLeftX, topLeftY)
+	renderer.LineTo(topRightX, topRightY)
+	renderer.LineTo(bottomRightX, bottomRightY)
+	renderer.LineTo(bottomLeftX, bottomLeftY)
+	renderer.LineTo(topLeftX, topLeftY)
+	renderer.Fill()
 	
 	// Draw each of the endpoints by flashing the aperture at the endpoints
-	aperture.DrawApertureSurface(surface, gfxState, startX, startY)
-	aperture.DrawApertureSurface(surface, gfxState, endX, endY)
+	renderer.Flash(aperture, gfxState, startX, startY)
+	renderer.Flash(aperture, gfxState, endX, endY)
 
 	return nil
 }
 
-func (aperture *RectangleAperture) StrokeApertureClockwise(surface *cairo.Surface, gfxState *GraphicsState, centerX float64, centerY float64, radius float64, startAngle float64, endAngle float64) error {
+func (aperture *RectangleAperture) StrokeApertureClockwise(renderer Renderer, gfxState *GraphicsState, centerX float64, centerY float64, radius float64, startAngle float64, endAngle float64) error {
 	return nil
 }
 
-func (aperture *RectangleAperture) StrokeApertureCounterClockwise(surface *cairo.Surface, gfxState *GraphicsState, centerX float64, centerY float64, radius float64, startAngle float64, endAngle float64) error {
+func (aperture *RectangleAperture) StrokeApertureCounterClockwise(renderer Renderer, gfxState *GraphicsState, centerX float64, centerY float64, radius float64, startAngle float64, endAngle float64) error {
 	return nil
 }
 
-func (aperture *RectangleAperture) renderApertureToGraphicsState(gfxState *GraphicsState) {
-	// This will render the aperture to a cairo surface the first time it is needed, then
-	// cache it in the graphics state.  Subsequent draws of the aperture will used the cached surface
-	
+func (aperture *RectangleAperture) RenderApertureShape(renderer Renderer, gfxState *GraphicsState, withHole bool) error {
 	radiusX := aperture.xSize / 2.0
 	radiusY := aperture.ySize / 2.0
 	
-	// Construct the surface we're drawing to
-	imageWidth := int(math.Ceil(aperture.xSize * gfxState.scaleFactor))
-	imageHeight := int(math.Ceil(aperture.ySize * gfxState.scaleFactor))
-	surface := cairo.NewSurface(cairo.FORMAT_ARGB32, imageWidth, imageHeight)
-	surface.SetAntialias(cairo.ANTIALIAS_NONE)
-	// Scale the surface so we can use unscaled coordinates while rendering the aperture
-	surface.Scale(gfxState.scaleFactor, gfxState.scaleFactor)
-	// Translate the surface so that the origin is actually the center of the image
-	surface.Translate(radiusX, radiusY)
-	
-	// Draw the aperture
-	if gfxState.currentLevelPolarity == DARK_POLARITY {
-		surface.SetSourceRGBA(0.0, 0.0, 0.0, 1.0)
-	} else {
-		surface.SetSourceRGBA(1.0, 1.0, 1.0, 1.0)
-	}
-	
-	surface.MoveTo(-radiusX, radiusY)
-	surface.LineTo(radiusX, radiusY)
-	surface.LineTo(radiusX, -radiusY)
-	surface.LineTo(-radiusX, -radiusY)
-	surface.LineTo(-radiusX, radiusY)
-	
-	surface.Fill()
-	
-	// Save the aperture reference before the hole (if any) is rendered, to the no-holes aperture map
-	gfxState.renderedAperturesNoHoles[aperture.apertureNumber] = surface
-	
-	// If present, remove the hole
-	if aperture.Hole != nil {
-		// If there's a hole, we need to create a copy surface and draw the hole on the copy
-		newSurface := copyApertureSurface(surface, gfxState, cairo.ANTIALIAS_DEFAULT, gfxState.scaleFactor, radiusX, radiusY)
-		aperture.DrawHoleSurface(newSurface)
-		
-		// Then, we save the rendered aperture with the hole to the graphics state
-		gfxState.renderedApertures[aperture.apertureNumber] = newSurface
-	} else {
-		// If there wasn't a hole, we can save the same surface reference as the no-hole aperture in the aperture map
-		gfxState.renderedApertures[aperture.apertureNumber] = surface
-	}
+	renderer.MoveTo(-radiusX, radiusY)
+	renderer.LineTo(radiusX, radiusY)
+	renderer.LineTo(radiusX, -radiusY)
+	renderer.LineTo(-radiusX, -radiusY)
+	renderer.ClosePath()
 	
-	gfxState.renderedApertures[aperture.apertureNumber].WriteToPNG(fmt.Sprintf("Aperture-%d.png", aperture.apertureNumber))
+	return fillApertureShape(aperture, renderer, withHole)
 }
 
 func (aperture *RectangleAperture) String() string {
 	return fmt.Sprintf("{RA, X: %f, Y: %f, Hole: %v}", aperture.xSize, aperture.ySize, aperture.Hole)
-}
\ No newline at end of file
+}
+
+func (aperture *RectangleAperture) GetXSize() float64 {
+	return aperture.xSize
+}
+
+func (aperture *RectangleAperture) GetYSize() float64 {
+	return aperture.ySize
+}
@@ -2,7 +2,6 @@ package gerber_rs274x
 
 import (
 	"fmt"
-	cairo "github.com/ungerik/go-cairo"
 )
 
 type RectangularHole struct {
@@ -18,23 +17,24 @@ func (rectangle* RectangularHole) String() string {
 	return fmt.Sprintf("{RH, X: %f, Y: %f}", rectangle.holeXSize, rectangle.holeYSize)
 }
 
-func (hole *RectangularHole) DrawHoleSurface(surface *cairo.Surface) error {
+func (hole *RectangularHole) DrawHole(renderer Renderer) error {
 	
 	xRadius := hole.holeXSize / 2.0
 	yRadius := hole.holeYSize / 2.0
 	
-	surface.Save()
-	
-	// We temporarily set the compositing operator to clear, to clear the hole to transparent
-	surface.SetOperator(cairo.OPERATOR_CLEAR)
-	surface.MoveTo(-xRadius, -yRadius)
-	surface.LineTo(xRadius, -yRadius)
-	surface.LineTo(xRadius, yRadius)
-	surface.LineTo(-xRadius, yRadius)
-	surface.LineTo(-xRadius, -yRadius)
-	surface.Fill()
-	
-	surface.Restore()
+	renderer.MoveTo(-xRadius, -yRadius)
+	renderer.LineTo(xRadius, -yRadius)
+	renderer.LineTo(xRadius, yRadius)
+	renderer.LineTo(-xRadius, yRadius)
+	renderer.ClosePath()
 	
 	return nil
-}
\ No newline at end of file
+}
+
+func (hole *RectangularHole) GetXSize() float64 {
+	return hole.holeXSize
+}
+
+func (hole *RectangularHole) GetYSize() float64 {
+	return hole.holeYSize
+}
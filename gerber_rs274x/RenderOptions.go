@@ -0,0 +1,197 @@
+package gerber_rs274x
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"log/slog"
+	"math"
+)
+
+// The format a rendered raster image is written out in
+type ImageFormat int
+
+const (
+	IMAGE_FORMAT_PNG ImageFormat = iota
+	IMAGE_FORMAT_JPEG // No transparency, so should be used with an opaque background
+	IMAGE_FORMAT_GIF
+)
+
+const (
+	DEFAULT_IMAGE_SIZE int = 800
+	// When the image is sized to fit, the margin on each side defaults to this fraction of the image size
+	DEFAULT_MARGIN_FRACTION float64 = 0.05
+	MM_PER_INCH float64 = 25.4
+)
+
+// RenderOptions controls how a parsed file is rendered
+type RenderOptions struct {
+	// Diagnostics from the renderer go here.  If nil, they are discarded
+	Logger *slog.Logger
+	// If set, every aperture is also written out to this directory as Aperture-<number>.png as it is rendered.
+	// Nothing is written to the filesystem (other than the requested output) unless this is set
+	ApertureDebugDir string
+
+	// The rest of the options only apply to raster images
+
+	// The resolution of the image, in pixels per inch (converted as needed for files in millimeters).
+	// If set, the image is sized to hold the whole file at exactly this resolution, plus the margin, and Width and Height are ignored
+	DPI float64
+	// The size of the image in pixels, when DPI isn't set.  The file is scaled to fit inside the margins, keeping its aspect ratio.
+	// Each defaults to 800 if not set
+	Width int
+	Height int
+	// The margin left around the file on each side of the image, in pixels.  If zero, it defaults to 5% of the width and height
+	// when the image is sized to fit, and to no margin when rendering at a fixed DPI.  Set it negative for no margin at all
+	Margin int
+	// The color everything drawn with dark polarity is drawn in.  Defaults to opaque black
+	Foreground color.Color
+	// The color of the image background, which is also what clear polarity draws in.  Defaults to transparent
+	Background color.Color
+	// Turns on antialiasing of the edges of everything drawn.  Off by default, so every pixel is either foreground or background
+	Antialias bool
+	// The format used when the image is written out.  Defaults to PNG
+	Format ImageFormat
+}
+
+func (options RenderOptions) logger() *slog.Logger {
+	return loggerOrDiscard(options.Logger)
+}
+
+func loggerOrDiscard(logger *slog.Logger) *slog.Logger {
+	if logger == nil {
+		return slog.New(slog.DiscardHandler)
+	}
+
+	return logger
+}
+
+func (gfxState *GraphicsState) setRenderOptions(options RenderOptions) {
+	gfxState.logger = options.logger()
+	gfxState.apertureDebugDir = options.ApertureDebugDir
+}
+
+func (options RenderOptions) foreground() color.Color {
+	if options.Foreground == nil {
+		return color.Black
+	}
+
+	return options.Foreground
+}
+
+func (options RenderOptions) background() color.Color {
+	if options.Background == nil {
+		return color.Transparent
+	}
+
+	return options.Background
+}
+
+// Works out the size of the raster image, and the scaling and offsets that map the file (with the given bounds) onto it
+func (options RenderOptions) rasterLayout(bounds *ImageBounds, units Units) (int, int, ScalingParms, error) {
+	var scaling ScalingParms
+
+	if !bounds.boundsSet {
+		return 0,0,scaling,fmt.Errorf("Nothing to render")
+	}
+
+	xSpan := bounds.xMax - bounds.xMin
+	ySpan := bounds.yMax - bounds.yMin
+
+	if options.DPI < 0.0 {
+		return 0,0,scaling,fmt.Errorf("Invalid DPI %v", options.DPI)
+	} else if options.DPI > 0.0 {
+		margin := math.Max(float64(options.Margin), 0.0)
+		scaling.scaleFactor = scaleForUnits(options.DPI, units)
+
+		width := int(math.Ceil((xSpan * scaling.scaleFactor) + (2.0 * margin)))
+		height := int(math.Ceil((ySpan * scaling.scaleFactor) + (2.0 * margin)))
+		if width <= 0 || height <= 0 {
+			return 0,0,scaling,fmt.Errorf("Image would be empty at %v DPI", options.DPI)
+		}
+
+		// Compute offsets to apply to all coordinates to start them at zero and account for margins
+		scaling.xOffset = -(bounds.xMin * scaling.scaleFactor) + margin
+		scaling.yOffset = -(bounds.yMin * scaling.scaleFactor) + margin
+
+		return width,height,scaling,nil
+	}
+
+	width := options.Width
+	if width <= 0 {
+		width = DEFAULT_IMAGE_SIZE
+	}
+
+	height := options.Height
+	if height <= 0 {
+		height = DEFAULT_IMAGE_SIZE
+	}
+
+	xMargin := math.Max(float64(options.Margin), 0.0)
+	yMargin := xMargin
+	if options.Margin == 0 {
+		xMargin = float64(width) * DEFAULT_MARGIN_FRACTION
+		yMargin = float64(height) * DEFAULT_MARGIN_FRACTION
+	}
+
+	// Compute the appropriate scaling factor.  A file that's only a line (or a point) has no span in one (or both)
+	// directions, so that direction doesn't constrain the scaling
+	xScale := math.Inf(1)
+	if xSpan > 0.0 {
+		xScale = (float64(width) - (2.0 * xMargin)) / xSpan
+	}
+	yScale := math.Inf(1)
+	if ySpan > 0.0 {
+		yScale = (float64(height) - (2.0 * yMargin)) / ySpan
+	}
+
+	scaling.scaleFactor = math.Min(xScale, yScale)
+	if math.IsInf(scaling.scaleFactor, 1) {
+		scaling.scaleFactor = 1.0
+	} else if scaling.scaleFactor <= 0.0 {
+		return 0,0,scaling,fmt.Errorf("Margin of %d pixels leaves no room in a %dx%d image", options.Margin, width, height)
+	}
+
+	// Compute offsets to apply to all coordinates to start them at zero and account for margins
+	scaling.xOffset = -(bounds.xMin * scaling.scaleFactor) + xMargin
+	scaling.yOffset = -(bounds.yMin * scaling.scaleFactor) + yMargin
+
+	return width,height,scaling,nil
+}
+
+// Converts a resolution per inch into a resolution per unit of the file
+func scaleForUnits(perInch float64, units Units) float64 {
+	switch units {
+		case UNITS_MM:
+			return perInch / MM_PER_INCH
+
+		default:
+			return perInch
+	}
+}
+
+// Returns the (non-premultiplied) components of the color, from 0 to 1
+func colorComponents(c color.Color) (float64, float64, float64, float64) {
+	nrgba := color.NRGBAModel.Convert(c).(color.NRGBA)
+	return float64(nrgba.R) / 255.0, float64(nrgba.G) / 255.0, float64(nrgba.B) / 255.0, float64(nrgba.A) / 255.0
+}
+
+func encodeImage(w io.Writer, img image.Image, format ImageFormat) error {
+	switch format {
+		case IMAGE_FORMAT_PNG:
+			return png.Encode(w, img)
+
+		case IMAGE_FORMAT_JPEG:
+			return jpeg.Encode(w, img, nil)
+
+		case IMAGE_FORMAT_GIF:
+			return gif.Encode(w, img, nil)
+
+		default:
+			return fmt.Errorf("Unknown image format %d", format)
+	}
+}
@@ -0,0 +1,161 @@
+package gerber_rs274x
+
+import (
+	"fmt"
+	"math"
+	"gerber_rs274x/polygon"
+)
+
+// A Renderer is a drawing backend for a parsed gerber file.  The data blocks describe what to draw in terms of these
+// operations, so that new kinds of output can be added without touching the data blocks themselves.
+// All coordinates are in the units of the gerber file, in the gerber file coordinate frame (y increasing upwards,
+// angles in radians measured counter-clockwise from the positive x-axis).  Mapping them onto the output is up to the renderer.
+// Arc angles follow the same rules as cairo: the end angle is moved by multiples of 2 pi until it is on the correct side of the start angle
+type Renderer interface {
+	// Starts a new sub-path at the given point
+	MoveTo(x float64, y float64)
+	// Adds a straight line from the current point to the given point to the current path
+	LineTo(x float64, y float64)
+	// Adds a counter-clockwise arc to the current path.  If there is a current point, a straight line is added from it to the start of the arc
+	Arc(centerX float64, centerY float64, radius float64, startAngle float64, endAngle float64)
+	// Adds a clockwise arc to the current path.  If there is a current point, a straight line is added from it to the start of the arc
+	ArcNegative(centerX float64, centerY float64, radius float64, startAngle float64, endAngle float64)
+	// Closes the current sub-path with a straight line back to its start
+	ClosePath()
+	// Fills the current path with the current polarity, using the even/odd fill rule, then clears the path
+	Fill()
+	// Sets the polarity used by Fill and Flash
+	SetPolarity(polarity Polarity)
+	// Draws the aperture, centered on the given point, with the current polarity
+	Flash(aperture Aperture, gfxState *GraphicsState, x float64, y float64) error
+	// The same as Flash, but the hole (if the aperture has one) is left filled in
+	FlashNoHole(aperture Aperture, gfxState *GraphicsState, x float64, y float64) error
+	// Applies a translation, followed by a counter-clockwise rotation (in radians), on top of the current transform,
+	// until the matching call to PopTransform
+	PushTransform(xOffset float64, yOffset float64, rotation float64)
+	PopTransform()
+}
+
+// A renderer that can draw a whole stroke of an aperture itself (for example, as a stroked path in a vector format)
+// can also implement this.  If it returns false, the stroke is drawn by the aperture with the Renderer operations as usual
+type StrokeRenderer interface {
+	StrokeLinear(aperture Aperture, gfxState *GraphicsState, startX float64, startY float64, endX float64, endY float64) (bool, error)
+	StrokeArc(aperture Aperture, gfxState *GraphicsState, centerX float64, centerY float64, radius float64, startAngle float64, endAngle float64, clockwise bool) (bool, error)
+}
+
+// A translation, followed by a counter-clockwise rotation.  Renderers that don't have transforms of their own can apply these
+// to the coordinates directly as they're drawn (the same way cairo does), so that a path can be built up across a change in transform
+type pathTransform struct {
+	xOffset float64
+	yOffset float64
+	rotation float64
+}
+
+func (transform pathTransform) apply(x float64, y float64) (float64, float64) {
+	if transform.rotation != 0.0 {
+		sin,cos := math.Sincos(transform.rotation)
+		x,y = (x * cos) - (y * sin),(x * sin) + (y * cos)
+	}
+
+	return x + transform.xOffset,y + transform.yOffset
+}
+
+// The transform for a call to PushTransform on top of this one.  The new transform is applied underneath the current one
+func (transform pathTransform) push(xOffset float64, yOffset float64, rotation float64) pathTransform {
+	x,y := transform.apply(xOffset, yOffset)
+	return pathTransform{x, y, transform.rotation + rotation}
+}
+
+// Draws a parsed file with the given renderer.  The renderer gets coordinates in the units of the file, so any scaling
+// onto the output has to be done by the renderer itself
+func Render(renderer Renderer, parsedFile []DataBlock, options RenderOptions) error {
+	gfxState := newGraphicsState()
+	gfxState.setRenderOptions(options)
+
+	if err := renderDataBlocks(renderer, parsedFile, gfxState); err != nil {
+		return err
+	}
+
+	return checkFileComplete(gfxState)
+}
+
+func renderDataBlocks(renderer Renderer, parsedFile []DataBlock, gfxState *GraphicsState) error {
+	renderer.SetPolarity(gfxState.currentLevelPolarity)
+
+	for _,dataBlock := range parsedFile {
+		if err := dataBlock.ProcessDataBlockRender(renderer, gfxState); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func strokeLinear(renderer Renderer, aperture Aperture, gfxState *GraphicsState, startX float64, startY float64, endX float64, endY float64) error {
+	if strokeRenderer,ok := renderer.(StrokeRenderer); ok {
+		if stroked,err := strokeRenderer.StrokeLinear(aperture, gfxState, startX, startY, endX, endY); err != nil || stroked {
+			return err
+		}
+	}
+
+	return aperture.StrokeApertureLinear(renderer, gfxState, startX, startY, endX, endY)
+}
+
+func strokeArc(renderer Renderer, aperture Aperture, gfxState *GraphicsState, centerX float64, centerY float64, radius float64, startAngle float64, endAngle float64, clockwise bool) error {
+	if strokeRenderer,ok := renderer.(StrokeRenderer); ok {
+		if stroked,err := strokeRenderer.StrokeArc(aperture, gfxState, centerX, centerY, radius, startAngle, endAngle, clockwise); err != nil || stroked {
+			return err
+		}
+	}
+
+	if clockwise {
+		return aperture.StrokeApertureClockwise(renderer, gfxState, centerX, centerY, radius, startAngle, endAngle)
+	}
+
+	return aperture.StrokeApertureCounterClockwise(renderer, gfxState, centerX, centerY, radius, startAngle, endAngle)
+}
+
+// Fills all of the rings of the polygons as a single path.  The polygons don't overlap and their holes are inside them,
+// so the even/odd fill rule fills exactly their area
+func fillPolygons(renderer Renderer, polygons []polygon.Polygon) {
+	rings := polygon.Rings(polygons)
+	if len(rings) == 0 {
+		return
+	}
+
+	for _,ring := range rings {
+		renderer.MoveTo(ring[0].X, ring[0].Y)
+		for _,point := range ring[1:] {
+			renderer.LineTo(point.X, point.Y)
+		}
+		renderer.ClosePath()
+	}
+	renderer.Fill()
+}
+
+// Runs through the whole file, just keeping track of the bounds of everything that would be drawn,
+// and the units they're in
+func computeImageBounds(parsedFile []DataBlock, options RenderOptions) (*ImageBounds, Units, error) {
+	gfxStateBounds := newGraphicsState()
+	gfxStateBounds.setRenderOptions(options)
+	bounds := newImageBounds()
+
+	for _,dataBlock := range parsedFile {
+		if err := dataBlock.ProcessDataBlockBoundsCheck(bounds, gfxStateBounds); err != nil {
+			return nil,gfxStateBounds.units,err
+		}
+	}
+
+	gfxStateBounds.logger.Debug("Computed image bounds", "xMin", bounds.xMin, "xMax", bounds.xMax, "yMin", bounds.yMin, "yMax", bounds.yMax)
+
+	return bounds,gfxStateBounds.units,nil
+}
+
+func checkFileComplete(gfxState *GraphicsState) error {
+	// Make sure that the entire file was rendered
+	if !gfxState.fileComplete {
+		return fmt.Errorf("Render of file completed without reaching end of file code (M02)")
+	}
+
+	return nil
+}
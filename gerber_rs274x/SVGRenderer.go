@@ -0,0 +1,330 @@
+package gerber_rs274x
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Draws into an SVG document, in the units of the gerber file.  Apertures are defined once and then referenced
+// wherever they're flashed, draws with circular apertures are written as stroked paths, and everything drawn with clear
+// polarity becomes a mask over everything drawn before it
+type svgRenderer struct {
+	bounds *ImageBounds
+	polarity Polarity
+	// Aperture definitions and masks
+	defs strings.Builder
+	definedApertures map[string]bool
+	numMasks int
+	// Everything drawn so far with dark polarity
+	content strings.Builder
+	// Everything drawn with clear polarity since the last dark content.  This is turned into a mask over
+	// the dark content once the polarity goes back to dark
+	clearContent strings.Builder
+	// The path being built up for the next fill
+	path strings.Builder
+	// The current point of the path, as it was written out (empty if there isn't one)
+	currentPoint string
+	currentTransform pathTransform
+	transformStack []pathTransform
+}
+
+// Renders the parsed file as an SVG image.  Everything is drawn in the units of the file (inches or millimeters),
+// so the image is 1:1 with the real board and scales without losing any detail
+func RenderSVG(w io.Writer, blocks []DataBlock, options RenderOptions) error {
+	// First, need to run through the file just keeping track of the bounds, so we can size the image
+	bounds,_,err := computeImageBounds(blocks, options)
+	if err != nil {
+		return err
+	}
+
+	gfxState := newGraphicsState()
+	gfxState.setRenderOptions(options)
+
+	renderer := newSVGRenderer(bounds)
+	if err := renderDataBlocks(renderer, blocks, gfxState); err != nil {
+		return err
+	}
+
+	if err := renderer.writeDocument(w, gfxState.units); err != nil {
+		return err
+	}
+
+	return checkFileComplete(gfxState)
+}
+
+func newSVGRenderer(bounds *ImageBounds) *svgRenderer {
+	renderer := new(svgRenderer)
+
+	renderer.bounds = bounds
+	renderer.polarity = DARK_POLARITY
+	renderer.definedApertures = make(map[string]bool, 10) // Start with an initial capacity of 10 apertures, will grow as needed
+
+	return renderer
+}
+
+func (renderer *svgRenderer) MoveTo(x float64, y float64) {
+	x,y = renderer.currentTransform.apply(x, y)
+	renderer.currentPoint = formatSVGPoint(x, y)
+	fmt.Fprintf(&renderer.path, "M%s ", renderer.currentPoint)
+}
+
+func (renderer *svgRenderer) LineTo(x float64, y float64) {
+	x,y = renderer.currentTransform.apply(x, y)
+	appendSVGLine(&renderer.path, &renderer.currentPoint, formatSVGPoint(x, y))
+}
+
+func (renderer *svgRenderer) Arc(centerX float64, centerY float64, radius float64, startAngle float64, endAngle float64) {
+	centerX,centerY = renderer.currentTransform.apply(centerX, centerY)
+	rotation := renderer.currentTransform.rotation
+	appendSVGArc(&renderer.path, &renderer.currentPoint, centerX, centerY, radius, startAngle + rotation, endAngle + rotation, false)
+}
+
+func (renderer *svgRenderer) ArcNegative(centerX float64, centerY float64, radius float64, startAngle float64, endAngle float64) {
+	centerX,centerY = renderer.currentTransform.apply(centerX, centerY)
+	rotation := renderer.currentTransform.rotation
+	appendSVGArc(&renderer.path, &renderer.currentPoint, centerX, centerY, radius, startAngle + rotation, endAngle + rotation, true)
+}
+
+func (renderer *svgRenderer) ClosePath() {
+	if renderer.currentPoint != "" {
+		renderer.path.WriteString("Z ")
+	}
+}
+
+func (renderer *svgRenderer) Fill() {
+	if renderer.path.Len() > 0 {
+		fmt.Fprintf(renderer.output(), "<path d=\"%s\"/>\n", strings.TrimSpace(renderer.path.String()))
+	}
+
+	renderer.path.Reset()
+	renderer.currentPoint = ""
+}
+
+func (renderer *svgRenderer) SetPolarity(polarity Polarity) {
+	if renderer.polarity == CLEAR_POLARITY && polarity == DARK_POLARITY {
+		// New dark content has to be drawn on top of the holes cleared so far
+		renderer.applyClearContent()
+	}
+
+	renderer.polarity = polarity
+}
+
+func (renderer *svgRenderer) Flash(aperture Aperture, gfxState *GraphicsState, x float64, y float64) error {
+	return renderer.useAperture(aperture, gfxState, x, y, true)
+}
+
+func (renderer *svgRenderer) FlashNoHole(aperture Aperture, gfxState *GraphicsState, x float64, y float64) error {
+	// Without a hole, both versions of the aperture are the same, so we use the same definition for both
+	return renderer.useAperture(aperture, gfxState, x, y, aperture.GetHole() == nil)
+}
+
+func (renderer *svgRenderer) PushTransform(xOffset float64, yOffset float64, rotation float64) {
+	renderer.transformStack = append(renderer.transformStack, renderer.currentTransform)
+
+	renderer.currentTransform = renderer.currentTransform.push(xOffset, yOffset, rotation)
+}
+
+func (renderer *svgRenderer) PopTransform() {
+	if len(renderer.transformStack) == 0 {
+		return
+	}
+
+	renderer.currentTransform = renderer.transformStack[len(renderer.transformStack) - 1]
+	renderer.transformStack = renderer.transformStack[:len(renderer.transformStack) - 1]
+}
+
+func (renderer *svgRenderer) StrokeLinear(aperture Aperture, gfxState *GraphicsState, startX float64, startY float64, endX float64, endY float64) (bool, error) {
+	if width,ok := svgStrokeWidth(aperture); !ok {
+		return false,nil
+	} else {
+		startX,startY = renderer.currentTransform.apply(startX, startY)
+		endX,endY = renderer.currentTransform.apply(endX, endY)
+		// A line that starts and ends at the same point is kept, since it's still drawn as a dot because of the round line cap
+		d := fmt.Sprintf("M%s L%s", formatSVGPoint(startX, startY), formatSVGPoint(endX, endY))
+		renderer.writeStroke(d, width)
+
+		return true,nil
+	}
+}
+
+func (renderer *svgRenderer) StrokeArc(aperture Aperture, gfxState *GraphicsState, centerX float64, centerY float64, radius float64, startAngle float64, endAngle float64, clockwise bool) (bool, error) {
+	if width,ok := svgStrokeWidth(aperture); !ok {
+		return false,nil
+	} else {
+		centerX,centerY = renderer.currentTransform.apply(centerX, centerY)
+		rotation := renderer.currentTransform.rotation
+		var d strings.Builder
+		currentPoint := ""
+		appendSVGArc(&d, &currentPoint, centerX, centerY, radius, startAngle + rotation, endAngle + rotation, clockwise)
+		renderer.writeStroke(strings.TrimSpace(d.String()), width)
+
+		return true,nil
+	}
+}
+
+// Strokes can only be written as stroked paths if the aperture is a circle without a hole.  Anything else
+// is drawn by the aperture itself
+func svgStrokeWidth(aperture Aperture) (float64, bool) {
+	if circle,ok := aperture.(*CircleAperture); ok && circle.Hole == nil {
+		return circle.diameter,true
+	}
+
+	return 0.0,false
+}
+
+func (renderer *svgRenderer) writeStroke(d string, width float64) {
+	fmt.Fprintf(renderer.output(), "<path d=\"%s\" fill=\"none\" stroke=\"currentColor\" stroke-width=\"%s\" stroke-linecap=\"round\" stroke-linejoin=\"round\"/>\n", d, formatSVGNumber(width))
+}
+
+func (renderer *svgRenderer) useAperture(aperture Aperture, gfxState *GraphicsState, x float64, y float64, withHole bool) error {
+	id := fmt.Sprintf("aperture-%d", aperture.GetApertureNumber())
+	if !withHole {
+		id += "-nohole"
+	}
+
+	if !renderer.definedApertures[id] {
+		// The first time an aperture is used, it's drawn into its own renderer, centered on the origin,
+		// and saved as a definition that can be referenced every time it's used after that
+		apertureRenderer := newSVGRenderer(renderer.bounds)
+		if err := aperture.RenderApertureShape(apertureRenderer, gfxState, withHole); err != nil {
+			return err
+		}
+
+		fmt.Fprintf(&renderer.defs, "<g id=\"%s\">\n%s</g>\n", id, apertureRenderer.content.String())
+		renderer.definedApertures[id] = true
+	}
+
+	x,y = renderer.currentTransform.apply(x, y)
+	if renderer.currentTransform.rotation == 0.0 {
+		fmt.Fprintf(renderer.output(), "<use xlink:href=\"#%s\" x=\"%s\" y=\"%s\"/>\n", id, formatSVGNumber(x), formatSVGNumber(y))
+	} else {
+		fmt.Fprintf(renderer.output(), "<use xlink:href=\"#%s\" transform=\"translate(%s %s) rotate(%s)\"/>\n", id, formatSVGNumber(x), formatSVGNumber(y), formatSVGNumber(renderer.currentTransform.rotation * (180.0 / math.Pi)))
+	}
+
+	return nil
+}
+
+func (renderer *svgRenderer) output() *strings.Builder {
+	if renderer.polarity == CLEAR_POLARITY {
+		return &renderer.clearContent
+	}
+
+	return &renderer.content
+}
+
+// Turns everything drawn with clear polarity into a mask, and applies it to everything drawn with dark polarity before it
+func (renderer *svgRenderer) applyClearContent() {
+	if renderer.clearContent.Len() == 0 {
+		return
+	}
+
+	renderer.numMasks++
+	id := fmt.Sprintf("clear-%d", renderer.numMasks)
+	x := formatSVGNumber(renderer.bounds.xMin)
+	y := formatSVGNumber(renderer.bounds.yMin)
+	width := formatSVGNumber(renderer.bounds.xMax - renderer.bounds.xMin)
+	height := formatSVGNumber(renderer.bounds.yMax - renderer.bounds.yMin)
+
+	// White parts of the mask are kept, and black parts are cleared
+	fmt.Fprintf(&renderer.defs, "<mask id=\"%s\" maskUnits=\"userSpaceOnUse\" x=\"%s\" y=\"%s\" width=\"%s\" height=\"%s\">\n", id, x, y, width, height)
+	fmt.Fprintf(&renderer.defs, "<rect x=\"%s\" y=\"%s\" width=\"%s\" height=\"%s\" fill=\"white\"/>\n", x, y, width, height)
+	fmt.Fprintf(&renderer.defs, "<g color=\"black\">\n%s</g>\n</mask>\n", renderer.clearContent.String())
+
+	masked := fmt.Sprintf("<g mask=\"url(#%s)\">\n%s</g>\n", id, renderer.content.String())
+	renderer.content.Reset()
+	renderer.content.WriteString(masked)
+	renderer.clearContent.Reset()
+}
+
+func (renderer *svgRenderer) writeDocument(w io.Writer, units Units) error {
+	renderer.applyClearContent()
+
+	var unitSuffix string
+	switch units {
+		case UNITS_IN:
+			unitSuffix = "in"
+
+		case UNITS_MM:
+			unitSuffix = "mm"
+	}
+
+	width := renderer.bounds.xMax - renderer.bounds.xMin
+	height := renderer.bounds.yMax - renderer.bounds.yMin
+
+	var document strings.Builder
+	document.WriteString("<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n")
+	// The y-axis of the gerber file points up, so the whole image is flipped, and the view box is flipped to match
+	fmt.Fprintf(&document, "<svg xmlns=\"http://www.w3.org/2000/svg\" xmlns:xlink=\"http://www.w3.org/1999/xlink\" width=\"%s%s\" height=\"%s%s\" viewBox=\"%s %s %s %s\">\n",
+		formatSVGNumber(width), unitSuffix, formatSVGNumber(height), unitSuffix,
+		formatSVGNumber(renderer.bounds.xMin), formatSVGNumber(-renderer.bounds.yMax), formatSVGNumber(width), formatSVGNumber(height))
+	document.WriteString("<g transform=\"scale(1 -1)\" color=\"black\" fill=\"currentColor\" fill-rule=\"evenodd\">\n")
+	fmt.Fprintf(&document, "<defs>\n%s</defs>\n", renderer.defs.String())
+	document.WriteString(renderer.content.String())
+	document.WriteString("</g>\n</svg>\n")
+
+	_,err := io.WriteString(w, document.String())
+	return err
+}
+
+// Adds a line to the path data.  Just like cairo, a line without a current point only moves to its end
+func appendSVGLine(path *strings.Builder, currentPoint *string, point string) {
+	if *currentPoint == "" {
+		fmt.Fprintf(path, "M%s ", point)
+	} else if *currentPoint != point {
+		fmt.Fprintf(path, "L%s ", point)
+	}
+
+	*currentPoint = point
+}
+
+// Adds an arc to the path data, following the same rules as cairo for the angles
+func appendSVGArc(path *strings.Builder, currentPoint *string, centerX float64, centerY float64, radius float64, startAngle float64, endAngle float64, clockwise bool) {
+	if clockwise {
+		for endAngle > startAngle {
+			endAngle -= TWO_PI
+		}
+	} else {
+		for endAngle < startAngle {
+			endAngle += TWO_PI
+		}
+	}
+
+	startX := centerX + (radius * math.Cos(startAngle))
+	startY := centerY + (radius * math.Sin(startAngle))
+	appendSVGLine(path, currentPoint, formatSVGPoint(startX, startY))
+
+	// An SVG arc command can't draw a whole circle, and is ambiguous at exactly half of one, so we split
+	// the arc into pieces of at most a quarter circle each
+	sweepFlag := 1
+	if clockwise {
+		sweepFlag = 0
+	}
+	span := endAngle - startAngle
+	segments := int(math.Ceil(math.Abs(span) / (math.Pi * 0.5)))
+	for segment := 1; segment <= segments; segment++ {
+		angle := startAngle + (span * float64(segment) / float64(segments))
+		x := centerX + (radius * math.Cos(angle))
+		y := centerY + (radius * math.Sin(angle))
+		*currentPoint = formatSVGPoint(x, y)
+		fmt.Fprintf(path, "A%s %s 0 0 %d %s ", formatSVGNumber(radius), formatSVGNumber(radius), sweepFlag, *currentPoint)
+	}
+}
+
+func formatSVGPoint(x float64, y float64) string {
+	return formatSVGNumber(x) + " " + formatSVGNumber(y)
+}
+
+// Formats a number for the SVG output, without any noise from floating point error in the last few digits
+func formatSVGNumber(value float64) string {
+	formatted := strconv.FormatFloat(value, 'f', 6, 64)
+	formatted = strings.TrimRight(formatted, "0")
+	formatted = strings.TrimSuffix(formatted, ".")
+	if formatted == "-0" {
+		return "0"
+	}
+
+	return formatted
+}
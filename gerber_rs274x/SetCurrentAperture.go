@@ -2,7 +2,6 @@ package gerber_rs274x
 
 import (
 	"fmt"
-	cairo "github.com/ungerik/go-cairo"
 )
 
 type SetCurrentAperture struct {
@@ -13,6 +12,10 @@ func (setCurrentAperture *SetCurrentAperture) DataBlockPlaceholder() {
 
 }
 
+func (setCurrentAperture *SetCurrentAperture) Accept(visitor Visitor) error {
+	return visitor.VisitSetCurrentAperture(setCurrentAperture)
+}
+
 func (setCurrentAperture *SetCurrentAperture) ProcessDataBlockBoundsCheck(imageBounds *ImageBounds, gfxState *GraphicsState) error {
 	// Make sure the aperture we're trying to switch to has already been defined
 	if _,exists := gfxState.apertures[setCurrentAperture.apertureNumber]; !exists {
@@ -25,7 +28,7 @@ func (setCurrentAperture *SetCurrentAperture) ProcessDataBlockBoundsCheck(imageB
 	return nil
 }
 
-func (setCurrentAperture *SetCurrentAperture) ProcessDataBlockSurface(surface *cairo.Surface, gfxState *GraphicsState) error {
+func (setCurrentAperture *SetCurrentAperture) ProcessDataBlockRender(renderer Renderer, gfxState *GraphicsState) error {
 	// Make sure the aperture we're trying to switch to has already been defined
 	if _,exists := gfxState.apertures[setCurrentAperture.apertureNumber]; !exists {
 		return fmt.Errorf("Unable to switch to undefined aperture %d", setCurrentAperture.apertureNumber)
@@ -39,4 +42,8 @@ func (setCurrentAperture *SetCurrentAperture) ProcessDataBlockSurface(surface *c
 
 func (setCurrentAperture *SetCurrentAperture) String() string {
 	return fmt.Sprintf("{SET APERTURE, Aperture: %d}", setCurrentAperture.apertureNumber)
-}
\ No newline at end of file
+}
+
+func (setCurrentAperture *SetCurrentAperture) GetApertureNumber() int {
+	return setCurrentAperture.apertureNumber
+}
@@ -0,0 +1,329 @@
+package gerber_rs274x
+
+import (
+	"strconv"
+	"strings"
+)
+
+// What a file describes, from the first field of its .FileFunction attribute
+type FileFunctionType int
+// Which side of the board a layer is on
+type LayerSide int
+// Whether the image in a file is drawn as it is, or inverted, from the .FilePolarity attribute
+type FilePolarity int
+// Which part of the fabrication data a file belongs to, from the .Part attribute
+type PartType int
+// What an aperture is used for, from the first field of its .AperFunction attribute
+type AperFunctionType int
+
+const (
+	FILE_FUNCTION_UNKNOWN FileFunctionType = iota // Not one of the functions in the spec (the name is still kept)
+	FILE_FUNCTION_COPPER
+	FILE_FUNCTION_PLATED
+	FILE_FUNCTION_NON_PLATED
+	FILE_FUNCTION_PROFILE
+	FILE_FUNCTION_SOLDERMASK
+	FILE_FUNCTION_LEGEND
+	FILE_FUNCTION_COMPONENT
+	FILE_FUNCTION_PASTE
+	FILE_FUNCTION_GLUE
+	FILE_FUNCTION_CARBONMASK
+	FILE_FUNCTION_GOLDMASK
+	FILE_FUNCTION_HEATSINKMASK
+	FILE_FUNCTION_PEELABLEMASK
+	FILE_FUNCTION_SILVERMASK
+	FILE_FUNCTION_TINMASK
+	FILE_FUNCTION_DEPTHROUT
+	FILE_FUNCTION_VCUT
+	FILE_FUNCTION_VIAFILL
+	FILE_FUNCTION_PADS
+	FILE_FUNCTION_OTHER
+	FILE_FUNCTION_DRILLMAP
+	FILE_FUNCTION_FABRICATION_DRAWING
+	FILE_FUNCTION_VCUTMAP
+	FILE_FUNCTION_ASSEMBLY_DRAWING
+	FILE_FUNCTION_ARRAY_DRAWING
+	FILE_FUNCTION_OTHER_DRAWING
+)
+
+const (
+	SIDE_UNKNOWN LayerSide = iota
+	SIDE_TOP // Top
+	SIDE_INNER // Inr: an inner copper layer
+	SIDE_BOTTOM // Bot
+)
+
+const (
+	FILE_POLARITY_UNKNOWN FilePolarity = iota
+	FILE_POLARITY_POSITIVE
+	FILE_POLARITY_NEGATIVE
+)
+
+const (
+	PART_UNKNOWN PartType = iota
+	PART_SINGLE // A single PCB
+	PART_ARRAY // An array of PCBs (a customer panel)
+	PART_FABRICATION_PANEL
+	PART_COUPON
+	PART_OTHER
+)
+
+const (
+	APER_FUNCTION_UNKNOWN AperFunctionType = iota // Not one of the functions in the spec (the name is still kept)
+	APER_FUNCTION_VIA_DRILL
+	APER_FUNCTION_BACK_DRILL
+	APER_FUNCTION_COMPONENT_DRILL
+	APER_FUNCTION_MECHANICAL_DRILL
+	APER_FUNCTION_CASTELLATED_DRILL
+	APER_FUNCTION_OTHER_DRILL
+	APER_FUNCTION_COMPONENT_PAD
+	APER_FUNCTION_SMD_PAD
+	APER_FUNCTION_BGA_PAD
+	APER_FUNCTION_CONNECTOR_PAD
+	APER_FUNCTION_HEATSINK_PAD
+	APER_FUNCTION_VIA_PAD
+	APER_FUNCTION_TEST_PAD
+	APER_FUNCTION_CASTELLATED_PAD
+	APER_FUNCTION_FIDUCIAL_PAD
+	APER_FUNCTION_THERMAL_RELIEF_PAD
+	APER_FUNCTION_WASHER_PAD
+	APER_FUNCTION_ANTI_PAD
+	APER_FUNCTION_OTHER_PAD
+	APER_FUNCTION_CONDUCTOR
+	APER_FUNCTION_ETCHED_COMPONENT
+	APER_FUNCTION_NON_CONDUCTOR
+	APER_FUNCTION_COPPER_BALANCING
+	APER_FUNCTION_BORDER
+	APER_FUNCTION_OTHER_COPPER
+	APER_FUNCTION_PROFILE
+	APER_FUNCTION_NON_MATERIAL
+	APER_FUNCTION_MATERIAL
+	APER_FUNCTION_OTHER
+)
+
+var fileFunctionTypes = map[string]FileFunctionType{
+	"Copper": FILE_FUNCTION_COPPER,
+	"Plated": FILE_FUNCTION_PLATED,
+	"NonPlated": FILE_FUNCTION_NON_PLATED,
+	"Profile": FILE_FUNCTION_PROFILE,
+	"Soldermask": FILE_FUNCTION_SOLDERMASK,
+	"Legend": FILE_FUNCTION_LEGEND,
+	"Component": FILE_FUNCTION_COMPONENT,
+	"Paste": FILE_FUNCTION_PASTE,
+	"Glue": FILE_FUNCTION_GLUE,
+	"Carbonmask": FILE_FUNCTION_CARBONMASK,
+	"Goldmask": FILE_FUNCTION_GOLDMASK,
+	"Heatsinkmask": FILE_FUNCTION_HEATSINKMASK,
+	"Peelablemask": FILE_FUNCTION_PEELABLEMASK,
+	"Silvermask": FILE_FUNCTION_SILVERMASK,
+	"Tinmask": FILE_FUNCTION_TINMASK,
+	"Depthrout": FILE_FUNCTION_DEPTHROUT,
+	"Vcut": FILE_FUNCTION_VCUT,
+	"Viafill": FILE_FUNCTION_VIAFILL,
+	"Pads": FILE_FUNCTION_PADS,
+	"Other": FILE_FUNCTION_OTHER,
+	"Drillmap": FILE_FUNCTION_DRILLMAP,
+	"FabricationDrawing": FILE_FUNCTION_FABRICATION_DRAWING,
+	"Vcutmap": FILE_FUNCTION_VCUTMAP,
+	"AssemblyDrawing": FILE_FUNCTION_ASSEMBLY_DRAWING,
+	"ArrayDrawing": FILE_FUNCTION_ARRAY_DRAWING,
+	"OtherDrawing": FILE_FUNCTION_OTHER_DRAWING,
+}
+
+var aperFunctionTypes = map[string]AperFunctionType{
+	"ViaDrill": APER_FUNCTION_VIA_DRILL,
+	"BackDrill": APER_FUNCTION_BACK_DRILL,
+	"ComponentDrill": APER_FUNCTION_COMPONENT_DRILL,
+	"MechanicalDrill": APER_FUNCTION_MECHANICAL_DRILL,
+	"CastellatedDrill": APER_FUNCTION_CASTELLATED_DRILL,
+	"OtherDrill": APER_FUNCTION_OTHER_DRILL,
+	"ComponentPad": APER_FUNCTION_COMPONENT_PAD,
+	"SMDPad": APER_FUNCTION_SMD_PAD,
+	"BGAPad": APER_FUNCTION_BGA_PAD,
+	"ConnectorPad": APER_FUNCTION_CONNECTOR_PAD,
+	"HeatsinkPad": APER_FUNCTION_HEATSINK_PAD,
+	"ViaPad": APER_FUNCTION_VIA_PAD,
+	"TestPad": APER_FUNCTION_TEST_PAD,
+	"CastellatedPad": APER_FUNCTION_CASTELLATED_PAD,
+	"FiducialPad": APER_FUNCTION_FIDUCIAL_PAD,
+	"ThermalReliefPad": APER_FUNCTION_THERMAL_RELIEF_PAD,
+	"WasherPad": APER_FUNCTION_WASHER_PAD,
+	"AntiPad": APER_FUNCTION_ANTI_PAD,
+	"OtherPad": APER_FUNCTION_OTHER_PAD,
+	"Conductor": APER_FUNCTION_CONDUCTOR,
+	"EtchedComponent": APER_FUNCTION_ETCHED_COMPONENT,
+	"NonConductor": APER_FUNCTION_NON_CONDUCTOR,
+	"CopperBalancing": APER_FUNCTION_COPPER_BALANCING,
+	"Border": APER_FUNCTION_BORDER,
+	"OtherCopper": APER_FUNCTION_OTHER_COPPER,
+	"Profile": APER_FUNCTION_PROFILE,
+	"NonMaterial": APER_FUNCTION_NON_MATERIAL,
+	"Material": APER_FUNCTION_MATERIAL,
+	"Other": APER_FUNCTION_OTHER,
+}
+
+// The standard file attributes, parsed into their values.  Attributes the file doesn't have are left as their zero values
+type FileAttributes struct {
+	FileFunction *FileFunction
+	FilePolarity FilePolarity
+	Part PartType
+	// For PART_OTHER, the description of the part
+	PartDescription string
+	GenerationSoftware *GenerationSoftware
+	// Set if the file has a .SameCoordinates attribute, which means it's aligned with the other files with the same identifier
+	SameCoordinates bool
+	SameCoordinatesIdentifier string
+	// Every file attribute, including the standard ones above, with its values as they were in the file
+	Raw Attributes
+}
+
+// The .FileFunction attribute, which says which layer (or other data) the file holds
+type FileFunction struct {
+	Type FileFunctionType
+	// The function as it was written in the file, which is all there is to go on for FILE_FUNCTION_UNKNOWN
+	Name string
+	// For copper layers (and component layers), the copper layer number, starting at 1 for the top
+	CopperLayer int
+	// The side the layer is on, if it's given
+	Side LayerSide
+	// For drill and rout files, the first and last copper layers the holes go between
+	FromLayer int
+	ToLayer int
+	// The fields after the function, as they were written in the file (e.g. "L2", "Inr" and "Plane" for an inner plane)
+	Fields []string
+}
+
+// The .GenerationSoftware attribute, which says what wrote the file
+type GenerationSoftware struct {
+	Vendor string
+	Application string
+	Version string
+}
+
+// The .AperFunction attribute, which says what the objects drawn with an aperture are for
+type AperFunction struct {
+	Type AperFunctionType
+	// The function as it was written in the file, which is all there is to go on for APER_FUNCTION_UNKNOWN
+	Name string
+	// The fields after the function, as they were written in the file (e.g. "CuDef" for an SMD pad)
+	Fields []string
+}
+
+// Works out the standard file attributes from the file attributes of a file
+func ParseFileAttributes(attributes Attributes) *FileAttributes {
+	fileAttributes := new(FileAttributes)
+	fileAttributes.Raw = attributes
+
+	if values := attributes[".FileFunction"]; len(values) > 0 {
+		fileAttributes.FileFunction = parseFileFunction(values)
+	}
+
+	switch attributes.Value(".FilePolarity") {
+		case "Positive":
+			fileAttributes.FilePolarity = FILE_POLARITY_POSITIVE
+
+		case "Negative":
+			fileAttributes.FilePolarity = FILE_POLARITY_NEGATIVE
+	}
+
+	if values := attributes[".Part"]; len(values) > 0 {
+		switch values[0] {
+			case "Single":
+				fileAttributes.Part = PART_SINGLE
+
+			case "Array":
+				fileAttributes.Part = PART_ARRAY
+
+			case "FabricationPanel":
+				fileAttributes.Part = PART_FABRICATION_PANEL
+
+			case "Coupon":
+				fileAttributes.Part = PART_COUPON
+
+			case "Other":
+				fileAttributes.Part = PART_OTHER
+				fileAttributes.PartDescription = strings.Join(values[1:], ",")
+		}
+	}
+
+	if values,found := attributes[".GenerationSoftware"]; found {
+		generationSoftware := new(GenerationSoftware)
+		fields := []*string{&generationSoftware.Vendor, &generationSoftware.Application, &generationSoftware.Version}
+		for i := 0; i < len(fields) && i < len(values); i++ {
+			*fields[i] = values[i]
+		}
+		fileAttributes.GenerationSoftware = generationSoftware
+	}
+
+	if values,found := attributes[".SameCoordinates"]; found {
+		fileAttributes.SameCoordinates = true
+		fileAttributes.SameCoordinatesIdentifier = strings.Join(values, ",")
+	}
+
+	return fileAttributes
+}
+
+func parseFileFunction(values []string) *FileFunction {
+	fileFunction := new(FileFunction)
+	fileFunction.Name = values[0]
+	fileFunction.Type = fileFunctionTypes[values[0]]
+	fileFunction.Fields = values[1:]
+
+	fields := fileFunction.Fields
+	if (fileFunction.Type == FILE_FUNCTION_PLATED) || (fileFunction.Type == FILE_FUNCTION_NON_PLATED) {
+		// The first two fields are the copper layers the holes go from and to
+		if len(fields) >= 2 {
+			fileFunction.FromLayer,_ = strconv.Atoi(fields[0])
+			fileFunction.ToLayer,_ = strconv.Atoi(fields[1])
+			fields = fields[2:]
+		}
+	}
+
+	for _,field := range fields {
+		if side := parseLayerSide(field); (side != SIDE_UNKNOWN) && (fileFunction.Side == SIDE_UNKNOWN) {
+			fileFunction.Side = side
+		} else if (len(field) > 1) && (field[0] == 'L') && (fileFunction.CopperLayer == 0) {
+			if layer,err := strconv.Atoi(field[1:]); err == nil {
+				fileFunction.CopperLayer = layer
+			}
+		}
+	}
+
+	return fileFunction
+}
+
+func parseLayerSide(field string) LayerSide {
+	switch field {
+		case "Top":
+			return SIDE_TOP
+
+		case "Inr":
+			return SIDE_INNER
+
+		case "Bot":
+			return SIDE_BOTTOM
+
+		default:
+			return SIDE_UNKNOWN
+	}
+}
+
+// Works out the .AperFunction attribute from the attributes of a draw or flash.  Returns nil if there isn't one
+func ParseAperFunction(attributes Attributes) *AperFunction {
+	values := attributes[".AperFunction"]
+	if len(values) == 0 {
+		return nil
+	}
+
+	aperFunction := new(AperFunction)
+	aperFunction.Name = values[0]
+	aperFunction.Type = aperFunctionTypes[values[0]]
+	aperFunction.Fields = values[1:]
+
+	return aperFunction
+}
+
+// Whether the pad is copper defined (CuDef) rather than solder mask defined (SMDef).  Only SMD and BGA pads say which they are
+func (aperFunction *AperFunction) CopperDefined() bool {
+	return (len(aperFunction.Fields) > 0) && (aperFunction.Fields[0] == "CuDef")
+}
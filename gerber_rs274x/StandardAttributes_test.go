@@ -0,0 +1,75 @@
+package gerber_rs274x
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseFileAttributes(t *testing.T) {
+	fileAttributes := ParseFileAttributes(Attributes{
+		".FileFunction": {"Copper", "L2", "Inr"},
+		".FilePolarity": {"Negative"},
+		".Part": {"Other", "Test", "coupon"},
+		".GenerationSoftware": {"Vendor", "Tool"},
+		".SameCoordinates": {"Panel1"},
+	})
+
+	expectedFunction := &FileFunction{Type: FILE_FUNCTION_COPPER, Name: "Copper", CopperLayer: 2, Side: SIDE_INNER, Fields: []string{"L2", "Inr"}}
+	if !reflect.DeepEqual(fileAttributes.FileFunction, expectedFunction) {
+		t.Errorf(".FileFunction parsed as %+v, expected %+v", fileAttributes.FileFunction, expectedFunction)
+	}
+
+	if fileAttributes.FilePolarity != FILE_POLARITY_NEGATIVE {
+		t.Errorf(".FilePolarity parsed as %v, expected negative", fileAttributes.FilePolarity)
+	}
+
+	if (fileAttributes.Part != PART_OTHER) || (fileAttributes.PartDescription != "Test,coupon") {
+		t.Errorf(".Part parsed as %v (%s), expected other (Test,coupon)", fileAttributes.Part, fileAttributes.PartDescription)
+	}
+
+	// The version is optional
+	expectedSoftware := &GenerationSoftware{Vendor: "Vendor", Application: "Tool"}
+	if !reflect.DeepEqual(fileAttributes.GenerationSoftware, expectedSoftware) {
+		t.Errorf(".GenerationSoftware parsed as %+v, expected %+v", fileAttributes.GenerationSoftware, expectedSoftware)
+	}
+
+	if !fileAttributes.SameCoordinates || (fileAttributes.SameCoordinatesIdentifier != "Panel1") {
+		t.Errorf(".SameCoordinates parsed as %v (%s), expected true (Panel1)", fileAttributes.SameCoordinates, fileAttributes.SameCoordinatesIdentifier)
+	}
+
+	// Drill files give the copper layers the holes go between
+	drill := ParseFileAttributes(Attributes{".FileFunction": {"Plated", "1", "4", "PTH"}})
+	if (drill.FileFunction.Type != FILE_FUNCTION_PLATED) || (drill.FileFunction.FromLayer != 1) || (drill.FileFunction.ToLayer != 4) {
+		t.Errorf("Drill .FileFunction parsed as %+v, expected plated from layer 1 to 4", drill.FileFunction)
+	}
+
+	// Attributes the file doesn't have are left as zero values
+	empty := ParseFileAttributes(nil)
+	if (empty.FileFunction != nil) || (empty.FilePolarity != FILE_POLARITY_UNKNOWN) || (empty.Part != PART_UNKNOWN) || empty.SameCoordinates {
+		t.Errorf("Missing attributes parsed as %+v, expected zero values", empty)
+	}
+}
+
+func TestParseAperFunction(t *testing.T) {
+	aperFunction := ParseAperFunction(Attributes{".AperFunction": {"SMDPad", "CuDef"}, ".N": {"GND"}})
+	expected := &AperFunction{Type: APER_FUNCTION_SMD_PAD, Name: "SMDPad", Fields: []string{"CuDef"}}
+	if !reflect.DeepEqual(aperFunction, expected) {
+		t.Fatalf(".AperFunction parsed as %+v, expected %+v", aperFunction, expected)
+	}
+	if !aperFunction.CopperDefined() {
+		t.Errorf("SMDPad,CuDef isn't copper defined")
+	}
+
+	if solderMaskDefined := ParseAperFunction(Attributes{".AperFunction": {"SMDPad", "SMDef"}}); solderMaskDefined.CopperDefined() {
+		t.Errorf("SMDPad,SMDef is copper defined")
+	}
+
+	// Functions that aren't in the standard are kept by name
+	if unknown := ParseAperFunction(Attributes{".AperFunction": {"Mystery"}}); (unknown.Type != APER_FUNCTION_UNKNOWN) || (unknown.Name != "Mystery") {
+		t.Errorf("Unknown .AperFunction parsed as %+v", unknown)
+	}
+
+	if missing := ParseAperFunction(Attributes{".N": {"GND"}}); missing != nil {
+		t.Errorf("Attributes without .AperFunction parsed as %+v, expected nil", missing)
+	}
+}
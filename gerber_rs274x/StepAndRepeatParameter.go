@@ -2,7 +2,6 @@ package gerber_rs274x
 
 import (
 	"fmt"
-	cairo "github.com/ungerik/go-cairo"
 )
 
 type StepAndRepeatParameter struct {
@@ -11,23 +10,112 @@ type StepAndRepeatParameter struct {
 	yRepeats int
 	xStepDistance float64
 	yStepDistance float64
+	// The data blocks between this parameter and the end of the step and repeat block,
+	// which are replicated once for every step
+	dataBlocks []DataBlock
 }
 
 func (stepAndRepeat *StepAndRepeatParameter) DataBlockPlaceholder() {
 
 }
 
+func (stepAndRepeat *StepAndRepeatParameter) Accept(visitor Visitor) error {
+	return visitor.VisitStepAndRepeat(stepAndRepeat)
+}
+
 func (stepAndRepeat *StepAndRepeatParameter) ProcessDataBlockBoundsCheck(imageBounds *ImageBounds, gfxState *GraphicsState) error {
-	//TODO: Implement this
+	// Every copy of the block is identical, so we only need to check the bounds of the block once,
+	// and then extend those bounds out to cover the rest of the copies
+	startX := gfxState.currentX
+	startY := gfxState.currentY
+	startPolarity := gfxState.currentLevelPolarity
+	startNotation := gfxState.coordinateNotation
+	blockBounds := newImageBounds()
+
+	for _,dataBlock := range stepAndRepeat.dataBlocks {
+		if err := dataBlock.ProcessDataBlockBoundsCheck(blockBounds, gfxState); err != nil {
+			return err
+		}
+	}
+
+	// Once the whole step and repeat is done, the graphics state goes back to how it was before the block,
+	// the same as it does in the render pass
+	gfxState.updateCurrentCoordinate(startX, startY)
+	gfxState.currentLevelPolarity = startPolarity
+	gfxState.coordinateNotation = startNotation
+
+	if blockBounds.boundsSet {
+		xMax := blockBounds.xMax + (float64(stepAndRepeat.xRepeats - 1) * stepAndRepeat.xStepDistance)
+		yMax := blockBounds.yMax + (float64(stepAndRepeat.yRepeats - 1) * stepAndRepeat.yStepDistance)
+		imageBounds.updateBounds(blockBounds.xMin, xMax, blockBounds.yMin, yMax)
+	}
+
 	return nil
 }
 
-func (stepAndRepeat *StepAndRepeatParameter) ProcessDataBlockSurface(surface *cairo.Surface, gfxState *GraphicsState) error {
-	//TODO: Implement this
+func (stepAndRepeat *StepAndRepeatParameter) ProcessDataBlockRender(renderer Renderer, gfxState *GraphicsState) error {
+	// Every copy of the block has to start out with the same graphics state, so remember
+	// the parts of the state that the blocks inside the step and repeat can change
+	startX := gfxState.currentX
+	startY := gfxState.currentY
+	startPolarity := gfxState.currentLevelPolarity
+	startNotation := gfxState.coordinateNotation
+
+	for yRepeat := 0; yRepeat < stepAndRepeat.yRepeats; yRepeat++ {
+		for xRepeat := 0; xRepeat < stepAndRepeat.xRepeats; xRepeat++ {
+			gfxState.updateCurrentCoordinate(startX, startY)
+			gfxState.currentLevelPolarity = startPolarity
+			gfxState.coordinateNotation = startNotation
+			renderer.SetPolarity(startPolarity)
+
+			xOffset := float64(xRepeat) * stepAndRepeat.xStepDistance
+			yOffset := float64(yRepeat) * stepAndRepeat.yStepDistance
+
+			// Everything in this copy is drawn shifted by the offset of the copy
+			renderer.PushTransform(xOffset, yOffset, 0.0)
+
+			for _,dataBlock := range stepAndRepeat.dataBlocks {
+				if err := dataBlock.ProcessDataBlockRender(renderer, gfxState); err != nil {
+					renderer.PopTransform()
+					return err
+				}
+			}
+
+			renderer.PopTransform()
+		}
+	}
+
+	// Once the whole step and repeat is done, the graphics state goes back to how it was before the block,
+	// the same as it does in the bounds pass
+	gfxState.updateCurrentCoordinate(startX, startY)
+	gfxState.currentLevelPolarity = startPolarity
+	gfxState.coordinateNotation = startNotation
+	renderer.SetPolarity(startPolarity)
+
 	return nil
 }
 
 func (srParam *StepAndRepeatParameter) String() string {
-	return fmt.Sprintf("{SR, X Repeats: %d, Y Repeats: %d, I Step: %f, J Step: %f}", srParam.xRepeats, srParam.yRepeats, srParam.xStepDistance, srParam.yStepDistance)
+	return fmt.Sprintf("{SR, X Repeats: %d, Y Repeats: %d, I Step: %f, J Step: %f, Blocks: %v}", srParam.xRepeats, srParam.yRepeats, srParam.xStepDistance, srParam.yStepDistance, srParam.dataBlocks)
+}
+
+func (stepAndRepeat *StepAndRepeatParameter) GetXRepeats() int {
+	return stepAndRepeat.xRepeats
+}
+
+func (stepAndRepeat *StepAndRepeatParameter) GetYRepeats() int {
+	return stepAndRepeat.yRepeats
 }
 
+func (stepAndRepeat *StepAndRepeatParameter) GetXStepDistance() float64 {
+	return stepAndRepeat.xStepDistance
+}
+
+func (stepAndRepeat *StepAndRepeatParameter) GetYStepDistance() float64 {
+	return stepAndRepeat.yStepDistance
+}
+
+// The data blocks that make up one copy of the step and repeat block
+func (stepAndRepeat *StepAndRepeatParameter) GetDataBlocks() []DataBlock {
+	return stepAndRepeat.dataBlocks
+}
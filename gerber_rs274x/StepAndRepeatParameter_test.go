@@ -0,0 +1,63 @@
+package gerber_rs274x
+
+import (
+	"math"
+	"strings"
+	"testing"
+	"gerber_rs274x/polygon"
+)
+
+// A 2x3 step and repeat of a square pad, with a smaller clear square in the middle of each copy
+const stepAndRepeatFile = `%FSLAX24Y24*%
+%MOIN*%
+%ADD10R,0.1000X0.1000*%
+%ADD11R,0.0500X0.0500*%
+%SRX2Y3I0.5J0.4*%
+%LPD*%
+D10*
+X0Y0D03*
+%LPC*%
+D11*
+X0Y0D03*
+%SR*%
+M02*`
+
+func TestStepAndRepeat(t *testing.T) {
+	dataBlocks,err := ParseGerberFile(strings.NewReader(stepAndRepeatFile))
+	if err != nil {
+		t.Fatalf("Error parsing step and repeat: %v", err)
+	}
+
+	// The bounds cover every copy, not just the first one
+	if bounds,_,err := computeImageBounds(dataBlocks, RenderOptions{}); err != nil {
+		t.Fatalf("Error computing the bounds of the step and repeat: %v", err)
+	} else {
+		got := []float64{bounds.xMin, bounds.xMax, bounds.yMin, bounds.yMax}
+		expected := []float64{-0.05, 0.55, -0.05, 0.85}
+		for index := range got {
+			if math.Abs(got[index] - expected[index]) > 1e-9 {
+				t.Errorf("Step and repeat bounds are (xMin, xMax, yMin, yMax) %v, expected %v", got, expected)
+				break
+			}
+		}
+	}
+
+	// Every copy is drawn separately, and each one gets its own hole, since the polarity goes back to dark
+	// at the start of every copy
+	if geometry,err := ExtractGeometry(dataBlocks, GeometryOptions{}); err != nil {
+		t.Fatalf("Error extracting the geometry of the step and repeat: %v", err)
+	} else {
+		holes := 0
+		for _,shape := range geometry.Polygons {
+			holes += len(shape.Holes)
+		}
+
+		if (len(geometry.Polygons) != 6) || (holes != 6) {
+			t.Errorf("Step and repeat has %d copies with %d holes, expected 6 copies with 6 holes", len(geometry.Polygons), holes)
+		}
+
+		if area := polygon.Area(geometry.Polygons); math.Abs(area - (6.0 * (0.01 - 0.0025))) > 1e-9 {
+			t.Errorf("Step and repeat covers an area of %v, expected %v", area, 6.0 * (0.01 - 0.0025))
+		}
+	}
+}
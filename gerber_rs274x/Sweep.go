@@ -0,0 +1,172 @@
+package gerber_rs274x
+
+import (
+	"math"
+	"gerber_rs274x/polygon"
+)
+
+// The furthest an aperture swept along an arc turns around the center of the arc in a single step
+const MAX_SWEEP_STEP_ANGLE float64 = math.Pi / 8.0
+
+// Renderers that break arcs up into straight segments say how finely, so that arcs swept by an aperture can be broken up
+// into steps just as finely.  Other renderers get the default geometry tolerance
+type approximatingRenderer interface {
+	arcTolerance() float64
+}
+
+func sweepTolerance(renderer Renderer, gfxState *GraphicsState) float64 {
+	if approximating,ok := renderer.(approximatingRenderer); ok {
+		return approximating.arcTolerance()
+	}
+
+	return defaultArcTolerance(gfxState.units)
+}
+
+// The path of a linear stroke, as the points the aperture is swept through
+func linearSweepPath(startX float64, startY float64, endX float64, endY float64) []polygon.Point {
+	return []polygon.Point{{X: startX, Y: startY}, {X: endX, Y: endY}}
+}
+
+// The path of an arc stroke, broken up into straight steps.  The aperture is swept exactly along each step, so the only
+// error is how far the steps cut across the arc, and stepping about as far as the aperture is wide keeps that small next
+// to the aperture without piling up shapes to merge.  Apertures smaller than that don't step any finer than the tolerance
+// of the renderer needs, and apertures that are big next to the arc still only turn a little at a time
+func arcSweepPath(renderer Renderer, aperture Aperture, gfxState *GraphicsState, centerX float64, centerY float64, radius float64, startAngle float64, endAngle float64, clockwise bool) []polygon.Point {
+	if clockwise {
+		for endAngle > startAngle {
+			endAngle -= TWO_PI
+		}
+	} else {
+		for endAngle < startAngle {
+			endAngle += TWO_PI
+		}
+	}
+
+	sweep := endAngle - startAngle
+	steps := arcSteps(radius, sweep, sweepTolerance(renderer, gfxState))
+	if minSize := aperture.GetMinSize(gfxState); minSize > 0.0 {
+		sizeSteps := int(math.Ceil(math.Abs(sweep * radius) / minSize))
+		turnSteps := int(math.Ceil(math.Abs(sweep) / MAX_SWEEP_STEP_ANGLE))
+		steps = max(1, min(steps, max(sizeSteps, turnSteps)))
+	}
+	path := make([]polygon.Point, 0, steps + 1)
+	for step := 0; step <= steps; step++ {
+		angle := startAngle + (sweep * float64(step) / float64(steps))
+		path = append(path, polygon.Point{X: centerX + (radius * math.Cos(angle)), Y: centerY + (radius * math.Sin(angle))})
+	}
+
+	return path
+}
+
+// Sweeps a convex aperture along the path.  The outline of the aperture is given as the corners of a convex polygon,
+// rounded off by the radius (so an obround is two corners and the radius of its ends).  A convex shape moved along a straight
+// line sweeps out the convex hull of the shape at either end, so each step of the path is filled as that hull
+func sweepConvexAperture(renderer Renderer, aperture Aperture, gfxState *GraphicsState, path []polygon.Point, corners []polygon.Point, radius float64) error {
+	if aperture.GetHole() != nil && pathLength(path) < aperture.GetMinSize(gfxState) {
+		// Just like with circles, a short stroke doesn't cover the hole up, so we fall back to stepping the aperture along the path
+		return flashAlongPath(renderer, aperture, gfxState, path)
+	}
+
+	for i := 1; i < len(path); i++ {
+		from := path[i - 1]
+		to := path[i]
+		points := make([]polygon.Point, 0, 2 * len(corners))
+		for _,corner := range corners {
+			points = append(points, polygon.Point{X: from.X + corner.X, Y: from.Y + corner.Y}, polygon.Point{X: to.X + corner.X, Y: to.Y + corner.Y})
+		}
+		fillRoundedHull(renderer, polygon.ConvexHull(points), radius)
+	}
+
+	return nil
+}
+
+func flashAlongPath(renderer Renderer, aperture Aperture, gfxState *GraphicsState, path []polygon.Point) error {
+	length := pathLength(path)
+	drawStep := length / float64(SLOW_DRAWING_STEPS)
+
+	segment := 0
+	travelled := 0.0
+	for step := 0; step <= SLOW_DRAWING_STEPS; step++ {
+		distance := drawStep * float64(step)
+		// Move on to the segment of the path that the next flash lands on
+		for segment < len(path) - 2 && travelled + pointDistance(path[segment], path[segment + 1]) < distance {
+			travelled += pointDistance(path[segment], path[segment + 1])
+			segment++
+		}
+
+		x,y := path[segment].X,path[segment].Y
+		if segment + 1 < len(path) {
+			if segmentLength := pointDistance(path[segment], path[segment + 1]); segmentLength > 0.0 {
+				fraction := math.Min(1.0, (distance - travelled) / segmentLength)
+				x += (path[segment + 1].X - x) * fraction
+				y += (path[segment + 1].Y - y) * fraction
+			}
+		}
+
+		if err := renderer.Flash(aperture, gfxState, x, y); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func pathLength(path []polygon.Point) float64 {
+	length := 0.0
+	for i := 1; i < len(path); i++ {
+		length += pointDistance(path[i - 1], path[i])
+	}
+
+	return length
+}
+
+func pointDistance(a polygon.Point, b polygon.Point) float64 {
+	return math.Hypot(b.X - a.X, b.Y - a.Y)
+}
+
+// Fills the convex hull grown by the radius.  Each edge moves straight out by the radius, and each corner becomes an arc
+// joining the edges either side of it
+func fillRoundedHull(renderer Renderer, hull []polygon.Point, radius float64) {
+	if radius <= 0.0 {
+		// Without any rounding, a hull with fewer than three corners doesn't have any area
+		if len(hull) < 3 {
+			return
+		}
+
+		renderer.MoveTo(hull[0].X, hull[0].Y)
+		for _,corner := range hull[1:] {
+			renderer.LineTo(corner.X, corner.Y)
+		}
+		renderer.ClosePath()
+		renderer.Fill()
+		return
+	}
+
+	switch len(hull) {
+		case 0:
+			return
+
+		case 1:
+			renderer.MoveTo(hull[0].X + radius, hull[0].Y)
+			renderer.Arc(hull[0].X, hull[0].Y, radius, 0.0, TWO_PI)
+
+		default:
+			for i,corner := range hull {
+				before := hull[(i + len(hull) - 1) % len(hull)]
+				after := hull[(i + 1) % len(hull)]
+				// The edges point out of the hull on their right, since the hull is counter-clockwise
+				startAngle := math.Atan2(before.X - corner.X, corner.Y - before.Y)
+				endAngle := math.Atan2(corner.X - after.X, after.Y - corner.Y)
+				// The corners of a convex hull turn left through at most half a turn.  Two corners turn through exactly half a turn,
+				// which could come out as either direction
+				turn := math.Abs(math.Remainder(endAngle - startAngle, TWO_PI))
+
+				if i == 0 {
+					renderer.MoveTo(corner.X + (radius * math.Cos(startAngle)), corner.Y + (radius * math.Sin(startAngle)))
+				}
+				renderer.Arc(corner.X, corner.Y, radius, startAngle, startAngle + turn)
+			}
+	}
+	renderer.ClosePath()
+	renderer.Fill()
+}
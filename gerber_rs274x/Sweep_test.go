@@ -0,0 +1,85 @@
+package gerber_rs274x
+
+import (
+	"math"
+	"os"
+	"strings"
+	"testing"
+	"gerber_rs274x/polygon"
+)
+
+// A ring 3mm across with a 1.5mm hole, made with a primitive with exposure off
+const RING_MACRO string = "%FSLAX23Y23*%%MOMM*%%AMRING*1,1,3,0,0*1,0,1.5,0,0*%%ADD10RING*%D10*"
+
+func TestMacroSweep(t *testing.T) {
+	// The outline only strays from the true outline by the default tolerance, so the area is out by no more than
+	// the length of the outline times that
+	stadium := (3.0 * 5.0) + (math.Pi * 1.5 * 1.5)
+	tolerance := ((2.0 * 5.0) + (2.0 * math.Pi * 1.5)) * DEFAULT_GEOMETRY_TOLERANCE_INCHES * MM_PER_INCH
+	semicircle := (math.Pi * 5.0 * 3.0) + (math.Pi * 1.5 * 1.5)
+
+	testCases := []struct {
+		name string
+		draws string
+		numPolygons, numHoles int
+		area float64
+		// The area isn't checked if this is zero
+		areaTolerance float64
+	}{
+		// Drawn further than the hole is wide, the ring covers its own hole up, leaving a rectangle with rounded ends
+		{"Straight", "X0Y0D02*X5000Y0D01*", 1, 0, stadium, tolerance},
+		{"Straight twice", "X0Y0D02*X5000Y0D01*X0Y10000D02*X5000Y10000D01*", 2, 0, 2.0 * stadium, 2.0 * tolerance},
+		// A short draw leaves some of the hole uncovered
+		{"Short", "X0Y0D02*X500Y0D01*", 1, 1, 0.0, 0.0},
+		// Half way around a circle, which is stepped around a bit at a time, so it only comes within 1% of the true area
+		{"Arc", "G75*X5000Y0D02*G03X-5000Y0I-5000J0D01*", 1, 0, semicircle, 0.01 * semicircle},
+	}
+
+	for _,testCase := range testCases {
+		geometry := extractGeometry(t, testCase.name, RING_MACRO + testCase.draws + "M02*")
+
+		holes := 0
+		for _,shape := range geometry.Polygons {
+			holes += len(shape.Holes)
+		}
+
+		if (len(geometry.Polygons) != testCase.numPolygons) || (holes != testCase.numHoles) {
+			t.Errorf("%s: got %d polygons with %d holes, expected %d polygons with %d holes", testCase.name, len(geometry.Polygons), holes, testCase.numPolygons, testCase.numHoles)
+		} else if (testCase.areaTolerance > 0.0) && (math.Abs(polygon.Area(geometry.Polygons) - testCase.area) > testCase.areaTolerance) {
+			t.Errorf("%s: area is %v, expected %v", testCase.name, polygon.Area(geometry.Polygons), testCase.area)
+		}
+	}
+}
+
+func BenchmarkMacroArcSweep(b *testing.B) {
+	dataBlocks,err := ParseGerberFile(strings.NewReader(RING_MACRO + "G75*X5000Y0D02*G03X-5000Y0I-5000J0D01*M02*"))
+	if err != nil {
+		b.Fatalf("Error parsing: %v", err)
+	}
+
+	for i := 0; i < b.N; i++ {
+		if _,err := ExtractGeometry(dataBlocks, GeometryOptions{}); err != nil {
+			b.Fatalf("Error extracting the geometry: %v", err)
+		}
+	}
+}
+
+// Draws and arcs with obround, polygon and macro apertures
+func BenchmarkSweepFixture(b *testing.B) {
+	inputFile,err := os.Open("../testing/gerber-ex21.gbr")
+	if err != nil {
+		b.Fatalf("Error opening gerber-ex21.gbr: %v", err)
+	}
+	defer inputFile.Close()
+
+	dataBlocks,err := ParseGerberFile(inputFile)
+	if err != nil {
+		b.Fatalf("Error parsing gerber-ex21.gbr: %v", err)
+	}
+
+	for i := 0; i < b.N; i++ {
+		if _,err := ExtractGeometry(dataBlocks, GeometryOptions{}); err != nil {
+			b.Fatalf("Error extracting the geometry: %v", err)
+		}
+	}
+}
@@ -3,7 +3,6 @@ package gerber_rs274x
 import (
 	"fmt"
 	"math"
-	cairo "github.com/ungerik/go-cairo"
 )
 
 type ThermalPrimitive struct {
@@ -31,7 +30,7 @@ func (primitive *ThermalPrimitive) GetPrimitiveBounds(env *ExpressionEnvironment
 	return centerX - radius,centerX + radius,centerY - radius,centerY + radius
 }
 
-func (primitive *ThermalPrimitive) DrawPrimitiveToSurface(surface *cairo.Surface, env *ExpressionEnvironment) error {
+func (primitive *ThermalPrimitive) DrawPrimitive(renderer Renderer, env *ExpressionEnvironment) error {
 	// If there is a rotation angle defined, first check that the center is at the origin
 	// (rotations are only allowed if the center is at the origin)
 	centerX := primitive.centerX.EvaluateExpression(env)
@@ -43,10 +42,7 @@ func (primitive *ThermalPrimitive) DrawPrimitiveToSurface(surface *cairo.Surface
 	}
 	
 	// Now that we've checked the center, apply the rotation
-	surface.Save()
-	surface.Rotate(rotation)
-	
-	surface.SetSourceRGBA(0.0, 0.0, 0.0, 1.0)
+	renderer.PushTransform(0.0, 0.0, rotation)
 	
 	// Now, draw the thermal
 	outerRadius := (primitive.outerDiameter.EvaluateExpression(env) / 2.0)
@@ -67,27 +63,25 @@ func (primitive *ThermalPrimitive) DrawPrimitiveToSurface(surface *cairo.Surface
 	innerEndAngle := math.Atan2(innerEndY, innerEndX)
 	
 	// Since the thermal is composed of 4 copies of the same shape, just rotated by 90 degrees,
-	// we draw the same shape 4 times, rotating the surface by 90 degrees each time
+	// we draw the same shape 4 times, rotating it by 90 degrees each time
 	
 	for i := 0; i < 4; i++ {
-		surface.Save()
-	
-		// Rotate the surface
-		surface.Rotate(ONE_HALF_PI * float64(i))
+		// Rotate this piece into place
+		renderer.PushTransform(0.0, 0.0, ONE_HALF_PI * float64(i))
 	
 		//Draw one piece of the primitive
-		surface.MoveTo(outerStartX, outerStartY)
-		surface.ArcNegative(centerX, centerY, outerRadius, outerStartAngle, outerEndAngle)
-		surface.LineTo(innerStartX, innerStartY)
-		surface.Arc(centerX, centerY, innerRadius, innerStartAngle, innerEndAngle)
-		surface.LineTo(outerStartX, outerStartY)
-		surface.Fill()
+		renderer.MoveTo(outerStartX, outerStartY)
+		renderer.ArcNegative(centerX, centerY, outerRadius, outerStartAngle, outerEndAngle)
+		renderer.LineTo(innerStartX, innerStartY)
+		renderer.Arc(centerX, centerY, innerRadius, innerStartAngle, innerEndAngle)
+		renderer.LineTo(outerStartX, outerStartY)
+		renderer.Fill()
 		
-		surface.Restore()
+		renderer.PopTransform()
 	}
 	
-	// Undo all surface transformations
-	surface.Restore()
+	// Undo all transformations
+	renderer.PopTransform()
 	
 	return nil
 }
@@ -100,4 +94,28 @@ func (primitive *ThermalPrimitive) String() string {
 						primitive.innerDiameter,
 						primitive.gapThickness,
 						primitive.rotationAngle)
-}
\ No newline at end of file
+}
+
+func (primitive *ThermalPrimitive) GetCenterX() ApertureMacroExpression {
+	return primitive.centerX
+}
+
+func (primitive *ThermalPrimitive) GetCenterY() ApertureMacroExpression {
+	return primitive.centerY
+}
+
+func (primitive *ThermalPrimitive) GetOuterDiameter() ApertureMacroExpression {
+	return primitive.outerDiameter
+}
+
+func (primitive *ThermalPrimitive) GetInnerDiameter() ApertureMacroExpression {
+	return primitive.innerDiameter
+}
+
+func (primitive *ThermalPrimitive) GetGapThickness() ApertureMacroExpression {
+	return primitive.gapThickness
+}
+
+func (primitive *ThermalPrimitive) GetRotationAngle() ApertureMacroExpression {
+	return primitive.rotationAngle
+}
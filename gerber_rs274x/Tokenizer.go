@@ -0,0 +1,163 @@
+package gerber_rs274x
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+type TokenType int
+
+const (
+	PARAMETER_TOKEN TokenType = iota
+	DATA_BLOCK_TOKEN
+)
+
+type gerberToken struct {
+	tokenType TokenType
+	contents string
+	// Position of the first character of the token in the file (both start at 1)
+	line int
+	column int
+}
+
+// The tokenizer reads a gerber file incrementally, and splits it into parameter blocks (everything in between a pair of
+// "%" characters) and data blocks (everything up to a "*" character).  Only the token currently being read is held in
+// memory, so files of any size can be tokenized
+type gerberTokenizer struct {
+	reader *bufio.Reader
+	accumulator strings.Builder
+	// Position of the next character to be read from the file
+	line int
+	column int
+	// Position of the first character of the token currently being read
+	tokenLine int
+	tokenColumn int
+	// Set when a parameter block starts in the middle of a data block.  The broken data block is reported as
+	// an error, and the parameter block is read on the next call, so that the rest of the file can still be tokenized
+	parameterPending bool
+}
+
+func newGerberTokenizer(in io.Reader) *gerberTokenizer {
+	tokenizer := new(gerberTokenizer)
+	tokenizer.reader = bufio.NewReader(in)
+	tokenizer.line = 1
+	tokenizer.column = 1
+
+	return tokenizer
+}
+
+func (tokenizer *gerberTokenizer) readByte() (byte, error) {
+	char,err := tokenizer.reader.ReadByte()
+	if err != nil {
+		return char,err
+	}
+
+	if char == '\n' {
+		tokenizer.line++
+		tokenizer.column = 1
+	} else {
+		tokenizer.column++
+	}
+
+	return char,nil
+}
+
+func (tokenizer *gerberTokenizer) startToken() {
+	// Called right after the first character of a token has been read
+	tokenizer.tokenLine = tokenizer.line
+	tokenizer.tokenColumn = tokenizer.column - 1
+}
+
+func (tokenizer *gerberTokenizer) newToken(tokenType TokenType, contents string) *gerberToken {
+	return &gerberToken{tokenType, contents, tokenizer.tokenLine, tokenizer.tokenColumn}
+}
+
+func (tokenizer *gerberTokenizer) syntaxError(format string, args ...interface{}) *ParseError {
+	return &ParseError{
+		Line: tokenizer.tokenLine,
+		Column: tokenizer.tokenColumn,
+		Block: tokenizer.accumulator.String(),
+		Category: SYNTAX_ERROR,
+		Err: fmt.Errorf(format, args...),
+	}
+}
+
+// Returns the next token in the file, or io.EOF once the whole file has been consumed.
+// Malformed tokens are returned as a *ParseError with the SYNTAX_ERROR category
+func (tokenizer *gerberTokenizer) nextToken() (*gerberToken, error) {
+	tokenizer.accumulator.Reset()
+
+	if tokenizer.parameterPending {
+		tokenizer.parameterPending = false
+		return tokenizer.readParameter()
+	}
+
+	for {
+		char,err := tokenizer.readByte()
+		if err == io.EOF {
+			// It's fine to run out of input in between tokens, but if we were in the middle of
+			// a data block, the file has been truncated
+			if len(strings.TrimSpace(tokenizer.accumulator.String())) > 0 {
+				return nil,tokenizer.syntaxError("Unterminated data block at end of file")
+			}
+			return nil,io.EOF
+		} else if err != nil {
+			return nil,err
+		}
+
+		switch char {
+			case '\r', '\n':
+				// Line breaks carry no meaning in a gerber file, so they are dropped wherever they occur
+
+			case '%':
+				// Parameter blocks can't start in the middle of a data block
+				if tokenizer.accumulator.Len() > 0 {
+					syntaxErr := tokenizer.syntaxError("Parameter block started in the middle of a data block")
+					tokenizer.parameterPending = true
+					tokenizer.startToken()
+					return nil,syntaxErr
+				}
+				tokenizer.startToken()
+				return tokenizer.readParameter()
+
+			case '*':
+				if tokenizer.accumulator.Len() == 0 {
+					// Empty data blocks don't do anything, so we just skip over them
+					continue
+				}
+				return tokenizer.newToken(DATA_BLOCK_TOKEN, tokenizer.accumulator.String()),nil
+
+			default:
+				if tokenizer.accumulator.Len() == 0 {
+					tokenizer.startToken()
+				}
+				tokenizer.accumulator.WriteByte(char)
+		}
+	}
+}
+
+func (tokenizer *gerberTokenizer) readParameter() (*gerberToken, error) {
+	for {
+		char,err := tokenizer.readByte()
+		if err == io.EOF {
+			return nil,tokenizer.syntaxError("Unterminated parameter block at end of file")
+		} else if err != nil {
+			return nil,err
+		}
+
+		switch char {
+			case '\r', '\n':
+				// Line breaks carry no meaning in a gerber file, so they are dropped wherever they occur
+
+			case '%':
+				// Parameters can have multiple embedded data blocks, each ended by a "*" character.  We keep
+				// the embedded "*" characters, because the parameter parsers need them, but strip off the final one
+				return tokenizer.newToken(PARAMETER_TOKEN, strings.TrimSuffix(tokenizer.accumulator.String(), "*")),nil
+
+			default:
+				tokenizer.accumulator.WriteByte(char)
+		}
+	}
+}
@@ -0,0 +1,154 @@
+package gerber_rs274x
+
+// Converts each data block into the units asked for in the parse options as it's parsed, keeping track of the units
+// the file itself is in (which can change part way through with the deprecated G70 and G71 codes)
+type unitConverter struct {
+	units Units
+	fileUnits Units
+}
+
+func newUnitConverter(units Units) *unitConverter {
+	converter := new(unitConverter)
+	converter.units = units
+	// Inches until an MO parameter says otherwise, the same as the graphics state
+	converter.fileUnits = UNITS_IN
+
+	return converter
+}
+
+// The factor that converts lengths in the from units into the to units
+func unitConversionFactor(from Units, to Units) float64 {
+	return scaleForUnits(1.0, from) / scaleForUnits(1.0, to)
+}
+
+func (converter *unitConverter) convert(dataBlock DataBlock) {
+	switch dataBlockValue := dataBlock.(type) {
+		case *ModeParameter:
+			converter.fileUnits = dataBlockValue.units
+			dataBlockValue.units = converter.units
+
+		case *GraphicsStateChange:
+			switch dataBlockValue.fnCode {
+				case SET_UNIT_INCH, SET_UNIT_MM:
+					if dataBlockValue.fnCode == SET_UNIT_INCH {
+						converter.fileUnits = UNITS_IN
+					} else {
+						converter.fileUnits = UNITS_MM
+					}
+
+					if converter.units == UNITS_IN {
+						dataBlockValue.fnCode = SET_UNIT_INCH
+					} else {
+						dataBlockValue.fnCode = SET_UNIT_MM
+					}
+			}
+
+		default:
+			if converter.fileUnits != converter.units {
+				scaleDataBlock(dataBlock, unitConversionFactor(converter.fileUnits, converter.units))
+			}
+	}
+}
+
+// Multiplies every length in the data block by the scale
+func scaleDataBlock(dataBlock DataBlock, scale float64) {
+	switch dataBlockValue := dataBlock.(type) {
+		case *Interpolation:
+			dataBlockValue.x *= scale
+			dataBlockValue.y *= scale
+			dataBlockValue.i *= scale
+			dataBlockValue.j *= scale
+
+		case *ApertureDefinitionParameter:
+			scaleAperture(dataBlockValue.aperture, scale)
+
+		case *ApertureMacroParameter:
+			for _,macroDataBlock := range dataBlockValue.dataBlocks {
+				if primitive,ok := macroDataBlock.(AperturePrimitive); ok {
+					scalePrimitive(primitive, scale)
+				}
+			}
+
+		case *StepAndRepeatParameter:
+			// The blocks inside have already been converted as they were parsed
+			dataBlockValue.xStepDistance *= scale
+			dataBlockValue.yStepDistance *= scale
+	}
+}
+
+func scaleAperture(aperture Aperture, scale float64) {
+	switch apertureValue := aperture.(type) {
+		case *CircleAperture:
+			apertureValue.diameter *= scale
+
+		case *RectangleAperture:
+			apertureValue.xSize *= scale
+			apertureValue.ySize *= scale
+
+		case *ObroundAperture:
+			apertureValue.xSize *= scale
+			apertureValue.ySize *= scale
+
+		case *PolygonAperture:
+			apertureValue.outerDiameter *= scale
+
+		case *MacroAperture:
+			// The modifiers are left alone, because there's no telling whether each one is a length, an angle or a count
+			// until the macro uses it.  The lengths in the macro itself are scaled instead, which takes care of any modifiers
+			// that end up being used as lengths
+	}
+
+	switch holeValue := aperture.GetHole().(type) {
+		case *CircularHole:
+			holeValue.holeDiameter *= scale
+
+		case *RectangularHole:
+			holeValue.holeXSize *= scale
+			holeValue.holeYSize *= scale
+	}
+}
+
+// Scales the modifiers of the primitive that are lengths, leaving exposures, counts and rotations alone
+func scalePrimitive(primitive AperturePrimitive, scale float64) {
+	switch primitiveValue := primitive.(type) {
+		case *CirclePrimitive:
+			scaleExpressions(scale, &primitiveValue.diameter, &primitiveValue.centerX, &primitiveValue.centerY)
+
+		case *VectorLinePrimitive:
+			scaleExpressions(scale, &primitiveValue.lineWidth, &primitiveValue.startX, &primitiveValue.startY, &primitiveValue.endX, &primitiveValue.endY)
+
+		case *CenterLinePrimitive:
+			scaleExpressions(scale, &primitiveValue.width, &primitiveValue.height, &primitiveValue.centerX, &primitiveValue.centerY)
+
+		case *LowerLeftLinePrimitive:
+			scaleExpressions(scale, &primitiveValue.width, &primitiveValue.height, &primitiveValue.lowerLeftX, &primitiveValue.lowerLeftY)
+
+		case *OutlinePrimitive:
+			scaleExpressions(scale, &primitiveValue.startX, &primitiveValue.startY)
+			for i := range primitiveValue.subsequentX {
+				scaleExpressions(scale, &primitiveValue.subsequentX[i], &primitiveValue.subsequentY[i])
+			}
+
+		case *PolygonPrimitive:
+			scaleExpressions(scale, &primitiveValue.centerX, &primitiveValue.centerY, &primitiveValue.diameter)
+
+		case *MoirePrimitive:
+			scaleExpressions(scale, &primitiveValue.centerX, &primitiveValue.centerY, &primitiveValue.outerDiameter, &primitiveValue.ringThickness,
+							&primitiveValue.ringGap, &primitiveValue.crosshairThickness, &primitiveValue.crosshairLength)
+
+		case *ThermalPrimitive:
+			scaleExpressions(scale, &primitiveValue.centerX, &primitiveValue.centerY, &primitiveValue.outerDiameter, &primitiveValue.innerDiameter,
+							&primitiveValue.gapThickness)
+	}
+}
+
+// Replaces each of the expressions with one that multiplies it by the scale.  Literals are just multiplied straight away
+func scaleExpressions(scale float64, expressions ...*ApertureMacroExpression) {
+	for _,expression := range expressions {
+		if literal,ok := (*expression).(*LiteralExpression); ok {
+			*expression = &LiteralExpression{literal.value * scale}
+		} else {
+			*expression = &ArithmeticExpression{OPERATOR_MULTIPLY, *expression, &LiteralExpression{scale}}
+		}
+	}
+}
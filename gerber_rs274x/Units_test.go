@@ -0,0 +1,127 @@
+package gerber_rs274x
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+func checkValue(t *testing.T, name string, got float64, expected float64) {
+	t.Helper()
+
+	if math.Abs(got - expected) > 1e-9 {
+		t.Errorf("%s is %v, expected %v", name, got, expected)
+	}
+}
+
+// Every length in a millimeter file comes out in inches, but the counts and angles in its macros stay the same
+func TestNormalizeUnits(t *testing.T) {
+	contents := "%FSLAX24Y24*%%MOMM*%" +
+		"%AMTEST*4,1,3,0,0,25.4,0,0,50.8,0,0,45*5,1,6,12.7,0,$1,30*%" +
+		"%ADD10C,2.54X1.27*%%ADD11R,25.4X12.7X2.54X5.08*%%ADD12TEST,25.4*%" +
+		"%SRX2Y3I25.4J50.8*%D10*X254000Y-127000D03*%SR*%" +
+		"D12*X0Y0D02*X254000Y0D01*G75*G03X0Y254000I-254000J0D01*M02*"
+
+	parseResult,err := ParseGerberFileWithOptions(strings.NewReader(contents), ParseOptions{Strict: true, NormalizeUnits: true, Units: UNITS_IN})
+	if err != nil {
+		t.Fatalf("Error parsing: %v", err)
+	}
+
+	// The flash inside the step and repeat is in its data blocks, rather than at the top level
+	dataBlocks := parseResult.DataBlocks
+	for _,dataBlock := range parseResult.DataBlocks {
+		if stepAndRepeat,ok := dataBlock.(*StepAndRepeatParameter); ok {
+			dataBlocks = append(dataBlocks, stepAndRepeat.dataBlocks...)
+		}
+	}
+
+	env := NewExpressionEnvironment()
+	env.setVariableValue(1, 25.4)
+
+	var interpolations []*Interpolation
+	for _,dataBlock := range dataBlocks {
+		switch dataBlockValue := dataBlock.(type) {
+			case *ModeParameter:
+				if dataBlockValue.units != UNITS_IN {
+					t.Errorf("Mode parameter has units %v, expected inches", dataBlockValue.units)
+				}
+
+			case *StepAndRepeatParameter:
+				if (dataBlockValue.xRepeats != 2) || (dataBlockValue.yRepeats != 3) {
+					t.Errorf("Step and repeat has %d by %d repeats, expected 2 by 3", dataBlockValue.xRepeats, dataBlockValue.yRepeats)
+				}
+				checkValue(t, "Step and repeat X distance", dataBlockValue.xStepDistance, 1.0)
+				checkValue(t, "Step and repeat Y distance", dataBlockValue.yStepDistance, 2.0)
+
+			case *ApertureDefinitionParameter:
+				switch aperture := dataBlockValue.aperture.(type) {
+					case *CircleAperture:
+						checkValue(t, "Circle diameter", aperture.diameter, 0.1)
+						checkValue(t, "Circle hole diameter", aperture.GetHole().(*CircularHole).holeDiameter, 0.05)
+
+					case *RectangleAperture:
+						checkValue(t, "Rectangle X size", aperture.xSize, 1.0)
+						checkValue(t, "Rectangle Y size", aperture.ySize, 0.5)
+						checkValue(t, "Rectangle hole X size", aperture.GetHole().(*RectangularHole).holeXSize, 0.1)
+						checkValue(t, "Rectangle hole Y size", aperture.GetHole().(*RectangularHole).holeYSize, 0.2)
+
+					case *MacroAperture:
+						// The modifiers can't be scaled, since there's no telling what they are until the macro uses them
+						if (len(aperture.modifiers) != 1) || (aperture.modifiers[0] != 25.4) {
+							t.Errorf("Macro aperture modifiers are %v, expected [25.4]", aperture.modifiers)
+						}
+				}
+
+			case *ApertureMacroParameter:
+				for _,macroDataBlock := range dataBlockValue.dataBlocks {
+					switch primitive := macroDataBlock.(type) {
+						case *OutlinePrimitive:
+							checkValue(t, "Outline vertex count", primitive.nPoints.EvaluateExpression(env), 3.0)
+							checkValue(t, "Outline rotation", primitive.rotationAngle.EvaluateExpression(env), 45.0)
+							checkValue(t, "Outline start X", primitive.startX.EvaluateExpression(env), 0.0)
+							if len(primitive.subsequentX) != 3 {
+								t.Errorf("Outline has %d subsequent vertices, expected 3", len(primitive.subsequentX))
+							} else {
+								checkValue(t, "Outline second vertex X", primitive.subsequentX[0].EvaluateExpression(env), 1.0)
+								checkValue(t, "Outline third vertex Y", primitive.subsequentY[1].EvaluateExpression(env), 2.0)
+							}
+
+						case *PolygonPrimitive:
+							checkValue(t, "Polygon vertex count", primitive.nVertices.EvaluateExpression(env), 6.0)
+							checkValue(t, "Polygon rotation", primitive.rotationAngle.EvaluateExpression(env), 30.0)
+							checkValue(t, "Polygon center X", primitive.centerX.EvaluateExpression(env), 0.5)
+							// The diameter is the modifier, which is in millimeters until the macro scales it
+							checkValue(t, "Polygon diameter", primitive.diameter.EvaluateExpression(env), 1.0)
+					}
+				}
+
+			case *Interpolation:
+				interpolations = append(interpolations, dataBlockValue)
+		}
+	}
+
+	// The move, the line and the arc, and then the flash from inside the step and repeat
+	expected := []struct {
+		x, y, i, j float64
+	}{
+		{0.0, 0.0, 0.0, 0.0},
+		{1.0, 0.0, 0.0, 0.0},
+		{0.0, 1.0, -1.0, 0.0},
+		{1.0, -0.5, 0.0, 0.0},
+	}
+
+	if len(interpolations) != len(expected) {
+		t.Fatalf("Got %d interpolations, expected %d", len(interpolations), len(expected))
+	}
+
+	for index,interpolation := range interpolations {
+		got := []float64{interpolation.x, interpolation.y, interpolation.i, interpolation.j}
+		want := []float64{expected[index].x, expected[index].y, expected[index].i, expected[index].j}
+		for k := range got {
+			if math.Abs(got[k] - want[k]) > 1e-9 {
+				t.Errorf("Interpolation %d is (x, y, i, j) %v, expected %v", index, got, want)
+				break
+			}
+		}
+	}
+}
@@ -14,4 +14,8 @@ func (expr *VariableExpression) EvaluateExpression(env *ExpressionEnvironment) f
 
 func (expr *VariableExpression) String() string {
 	return fmt.Sprintf("{VariableExpr, Variable Number: %d}", expr.variableNumber)
-}
\ No newline at end of file
+}
+
+func (expr *VariableExpression) GetVariableNumber() int {
+	return expr.variableNumber
+}
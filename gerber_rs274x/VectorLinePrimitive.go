@@ -2,8 +2,7 @@ package gerber_rs274x
 
 import (
 	"fmt"
-	_ "math"
-	"github.com/ungerik/go-cairo"
+	"math"
 )
 
 type VectorLinePrimitive struct {
@@ -25,30 +24,39 @@ func (primitive *VectorLinePrimitive) ApertureMacroDataBlockPlaceholder() {
 }
 
 func (primitive *VectorLinePrimitive) GetPrimitiveBounds(env *ExpressionEnvironment) (xMin float64, xMax float64, yMin float64, yMax float64) {
-	//TODO: Implement
-	
-	/*
-	surface := cairo.NewSurface(cairo.FORMAT_ARGB32, 100, 100)
-	
-	rotation := primitive.rotationAngle.EvaluateExpression(env)
-	rotationRadians := rotation * (math.Pi / 180.0)
-	surface.Rotate(rotationRadians)
-	
-	yRadius := primitive.lineWidth.EvaluateExpression(env) / 2.0
+	xs,ys,rotation := primitive.outline(env)
+	return primitiveOutlineBounds(xs, ys, rotation)
+}
+
+func (primitive *VectorLinePrimitive) DrawPrimitive(renderer Renderer, env *ExpressionEnvironment) error {
+	xs,ys,rotation := primitive.outline(env)
+	fillPrimitiveOutline(renderer, xs, ys, rotation)
+	return nil
+}
+
+// The corners of the line (a rectangle with square ends at the start and end points), before it is rotated.
+// A line with no length has no area, so it has no corners either
+func (primitive *VectorLinePrimitive) outline(env *ExpressionEnvironment) ([]float64, []float64, float64) {
+	halfWidth := primitive.lineWidth.EvaluateExpression(env) / 2.0
 	startX := primitive.startX.EvaluateExpression(env)
 	startY := primitive.startY.EvaluateExpression(env)
 	endX := primitive.endX.EvaluateExpression(env)
 	endY := primitive.endY.EvaluateExpression(env)
-	lineAngle1 := math.Atan2(startY, startX)
-	lineAngle2 := math.Atan2(endY, endX)
-	*/
-	
-	return 0.0,0.0,0.0,0.0
-}
+	rotation := primitive.rotationAngle.EvaluateExpression(env)
 
-func (primitive *VectorLinePrimitive) DrawPrimitiveToSurface(surface *cairo.Surface, env *ExpressionEnvironment) error {
-	//TODO: Implement
-	return nil
+	length := math.Hypot(endX - startX, endY - startY)
+	if length == 0.0 {
+		return nil,nil,rotation
+	}
+
+	// Offset from the center line to the sides of the line
+	normalX := -(endY - startY) * halfWidth / length
+	normalY := (endX - startX) * halfWidth / length
+
+	xs := []float64{startX - normalX, endX - normalX, endX + normalX, startX + normalX}
+	ys := []float64{startY - normalY, endY - normalY, endY + normalY, startY + normalY}
+
+	return xs,ys,rotation
 }
 
 func (primitive *VectorLinePrimitive) String() string {
@@ -60,4 +68,32 @@ func (primitive *VectorLinePrimitive) String() string {
 						primitive.endX,
 						primitive.endY,
 						primitive.rotationAngle)
-}
\ No newline at end of file
+}
+
+func (primitive *VectorLinePrimitive) GetExposure() ApertureMacroExpression {
+	return primitive.exposure
+}
+
+func (primitive *VectorLinePrimitive) GetLineWidth() ApertureMacroExpression {
+	return primitive.lineWidth
+}
+
+func (primitive *VectorLinePrimitive) GetStartX() ApertureMacroExpression {
+	return primitive.startX
+}
+
+func (primitive *VectorLinePrimitive) GetStartY() ApertureMacroExpression {
+	return primitive.startY
+}
+
+func (primitive *VectorLinePrimitive) GetEndX() ApertureMacroExpression {
+	return primitive.endX
+}
+
+func (primitive *VectorLinePrimitive) GetEndY() ApertureMacroExpression {
+	return primitive.endY
+}
+
+func (primitive *VectorLinePrimitive) GetRotationAngle() ApertureMacroExpression {
+	return primitive.rotationAngle
+}
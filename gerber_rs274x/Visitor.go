@@ -0,0 +1,95 @@
+package gerber_rs274x
+
+// A Visitor has one method for every type of data block, so that new passes over a parsed file
+// (analyses, writers, renderers, etc.) can be written without adding a method to every data block type.
+// Step and repeat blocks aren't descended into automatically.  VisitStepAndRepeat gets the whole block, and
+// should call Walk on its data blocks if the visitor needs to see them (possibly once for every copy)
+type Visitor interface {
+	VisitFormatSpecification(fsParam *FormatSpecificationParameter) error
+	VisitMode(moParam *ModeParameter) error
+	VisitApertureDefinition(adParam *ApertureDefinitionParameter) error
+	VisitApertureMacro(amParam *ApertureMacroParameter) error
+	VisitStepAndRepeat(srParam *StepAndRepeatParameter) error
+	VisitLevelPolarity(lpParam *LevelPolarityParameter) error
+	VisitFileAttribute(tfParam *FileAttributeParameter) error
+	VisitApertureAttribute(taParam *ApertureAttributeParameter) error
+	VisitObjectAttribute(toParam *ObjectAttributeParameter) error
+	VisitDeleteAttribute(tdParam *DeleteAttributeParameter) error
+	VisitComment(comment *IgnoreDataBlock) error
+	VisitSetCurrentAperture(setCurrentAperture *SetCurrentAperture) error
+	VisitGraphicsStateChange(graphicsStateChange *GraphicsStateChange) error
+	VisitInterpolation(interpolation *Interpolation) error
+}
+
+// Visits each of the data blocks in order, stopping at the first error returned by the visitor
+func Walk(blocks []DataBlock, visitor Visitor) error {
+	for _,dataBlock := range blocks {
+		if err := dataBlock.Accept(visitor); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// BaseVisitor does nothing for every type of data block.  Embed it in a visitor that only cares about
+// a few types of data block, and override just those methods.  Note that BaseVisitor's VisitStepAndRepeat
+// doesn't descend into the step and repeat block, because it can't call back into the embedding visitor
+type BaseVisitor struct {
+}
+
+func (visitor *BaseVisitor) VisitFormatSpecification(fsParam *FormatSpecificationParameter) error {
+	return nil
+}
+
+func (visitor *BaseVisitor) VisitMode(moParam *ModeParameter) error {
+	return nil
+}
+
+func (visitor *BaseVisitor) VisitApertureDefinition(adParam *ApertureDefinitionParameter) error {
+	return nil
+}
+
+func (visitor *BaseVisitor) VisitApertureMacro(amParam *ApertureMacroParameter) error {
+	return nil
+}
+
+func (visitor *BaseVisitor) VisitStepAndRepeat(srParam *StepAndRepeatParameter) error {
+	return nil
+}
+
+func (visitor *BaseVisitor) VisitLevelPolarity(lpParam *LevelPolarityParameter) error {
+	return nil
+}
+
+func (visitor *BaseVisitor) VisitFileAttribute(tfParam *FileAttributeParameter) error {
+	return nil
+}
+
+func (visitor *BaseVisitor) VisitApertureAttribute(taParam *ApertureAttributeParameter) error {
+	return nil
+}
+
+func (visitor *BaseVisitor) VisitObjectAttribute(toParam *ObjectAttributeParameter) error {
+	return nil
+}
+
+func (visitor *BaseVisitor) VisitDeleteAttribute(tdParam *DeleteAttributeParameter) error {
+	return nil
+}
+
+func (visitor *BaseVisitor) VisitComment(comment *IgnoreDataBlock) error {
+	return nil
+}
+
+func (visitor *BaseVisitor) VisitSetCurrentAperture(setCurrentAperture *SetCurrentAperture) error {
+	return nil
+}
+
+func (visitor *BaseVisitor) VisitGraphicsStateChange(graphicsStateChange *GraphicsStateChange) error {
+	return nil
+}
+
+func (visitor *BaseVisitor) VisitInterpolation(interpolation *Interpolation) error {
+	return nil
+}
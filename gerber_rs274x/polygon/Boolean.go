@@ -0,0 +1,657 @@
+package polygon
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// Snapping intersection points to the grid can occasionally create new intersections, so we keep splitting edges
+// until there aren't any left, up to this many times
+const MAX_SPLIT_PASSES int = 16
+
+// Merges the rings into non-overlapping polygons, with what is filled decided by the fill rule.
+// This also cleans up self-intersections, so it's the way to turn arbitrary rings into proper polygons.
+// Like all of the boolean operations, it returns an error if the edges can't all be split where they cross
+func Simplify(rings []Ring, rule FillRule) ([]Polygon, error) {
+	return clip([][]Ring{rings}, []FillRule{rule}, func(filled []bool) bool {
+		return filled[0]
+	})
+}
+
+// Everything that is filled in either a or b
+func Union(a []Polygon, b []Polygon) ([]Polygon, error) {
+	return clip([][]Ring{Rings(a), Rings(b)}, []FillRule{NON_ZERO, NON_ZERO}, func(filled []bool) bool {
+		return filled[0] || filled[1]
+	})
+}
+
+// Everything that is filled in a but not in b
+func Difference(a []Polygon, b []Polygon) ([]Polygon, error) {
+	return clip([][]Ring{Rings(a), Rings(b)}, []FillRule{NON_ZERO, NON_ZERO}, func(filled []bool) bool {
+		return filled[0] && !filled[1]
+	})
+}
+
+// Everything that is filled in both a and b
+func Intersection(a []Polygon, b []Polygon) ([]Polygon, error) {
+	return clip([][]Ring{Rings(a), Rings(b)}, []FillRule{NON_ZERO, NON_ZERO}, func(filled []bool) bool {
+		return filled[0] && filled[1]
+	})
+}
+
+// Everything that is filled in exactly one of a and b
+func Xor(a []Polygon, b []Polygon) ([]Polygon, error) {
+	return clip([][]Ring{Rings(a), Rings(b)}, []FillRule{NON_ZERO, NON_ZERO}, func(filled []bool) bool {
+		return filled[0] != filled[1]
+	})
+}
+
+// An edge between two grid points, with lo before hi (by x, then y)
+type segment struct {
+	lo gridPoint
+	hi gridPoint
+	// How many times each operand's rings go along this edge from lo to hi (negative for hi to lo)
+	winding []int
+}
+
+func (seg *segment) vertical() bool {
+	return seg.lo.x == seg.hi.x
+}
+
+func (seg *segment) bounds() (int64, int64, int64, int64) {
+	return seg.lo.x,seg.hi.x,min(seg.lo.y, seg.hi.y),max(seg.lo.y, seg.hi.y)
+}
+
+// Whether p (which has to be on the line through the segment) is strictly between its ends
+func (seg *segment) containsCollinear(p gridPoint) bool {
+	if (p == seg.lo) || (p == seg.hi) {
+		return false
+	}
+
+	xMin,xMax,yMin,yMax := seg.bounds()
+	return (p.x >= xMin) && (p.x <= xMax) && (p.y >= yMin) && (p.y <= yMax)
+}
+
+// Works out the polygons covering the area where inside returns true, given whether each of the operands is filled there.
+// Every edge of every operand is split wherever it meets another edge, and then each piece is kept if it separates an area
+// that's inside from one that isn't.  All of the decisions are made exactly on the grid, which is what keeps this robust
+// against collinear edges, touching vertices and edges that lie on top of each other
+func clip(operands [][]Ring, rules []FillRule, inside func(filled []bool) bool) ([]Polygon, error) {
+	segments := buildSegments(operands)
+	if len(segments) == 0 {
+		return nil,nil
+	}
+
+	converged := false
+	for pass := 0; pass < MAX_SPLIT_PASSES; pass++ {
+		var split bool
+		if segments,split = splitSegments(segments, len(operands)); !split {
+			converged = true
+			break
+		}
+	}
+
+	// If there are still edges crossing each other, the rings can't be traced properly, so there's no sensible result
+	if !converged {
+		return nil,fmt.Errorf("Edges were still crossing each other after %d passes of splitting them", MAX_SPLIT_PASSES)
+	}
+
+	segments = mergeSegments(segments, len(operands))
+	boundary := selectBoundary(segments, rules, inside)
+
+	return buildPolygons(traceRings(boundary)),nil
+}
+
+func buildSegments(operands [][]Ring) []*segment {
+	var segments []*segment
+	for operand,rings := range operands {
+		for _,ring := range rings {
+			points := make([]gridPoint, 0, len(ring))
+			for _,point := range ring {
+				if math.IsNaN(point.X) || math.IsNaN(point.Y) || math.IsInf(point.X, 0) || math.IsInf(point.Y, 0) {
+					continue
+				}
+				points = append(points, toGrid(point))
+			}
+
+			for i := range points {
+				if seg := newSegment(points[i], points[(i + 1) % len(points)], operand, 1, len(operands)); seg != nil {
+					segments = append(segments, seg)
+				}
+			}
+		}
+	}
+
+	return segments
+}
+
+// Makes a segment going from a to b, which counts for the given operand count times.  Returns nil if the segment would be empty
+func newSegment(a gridPoint, b gridPoint, operand int, count int, numOperands int) *segment {
+	if a == b {
+		return nil
+	}
+
+	seg := new(segment)
+	seg.winding = make([]int, numOperands)
+	if a.less(b) {
+		seg.lo,seg.hi = a,b
+		seg.winding[operand] = count
+	} else {
+		seg.lo,seg.hi = b,a
+		seg.winding[operand] = -count
+	}
+
+	return seg
+}
+
+// The segments are bucketed into vertical strips, so that we only need to look at the segments near a point.
+// The strips are narrower where there are more segments, so that a crowded part of the picture (like lots of copies of
+// an aperture stepped along a short draw) doesn't end up all in one strip
+type strips struct {
+	// Where each strip after the first one starts
+	starts []int64
+	buckets [][]int
+}
+
+func newStrips(segments []*segment) *strips {
+	st := new(strips)
+
+	ends := make([]int64, 0, 2 * len(segments))
+	for _,seg := range segments {
+		ends = append(ends, seg.lo.x, seg.hi.x)
+	}
+	sort.Slice(ends, func(i int, j int) bool {
+		return ends[i] < ends[j]
+	})
+
+	// Aim for a handful of segments in each strip
+	numStrips := (len(segments) / 8) + 1
+	for i := 1; i < numStrips; i++ {
+		start := ends[i * len(ends) / numStrips]
+		if (start > ends[0]) && ((len(st.starts) == 0) || (start > st.starts[len(st.starts) - 1])) {
+			st.starts = append(st.starts, start)
+		}
+	}
+	st.buckets = make([][]int, len(st.starts) + 1)
+
+	for i,seg := range segments {
+		for bucket := st.index(seg.lo.x); bucket <= st.index(seg.hi.x); bucket++ {
+			st.buckets[bucket] = append(st.buckets[bucket], i)
+		}
+	}
+
+	return st
+}
+
+// The strip that x falls in
+func (st *strips) index(x int64) int {
+	return sort.Search(len(st.starts), func(i int) bool {
+		return st.starts[i] > x
+	})
+}
+
+// Finds everywhere the segments meet, and splits them there.  Returns whether anything was split
+func splitSegments(segments []*segment, numOperands int) ([]*segment, bool) {
+	st := newStrips(segments)
+	splitPoints := make(map[int][]gridPoint)
+	firstStrips := make([]int, len(segments))
+	for i,seg := range segments {
+		firstStrips[i] = st.index(seg.lo.x)
+	}
+
+	addSplit := func(i int, p gridPoint) {
+		if (p != segments[i].lo) && (p != segments[i].hi) {
+			splitPoints[i] = append(splitPoints[i], p)
+		}
+	}
+
+	for bucket,members := range st.buckets {
+		// Within a strip, we only need to compare segments whose y ranges overlap
+		sorted := append([]int(nil), members...)
+		sort.Slice(sorted, func(a int, b int) bool {
+			_,_,yMinA,_ := segments[sorted[a]].bounds()
+			_,_,yMinB,_ := segments[sorted[b]].bounds()
+			return yMinA < yMinB
+		})
+
+		for a := 0; a < len(sorted); a++ {
+			i := sorted[a]
+			_,_,_,yMaxI := segments[i].bounds()
+			for b := a + 1; b < len(sorted); b++ {
+				j := sorted[b]
+				if _,_,yMinJ,_ := segments[j].bounds(); yMinJ > yMaxI {
+					break
+				}
+
+				// A pair of segments can share more than one strip, so each pair is only checked in the first strip they share
+				if bucket != max(firstStrips[i], firstStrips[j]) {
+					continue
+				}
+
+				findIntersection(segments[i], segments[j], func(p gridPoint) { addSplit(i, p) }, func(p gridPoint) { addSplit(j, p) })
+			}
+		}
+	}
+
+	if len(splitPoints) == 0 {
+		return segments,false
+	}
+
+	result := make([]*segment, 0, len(segments) + len(splitPoints))
+	for i,seg := range segments {
+		points,found := splitPoints[i]
+		if !found {
+			result = append(result, seg)
+			continue
+		}
+
+		// Order the split points along the segment, then replace the segment with the pieces between them
+		dx := float64(seg.hi.x - seg.lo.x)
+		dy := float64(seg.hi.y - seg.lo.y)
+		along := func(p gridPoint) float64 {
+			return (float64(p.x - seg.lo.x) * dx) + (float64(p.y - seg.lo.y) * dy)
+		}
+		sort.Slice(points, func(a int, b int) bool {
+			return along(points[a]) < along(points[b])
+		})
+
+		previous := seg.lo
+		for _,point := range append(points, seg.hi) {
+			if piece := newSegment(previous, point, 0, 0, numOperands); piece != nil {
+				// The pieces go the same way as the original segment (unless rounding has turned one around)
+				sign := 1
+				if piece.lo != previous {
+					sign = -1
+				}
+				for operand,count := range seg.winding {
+					piece.winding[operand] = sign * count
+				}
+				result = append(result, piece)
+			}
+			previous = point
+		}
+	}
+
+	return result,true
+}
+
+// Works out where segments s and t meet, and reports the points where either one needs to be split
+func findIntersection(s *segment, t *segment, splitS func(gridPoint), splitT func(gridPoint)) {
+	sxMin,sxMax,syMin,syMax := s.bounds()
+	txMin,txMax,tyMin,tyMax := t.bounds()
+	if (sxMax < txMin) || (txMax < sxMin) || (syMax < tyMin) || (tyMax < syMin) {
+		return
+	}
+
+	o1 := orientation(s.lo, s.hi, t.lo)
+	o2 := orientation(s.lo, s.hi, t.hi)
+	o3 := orientation(t.lo, t.hi, s.lo)
+	o4 := orientation(t.lo, t.hi, s.hi)
+
+	if (o1 == 0) && (o2 == 0) {
+		// The segments are collinear, so they overlap wherever the end of one is inside the other
+		for _,p := range []gridPoint{t.lo, t.hi} {
+			if s.containsCollinear(p) {
+				splitS(p)
+			}
+		}
+		for _,p := range []gridPoint{s.lo, s.hi} {
+			if t.containsCollinear(p) {
+				splitT(p)
+			}
+		}
+		return
+	}
+
+	if (o1 * o2 < 0) && (o3 * o4 < 0) {
+		// The segments properly cross, so find the crossing point and snap it to the grid
+		rx,ry := float64(s.hi.x - s.lo.x),float64(s.hi.y - s.lo.y)
+		qx,qy := float64(t.hi.x - t.lo.x),float64(t.hi.y - t.lo.y)
+		px,py := float64(t.lo.x - s.lo.x),float64(t.lo.y - s.lo.y)
+		along := ((px * qy) - (py * qx)) / ((rx * qy) - (ry * qx))
+
+		// Keep the point inside both segments' bounds, in case the segments are nearly parallel
+		x := int64(math.Round(float64(s.lo.x) + (along * rx)))
+		y := int64(math.Round(float64(s.lo.y) + (along * ry)))
+		x = max(max(sxMin, txMin), min(x, min(sxMax, txMax)))
+		y = max(max(syMin, tyMin), min(y, min(syMax, tyMax)))
+
+		p := gridPoint{x, y}
+		splitS(p)
+		splitT(p)
+		return
+	}
+
+	// Otherwise, the segments can only meet where the end of one touches the other
+	if (o1 == 0) && s.containsCollinear(t.lo) {
+		splitS(t.lo)
+	}
+	if (o2 == 0) && s.containsCollinear(t.hi) {
+		splitS(t.hi)
+	}
+	if (o3 == 0) && t.containsCollinear(s.lo) {
+		splitT(s.lo)
+	}
+	if (o4 == 0) && t.containsCollinear(s.hi) {
+		splitT(s.hi)
+	}
+}
+
+// Combines segments that lie exactly on top of each other, and drops the ones that cancel out completely
+func mergeSegments(segments []*segment, numOperands int) []*segment {
+	merged := make(map[[2]gridPoint]*segment, len(segments))
+	order := make([][2]gridPoint, 0, len(segments))
+	for _,seg := range segments {
+		key := [2]gridPoint{seg.lo, seg.hi}
+		if existing,found := merged[key]; found {
+			for operand,count := range seg.winding {
+				existing.winding[operand] += count
+			}
+		} else {
+			merged[key] = seg
+			order = append(order, key)
+		}
+	}
+
+	result := make([]*segment, 0, len(merged))
+	for _,key := range order {
+		seg := merged[key]
+		for _,count := range seg.winding {
+			if count != 0 {
+				result = append(result, seg)
+				break
+			}
+		}
+	}
+
+	return result
+}
+
+// A directed edge of the result, with the filled area on its left
+type boundaryEdge struct {
+	from gridPoint
+	to gridPoint
+	used bool
+}
+
+func filled(winding int, rule FillRule) bool {
+	switch rule {
+		case NON_ZERO:
+			return winding != 0
+
+		case POSITIVE:
+			return winding > 0
+
+		default:
+			return (winding % 2) != 0
+	}
+}
+
+// Keeps the segments that have the inside of the result on one side and the outside on the other,
+// turned so that the inside is on their left
+func selectBoundary(segments []*segment, rules []FillRule, inside func(filled []bool) bool) []*boundaryEdge {
+	if len(segments) == 0 {
+		return nil
+	}
+
+	st := newStrips(segments)
+	filledLeft := make([]bool, len(rules))
+	filledRight := make([]bool, len(rules))
+
+	var boundary []*boundaryEdge
+	for i,seg := range segments {
+		// Every segment goes from lo to hi, so the winding on the left of it is the winding on the right, plus the segment itself
+		right := windingRightOf(segments, st, i)
+		for operand,rule := range rules {
+			filledRight[operand] = filled(right[operand], rule)
+			filledLeft[operand] = filled(right[operand] + seg.winding[operand], rule)
+		}
+
+		insideLeft := inside(filledLeft)
+		insideRight := inside(filledRight)
+		if insideLeft && !insideRight {
+			boundary = append(boundary, &boundaryEdge{from: seg.lo, to: seg.hi})
+		} else if insideRight && !insideLeft {
+			boundary = append(boundary, &boundaryEdge{from: seg.hi, to: seg.lo})
+		}
+	}
+
+	return boundary
+}
+
+// The winding number of each operand just to the right of the middle of the segment (below it, unless it's vertical).
+// This comes from a ray cast straight down from there, adding up every segment that goes across the ray.
+// The segments have all been split where they meet, so none of them can cross each other, which means
+// comparing against the middle of the segment is enough to know which side of it they're on
+func windingRightOf(segments []*segment, st *strips, index int) []int {
+	seg := segments[index]
+	winding := make([]int, len(seg.winding))
+
+	// Work in doubled coordinates, so the middle of the segment is on the grid
+	midX := seg.lo.x + seg.hi.x
+	midY := seg.lo.y + seg.hi.y
+
+	var bucket int
+	if seg.vertical() {
+		// The right of a vertical segment going up is just past its x, but we cast the ray from just before its x
+		// on the left instead, and take the segment itself back out afterwards
+		bucket = st.index(floorDiv(midX - 1, 2))
+	} else {
+		bucket = st.index(floorDiv(midX, 2))
+	}
+
+	for _,other := range st.buckets[bucket] {
+		if other == index {
+			continue
+		}
+
+		t := segments[other]
+		var crosses bool
+		if seg.vertical() {
+			// The ray is just to the left of x, so take segments that start before x and end at or after it
+			crosses = (2 * t.lo.x < midX) && (2 * t.hi.x >= midX)
+		} else {
+			// Half open, so segments that meet at a vertex on the ray are only counted once
+			crosses = (2 * t.lo.x <= midX) && (2 * t.hi.x > midX)
+		}
+
+		if crosses && (crossSign(t.hi.x - t.lo.x, t.hi.y - t.lo.y, midX - (2 * t.lo.x), midY - (2 * t.lo.y)) > 0) {
+			// The middle of the segment is above t, so t crosses the ray
+			for operand,count := range t.winding {
+				winding[operand] += count
+			}
+		}
+	}
+
+	if seg.vertical() {
+		// We found the winding on the left of the segment, so the right is the left without the segment itself
+		for operand,count := range seg.winding {
+			winding[operand] -= count
+		}
+	}
+
+	return winding
+}
+
+// Links the boundary edges up into closed rings.  Where more than one edge leaves a point, we always take the one
+// that turns furthest to the left, which keeps each ring as small as possible (rings that only touch at a point
+// come out as separate rings)
+func traceRings(boundary []*boundaryEdge) [][]gridPoint {
+	outgoing := make(map[gridPoint][]*boundaryEdge, len(boundary))
+	for _,edge := range boundary {
+		outgoing[edge.from] = append(outgoing[edge.from], edge)
+	}
+
+	var rings [][]gridPoint
+	for _,start := range boundary {
+		if start.used {
+			continue
+		}
+
+		ring := []gridPoint{start.from}
+		edge := start
+		edge.used = true
+		for {
+			next := nextEdge(outgoing[edge.to], edge)
+			if (next == start) || (next == nil) || next.used {
+				break
+			}
+			ring = append(ring, next.from)
+			next.used = true
+			edge = next
+		}
+
+		if ring = removeCollinear(ring); len(ring) >= 3 {
+			rings = append(rings, ring)
+		}
+	}
+
+	return rings
+}
+
+// Of the edges leaving the end of incoming, the first one clockwise from the way we came in
+func nextEdge(candidates []*boundaryEdge, incoming *boundaryEdge) *boundaryEdge {
+	// The direction back along the incoming edge
+	backX := incoming.from.x - incoming.to.x
+	backY := incoming.from.y - incoming.to.y
+
+	// Which half turn (counter-clockwise from the way back) the edge is in
+	half := func(edge *boundaryEdge) int {
+		dx,dy := edge.to.x - edge.from.x,edge.to.y - edge.from.y
+		cross := crossSign(backX, backY, dx, dy)
+		if (cross > 0) || ((cross == 0) && (dotSign(backX, backY, dx, dy) > 0)) {
+			return 0
+		}
+		return 1
+	}
+
+	// The first edge clockwise is the one that's furthest counter-clockwise
+	var best *boundaryEdge
+	bestHalf := 0
+	for _,candidate := range candidates {
+		candidateHalf := half(candidate)
+		if best == nil || (candidateHalf > bestHalf) {
+			best,bestHalf = candidate,candidateHalf
+		} else if candidateHalf == bestHalf {
+			bestX,bestY := best.to.x - best.from.x,best.to.y - best.from.y
+			if crossSign(bestX, bestY, candidate.to.x - candidate.from.x, candidate.to.y - candidate.from.y) > 0 {
+				best = candidate
+			}
+		}
+	}
+
+	return best
+}
+
+// Drops points that don't change the direction of the ring (left over from splitting edges)
+func removeCollinear(ring []gridPoint) []gridPoint {
+	for changed := true; changed && (len(ring) >= 3); {
+		changed = false
+		kept := make([]gridPoint, 0, len(ring))
+		for i := range ring {
+			previous := ring[(i + len(ring) - 1) % len(ring)]
+			if len(kept) > 0 {
+				previous = kept[len(kept) - 1]
+			}
+			next := ring[(i + 1) % len(ring)]
+			if orientation(previous, ring[i], next) == 0 {
+				changed = true
+				continue
+			}
+			kept = append(kept, ring[i])
+		}
+		ring = kept
+	}
+
+	return ring
+}
+
+// Sorts the rings into outer rings (counter-clockwise) and holes (clockwise), and puts each hole
+// in the smallest outer ring around it
+func buildPolygons(rings [][]gridPoint) []Polygon {
+	type outerRing struct {
+		points []gridPoint
+		area int128
+		xMin, xMax, yMin, yMax int64
+	}
+
+	var outers []*outerRing
+	var holes [][]gridPoint
+	for _,ring := range rings {
+		area := doubleArea(ring)
+		switch area.sign() {
+			case 1:
+				outer := &outerRing{points: ring, area: area, xMin: ring[0].x, xMax: ring[0].x, yMin: ring[0].y, yMax: ring[0].y}
+				for _,point := range ring {
+					outer.xMin,outer.xMax = min(outer.xMin, point.x),max(outer.xMax, point.x)
+					outer.yMin,outer.yMax = min(outer.yMin, point.y),max(outer.yMax, point.y)
+				}
+				outers = append(outers, outer)
+
+			case -1:
+				holes = append(holes, ring)
+		}
+	}
+
+	polygons := make([]Polygon, len(outers))
+	for i,outer := range outers {
+		polygons[i].Outer = ringFromGrid(outer.points)
+	}
+
+	for _,hole := range holes {
+		// The middle of an edge of the hole can't be on any other ring, so it's a safe point to test (in doubled coordinates)
+		midX := hole[0].x + hole[1].x
+		midY := hole[0].y + hole[1].y
+
+		best := -1
+		for i,outer := range outers {
+			if (midX < 2 * outer.xMin) || (midX > 2 * outer.xMax) || (midY < 2 * outer.yMin) || (midY > 2 * outer.yMax) {
+				continue
+			}
+			if !containsDoubled(outer.points, midX, midY) {
+				continue
+			}
+			if (best < 0) || (outer.area.sub(outers[best].area).sign() < 0) {
+				best = i
+			}
+		}
+
+		if best >= 0 {
+			polygons[best].Holes = append(polygons[best].Holes, ringFromGrid(hole))
+		}
+	}
+
+	return polygons
+}
+
+// Whether the point (in doubled coordinates) is inside the ring, by casting a ray to the right
+func containsDoubled(ring []gridPoint, x int64, y int64) bool {
+	inside := false
+	for i := range ring {
+		a := ring[i]
+		b := ring[(i + 1) % len(ring)]
+		if (2 * a.y > y) == (2 * b.y > y) {
+			continue
+		}
+
+		// Make a the lower end, so the point is to the left of the edge if the cross product is positive
+		if a.y > b.y {
+			a,b = b,a
+		}
+		if crossSign(b.x - a.x, b.y - a.y, x - (2 * a.x), y - (2 * a.y)) > 0 {
+			inside = !inside
+		}
+	}
+
+	return inside
+}
+
+func ringFromGrid(points []gridPoint) Ring {
+	ring := make(Ring, len(points))
+	for i,point := range points {
+		ring[i] = fromGrid(point)
+	}
+
+	return ring
+}
@@ -0,0 +1,107 @@
+package polygon
+
+import (
+	"math"
+	"testing"
+)
+
+func square(x float64, y float64, size float64) Ring {
+	return Ring{{x, y}, {x + size, y}, {x + size, y + size}, {x, y + size}}
+}
+
+func checkResult(t *testing.T, name string, polygons []Polygon, err error, numPolygons int, numHoles int, area float64) {
+	t.Helper()
+
+	if err != nil {
+		t.Errorf("%s: unexpected error %v", name, err)
+		return
+	}
+
+	holes := 0
+	for _,polygon := range polygons {
+		holes += len(polygon.Holes)
+	}
+
+	if (len(polygons) != numPolygons) || (holes != numHoles) || (math.Abs(Area(polygons) - area) > 1e-9) {
+		t.Errorf("%s: got %d polygons with %d holes and area %v, expected %d polygons with %d holes and area %v (%v)",
+			name, len(polygons), holes, Area(polygons), numPolygons, numHoles, area, polygons)
+	}
+}
+
+func TestSharedEdges(t *testing.T) {
+	// Two squares side by side become one rectangle, with no vertices left over in the middle of its edges
+	union,err := Union([]Polygon{{Outer: square(0, 0, 1)}}, []Polygon{{Outer: square(1, 0, 1)}})
+	checkResult(t, "Union of squares sharing an edge", union, err, 1, 0, 2.0)
+	if (err == nil) && (len(union) == 1) && (len(union[0].Outer) != 4) {
+		t.Errorf("Union of squares sharing an edge: expected 4 vertices, got %v", union[0].Outer)
+	}
+
+	// Taking away a square that shares an edge with the first one leaves the first one alone
+	difference,err := Difference([]Polygon{{Outer: square(0, 0, 1)}}, []Polygon{{Outer: square(1, 0, 1)}})
+	checkResult(t, "Difference of squares sharing an edge", difference, err, 1, 0, 1.0)
+
+	// Shapes that only share an edge don't overlap at all
+	intersection,err := Intersection([]Polygon{{Outer: square(0, 0, 1)}}, []Polygon{{Outer: square(1, 0, 1)}})
+	checkResult(t, "Intersection of squares sharing an edge", intersection, err, 0, 0, 0.0)
+
+	// Edges lying on top of each other in the same direction cancel out completely
+	xor,err := Xor([]Polygon{{Outer: square(0, 0, 1)}}, []Polygon{{Outer: square(0, 0, 1)}})
+	checkResult(t, "Xor of a square with itself", xor, err, 0, 0, 0.0)
+}
+
+func TestCollinearEdges(t *testing.T) {
+	// The smaller square's left edge lies along part of the bigger square's right edge
+	union,err := Union([]Polygon{{Outer: square(0, 0, 2)}}, []Polygon{{Outer: square(2, 0.5, 1)}})
+	checkResult(t, "Union of squares with partly overlapping edges", union, err, 1, 0, 5.0)
+	if (err == nil) && (len(union) == 1) && (len(union[0].Outer) != 8) {
+		t.Errorf("Union of squares with partly overlapping edges: expected 8 vertices, got %v", union[0].Outer)
+	}
+
+	// Points in the middle of a straight edge don't change anything
+	withExtraPoints := Ring{{0, 0}, {1, 0}, {2, 0}, {2, 1}, {2, 2}, {0, 2}}
+	simplified,err := Simplify([]Ring{withExtraPoints}, NON_ZERO)
+	checkResult(t, "Square with collinear points", simplified, err, 1, 0, 4.0)
+	if (err == nil) && (len(simplified) == 1) && (len(simplified[0].Outer) != 4) {
+		t.Errorf("Square with collinear points: expected 4 vertices, got %v", simplified[0].Outer)
+	}
+}
+
+func TestTouchingVertices(t *testing.T) {
+	// Squares that only touch at a corner stay separate
+	union,err := Union([]Polygon{{Outer: square(0, 0, 1)}}, []Polygon{{Outer: square(1, 1, 1)}})
+	checkResult(t, "Union of squares touching at a corner", union, err, 2, 0, 2.0)
+
+	// A bow tie crosses itself in the middle, so it's two triangles touching at their tips
+	bowTie := Ring{{0, 0}, {2, 2}, {2, 0}, {0, 2}}
+	simplified,err := Simplify([]Ring{bowTie}, EVEN_ODD)
+	checkResult(t, "Bow tie", simplified, err, 2, 0, 2.0)
+
+	// A triangle with one vertex on the edge of the square cuts a notch out of it, rather than a hole
+	notch := Ring{{0, 2}, {2, 1}, {2, 3}}
+	difference,err := Difference([]Polygon{{Outer: square(0, 0, 4)}}, []Polygon{{Outer: notch}})
+	checkResult(t, "Square with a triangle touching its edge taken out", difference, err, 1, 0, 14.0)
+}
+
+func TestHoles(t *testing.T) {
+	difference,err := Difference([]Polygon{{Outer: square(0, 0, 3)}}, []Polygon{{Outer: square(1, 1, 1)}})
+	checkResult(t, "Square with a square taken out of the middle", difference, err, 1, 1, 8.0)
+
+	// Filling in the hole again leaves just the outer square
+	union,err := Union(difference, []Polygon{{Outer: square(1, 1, 1)}})
+	checkResult(t, "Square with its hole filled in", union, err, 1, 0, 9.0)
+
+	// Something smaller than the hole that's inside it is a separate polygon
+	island,err := Union(difference, []Polygon{{Outer: square(1.25, 1.25, 0.5)}})
+	checkResult(t, "Square with an island in its hole", island, err, 2, 1, 8.25)
+
+	// Nested rings wound the same way are a hole with the even-odd rule, but not with the non-zero rule
+	nested := []Ring{square(0, 0, 4), square(1, 1, 2)}
+	evenOdd,err := Simplify(nested, EVEN_ODD)
+	checkResult(t, "Nested squares with the even-odd rule", evenOdd, err, 1, 1, 12.0)
+	nonZero,err := Simplify(nested, NON_ZERO)
+	checkResult(t, "Nested squares with the non-zero rule", nonZero, err, 1, 0, 16.0)
+
+	// A hole that touches the outer ring at a vertex
+	touching,err := Difference([]Polygon{{Outer: square(0, 0, 4)}}, []Polygon{{Outer: Ring{{0, 0}, {2, 1}, {1, 2}}}})
+	checkResult(t, "Square with a hole touching its corner", touching, err, 1, 0, 14.5)
+}
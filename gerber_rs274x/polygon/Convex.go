@@ -0,0 +1,527 @@
+package polygon
+
+import (
+	"math"
+	"sort"
+)
+
+// Corners of the convex pieces that are less than this far off a straight line (as a fraction of the line's length)
+// are treated as straight
+const STRAIGHT_TURN_TOLERANCE float64 = 1e-9
+
+// The corners of the convex hull of the points, counter-clockwise.  Corners that lie on a straight edge are left out
+func ConvexHull(points []Point) Ring {
+	sorted := make([]Point, len(points))
+	copy(sorted, points)
+	sort.Slice(sorted, func(i int, j int) bool {
+		if sorted[i].X != sorted[j].X {
+			return sorted[i].X < sorted[j].X
+		}
+		return sorted[i].Y < sorted[j].Y
+	})
+	// Repeated points would come out as repeated corners
+	unique := sorted[:0]
+	for _,point := range sorted {
+		if (len(unique) == 0) || (point != unique[len(unique) - 1]) {
+			unique = append(unique, point)
+		}
+	}
+	sorted = unique
+
+	// We build the lower half of the hull going right, then the upper half going back left, dropping any point
+	// that doesn't make a left turn
+	hull := make(Ring, 0, len(sorted) + 1)
+	for pass := 0; pass < 2; pass++ {
+		halfStart := len(hull)
+		for _,point := range sorted {
+			for len(hull) >= halfStart + 2 && cross(hull[len(hull) - 2], hull[len(hull) - 1], point) <= 0.0 {
+				hull = hull[:len(hull) - 1]
+			}
+			hull = append(hull, point)
+		}
+		// The last point of each half is the first point of the other
+		hull = hull[:len(hull) - 1]
+
+		for i,j := 0,len(sorted) - 1; i < j; i,j = i + 1,j - 1 {
+			sorted[i],sorted[j] = sorted[j],sorted[i]
+		}
+	}
+
+	if len(hull) == 0 && len(points) > 0 {
+		// All of the points were the same
+		hull = append(hull, sorted[0])
+	}
+
+	return hull
+}
+
+// Breaks the polygons up into convex pieces that don't overlap, counter-clockwise, which together cover exactly
+// the same area.  The polygons mustn't overlap themselves or each other, as is the case for anything that comes out
+// of Simplify or the boolean operations.
+// Each polygon is cut up into triangles, and then neighbouring triangles are joined back together wherever the result
+// stays convex, so shapes that are already convex come out as a single piece and anything else comes out as a handful
+// of pieces per dent
+func ConvexPieces(polygons []Polygon) []Ring {
+	var pieces []Ring
+	for _,polygon := range polygons {
+		if triangles,ok := triangulate(polygon); ok {
+			pieces = append(pieces, mergeConvex(triangles)...)
+		} else {
+			// Rounding errors can leave no triangle that's clear of the rest of the polygon, but cutting the polygon
+			// into trapezoids always works
+			pieces = append(pieces, trapezoidPieces([]Polygon{polygon})...)
+		}
+	}
+
+	return pieces
+}
+
+// Cuts the polygon up into counter-clockwise triangles, by joining each hole to the outer ring and then clipping off one
+// corner after another.  Returns false if that didn't work out, which only happens when rounding errors get in the way
+func triangulate(polygon Polygon) ([]Ring, bool) {
+	points := removeDuplicates(polygon.Outer)
+	if points.Area() < 0.0 {
+		points = points.Reversed()
+	}
+
+	// Joining the holes from right to left means that each hole is joined to the outer ring, or to a hole that has already
+	// been joined to it, so that there's only the one ring left at the end
+	holes := make([]Ring, 0, len(polygon.Holes))
+	for _,hole := range polygon.Holes {
+		if hole = removeDuplicates(hole); len(hole) >= 3 {
+			if hole.Area() > 0.0 {
+				hole = hole.Reversed()
+			}
+			holes = append(holes, hole)
+		}
+	}
+	sort.Slice(holes, func(i int, j int) bool {
+		_,xMaxI,_,_ := holes[i].Bounds()
+		_,xMaxJ,_,_ := holes[j].Bounds()
+		return xMaxI > xMaxJ
+	})
+
+	for i,hole := range holes {
+		var ok bool
+		if points,ok = joinHole(points, hole, holes[i + 1:]); !ok {
+			return nil,false
+		}
+	}
+
+	triangles,ok := clipEars(points)
+	if !ok {
+		return nil,false
+	}
+
+	// As a last check, the triangles have to cover the polygon exactly
+	area := 0.0
+	for _,triangle := range triangles {
+		area += triangle.Area()
+	}
+	if math.Abs(area - polygon.Area()) > (STRAIGHT_TURN_TOLERANCE * math.Abs(polygon.Area())) {
+		return nil,false
+	}
+
+	return triangles,true
+}
+
+// Joins the hole on to the ring with a cut from its rightmost corner to the nearest corner of the ring it can see, going
+// out along the cut, around the hole and back again.  The holes that haven't been joined yet can block the cut too
+func joinHole(ring Ring, hole Ring, otherHoles []Ring) (Ring, bool) {
+	start := 0
+	for i,point := range hole {
+		if point.X > hole[start].X {
+			start = i
+		}
+	}
+	from := hole[start]
+
+	visible := func(to Point) bool {
+		for _,other := range append([]Ring{ring, hole}, otherHoles...) {
+			for i,a := range other {
+				b := other[(i + 1) % len(other)]
+				if segmentsCross(from, to, a, b) {
+					return false
+				}
+			}
+		}
+		return true
+	}
+
+	best := -1
+	bestDistance := math.Inf(1)
+	for i,to := range ring {
+		distance := math.Hypot(to.X - from.X, to.Y - from.Y)
+		// The same corner can be in the ring twice where an earlier hole was joined on, so the cut has to go into
+		// the right one of them
+		if (distance < bestDistance) && insideCorner(ring[(i + len(ring) - 1) % len(ring)], to, ring[(i + 1) % len(ring)], from) && visible(to) {
+			best = i
+			bestDistance = distance
+		}
+	}
+
+	if best < 0 {
+		return nil,false
+	}
+
+	joined := make(Ring, 0, len(ring) + len(hole) + 2)
+	joined = append(joined, ring[:best + 1]...)
+	joined = append(joined, hole[start:]...)
+	joined = append(joined, hole[:start + 1]...)
+	return append(joined, ring[best:]...),true
+}
+
+// Whether the segments from a to b and from c to d cross, or touch anywhere other than at their ends
+func segmentsCross(a Point, b Point, c Point, d Point) bool {
+	if (a == c) || (a == d) || (b == c) || (b == d) {
+		return false
+	}
+
+	abc,abd := cross(a, b, c),cross(a, b, d)
+	cda,cdb := cross(c, d, a),cross(c, d, b)
+	if ((abc == 0.0) && onSegment(a, b, c)) || ((abd == 0.0) && onSegment(a, b, d)) || ((cda == 0.0) && onSegment(c, d, a)) || ((cdb == 0.0) && onSegment(c, d, b)) {
+		return true
+	}
+
+	return ((abc > 0.0) != (abd > 0.0)) && ((cda > 0.0) != (cdb > 0.0)) && (abc != 0.0) && (abd != 0.0) && (cda != 0.0) && (cdb != 0.0)
+}
+
+// Whether p, which is on the line through a and b, is between them
+func onSegment(a Point, b Point, p Point) bool {
+	return (math.Min(a.X, b.X) <= p.X) && (p.X <= math.Max(a.X, b.X)) && (math.Min(a.Y, b.Y) <= p.Y) && (p.Y <= math.Max(a.Y, b.Y))
+}
+
+// Whether the direction from the corner to the point goes into the filled side of the corner, between the edge coming in
+// from before and the edge going out to after
+func insideCorner(before Point, corner Point, after Point, point Point) bool {
+	if cross(before, corner, after) >= 0.0 {
+		return (cross(before, corner, point) > 0.0) && (cross(corner, after, point) > 0.0)
+	}
+
+	return (cross(before, corner, point) > 0.0) || (cross(corner, after, point) > 0.0)
+}
+
+// Cuts a counter-clockwise ring into triangles by repeatedly clipping off a corner that doesn't have any of the rest
+// of the ring inside it.  Of all the corners that could be clipped off, we take the one with the shortest cut across it,
+// which keeps the triangles from fanning out of a single corner and makes them much easier to join back together.
+// Returns false if there's no such corner left before the ring is used up
+func clipEars(ring Ring) ([]Ring, bool) {
+	remaining := append(Ring(nil), ring...)
+	var triangles []Ring
+	for len(remaining) > 3 {
+		best := -1
+		bestCut := math.Inf(1)
+		for i := 0; i < len(remaining); i++ {
+			before := remaining[(i + len(remaining) - 1) % len(remaining)]
+			corner := remaining[i]
+			after := remaining[(i + 1) % len(remaining)]
+
+			bend := turn(before, corner, after)
+			if bend == 0.0 {
+				// Corners on a straight line are dropped straight away, without leaving a triangle behind
+				best = i
+				break
+			}
+
+			if cut := math.Hypot(after.X - before.X, after.Y - before.Y); (bend > 0.0) && (cut < bestCut) && clearEar(remaining, before, corner, after) {
+				best = i
+				bestCut = cut
+			}
+		}
+
+		if best < 0 {
+			return nil,false
+		}
+
+		before := remaining[(best + len(remaining) - 1) % len(remaining)]
+		corner := remaining[best]
+		after := remaining[(best + 1) % len(remaining)]
+		if turn(before, corner, after) > 0.0 {
+			triangles = append(triangles, Ring{before, corner, after})
+		}
+		remaining = append(remaining[:best], remaining[best + 1:]...)
+	}
+
+	if (len(remaining) == 3) && (turn(remaining[0], remaining[1], remaining[2]) > 0.0) {
+		triangles = append(triangles, remaining)
+	}
+
+	return triangles,true
+}
+
+// Whether none of the other corners of the ring are in the triangle, or on its edges.  The corners of the triangle can be
+// in the ring more than once where the holes were joined on, which doesn't get in the way
+func clearEar(ring Ring, a Point, b Point, c Point) bool {
+	for _,point := range ring {
+		if (point == a) || (point == b) || (point == c) {
+			continue
+		}
+
+		if (cross(a, b, point) >= 0.0) && (cross(b, c, point) >= 0.0) && (cross(c, a, point) >= 0.0) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Joins neighbouring convex pieces together for as long as the result stays convex.  Removing an edge between two pieces
+// only changes the corners at the ends of that edge, so only those have to be checked
+func mergeConvex(pieces []Ring) []Ring {
+	pieces = append([]Ring(nil), pieces...)
+	type edge struct {
+		from Point
+		to Point
+	}
+	owners := make(map[edge]int)
+	for i,piece := range pieces {
+		for j,point := range piece {
+			owners[edge{point, piece[(j + 1) % len(piece)]}] = i
+		}
+	}
+
+	for i := range pieces {
+		for merged := true; merged && (pieces[i] != nil); {
+			merged = false
+			piece := pieces[i]
+			for j,from := range piece {
+				to := piece[(j + 1) % len(piece)]
+				other,found := owners[edge{to, from}]
+				if !found || (other == i) || (pieces[other] == nil) {
+					continue
+				}
+
+				// Go around this piece from the end of the shared edge back to its start, and then around the other piece
+				// from the start of the shared edge back to its end
+				neighbour := pieces[other]
+				k := 0
+				for (neighbour[k] != to) || (neighbour[(k + 1) % len(neighbour)] != from) {
+					k++
+				}
+				joined := make(Ring, 0, len(piece) + len(neighbour) - 2)
+				for n := 1; n <= len(piece); n++ {
+					joined = append(joined, piece[(j + n) % len(piece)])
+				}
+				for n := 2; n < len(neighbour); n++ {
+					joined = append(joined, neighbour[(k + n) % len(neighbour)])
+				}
+
+				if !isConvex(joined) {
+					continue
+				}
+
+				pieces[i] = joined
+				pieces[other] = nil
+				for n,point := range joined {
+					owners[edge{point, joined[(n + 1) % len(joined)]}] = i
+				}
+				merged = true
+				break
+			}
+		}
+	}
+
+	var convex []Ring
+	for _,piece := range pieces {
+		if piece != nil {
+			convex = append(convex, piece)
+		}
+	}
+
+	return convex
+}
+
+// Whether the counter-clockwise ring turns left (or goes straight on) at every corner
+func isConvex(ring Ring) bool {
+	for i,corner := range ring {
+		if turn(ring[(i + len(ring) - 1) % len(ring)], corner, ring[(i + 1) % len(ring)]) < 0.0 {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Cuts the polygons into trapezoids between each of the heights that a corner is at, and adds each trapezoid on to the top
+// of the piece below it, as long as that piece stays convex.  This takes more pieces than joining up triangles, since all
+// of the cuts are horizontal, but it can't go wrong
+func trapezoidPieces(polygons []Polygon) []Ring {
+	var edges []pieceEdge
+	var heights []float64
+	for _,ring := range Rings(polygons) {
+		for i,start := range ring {
+			end := ring[(i + 1) % len(ring)]
+			heights = append(heights, start.Y)
+			if start.Y < end.Y {
+				edges = append(edges, pieceEdge{start, end})
+			} else if start.Y > end.Y {
+				edges = append(edges, pieceEdge{end, start})
+			}
+		}
+	}
+
+	sort.Float64s(heights)
+	sort.Slice(edges, func(i int, j int) bool {
+		return edges[i].bottom.Y < edges[j].bottom.Y
+	})
+
+	var pieces []Ring
+	// The pieces that are still open at the top, which the next trapezoids can be added to
+	var open []*convexPiece
+	nextEdge := 0
+	var active []pieceEdge
+	for i := 1; i < len(heights); i++ {
+		bottom,top := heights[i - 1],heights[i]
+		if bottom == top {
+			continue
+		}
+
+		// Keep track of the edges that cross all the way over this slice.  None of them can cross each other, and no corner
+		// is strictly between the bottom and the top, so their order across the slice is the same all the way up
+		for nextEdge < len(edges) && edges[nextEdge].bottom.Y <= bottom {
+			active = append(active, edges[nextEdge])
+			nextEdge++
+		}
+		remaining := active[:0]
+		for _,edge := range active {
+			if edge.top.Y > bottom {
+				remaining = append(remaining, edge)
+			}
+		}
+		active = remaining
+
+		middle := (bottom + top) / 2.0
+		sort.Slice(active, func(i int, j int) bool {
+			return active[i].xAt(middle) < active[j].xAt(middle)
+		})
+
+		// The polygons don't overlap, so what's between the first and second edges is filled, what's between the second
+		// and third isn't, and so on
+		var stillOpen []*convexPiece
+		for j := 0; j + 1 < len(active); j += 2 {
+			bottomLeft := Point{active[j].xAt(bottom), bottom}
+			bottomRight := Point{active[j + 1].xAt(bottom), bottom}
+			topLeft := Point{active[j].xAt(top), top}
+			topRight := Point{active[j + 1].xAt(top), top}
+
+			var piece *convexPiece
+			for k,candidate := range open {
+				if (candidate != nil) && candidate.extend(bottomLeft, bottomRight, topLeft, topRight) {
+					piece = candidate
+					open[k] = nil
+					break
+				}
+			}
+			if piece == nil {
+				piece = &convexPiece{left: []Point{bottomLeft, topLeft}, right: []Point{bottomRight, topRight}}
+			}
+			stillOpen = append(stillOpen, piece)
+		}
+
+		// Whatever didn't carry on up into this slice is finished
+		for _,piece := range open {
+			if piece != nil {
+				pieces = appendPiece(pieces, piece)
+			}
+		}
+		open = stillOpen
+	}
+
+	for _,piece := range open {
+		pieces = appendPiece(pieces, piece)
+	}
+
+	return pieces
+}
+
+// An edge of a polygon that isn't horizontal, from its lower end to its upper end
+type pieceEdge struct {
+	bottom Point
+	top Point
+}
+
+// Where the edge is at the height.  The ends come out exactly, so that trapezoids meeting at a corner line up exactly
+func (edge pieceEdge) xAt(y float64) float64 {
+	if y == edge.bottom.Y {
+		return edge.bottom.X
+	} else if y == edge.top.Y {
+		return edge.top.X
+	}
+
+	return edge.bottom.X + ((edge.top.X - edge.bottom.X) * (y - edge.bottom.Y) / (edge.top.Y - edge.bottom.Y))
+}
+
+// A convex piece built up out of trapezoids, as its left and right sides going up
+type convexPiece struct {
+	left []Point
+	right []Point
+}
+
+// Adds the trapezoid on to the top of the piece, if it sits exactly on top and the piece stays convex
+func (piece *convexPiece) extend(bottomLeft Point, bottomRight Point, topLeft Point, topRight Point) bool {
+	leftEnd := len(piece.left) - 1
+	rightEnd := len(piece.right) - 1
+	// Pieces that come to a point can't be carried on, since whatever is above only touches them at the point
+	if (bottomLeft != piece.left[leftEnd]) || (bottomRight != piece.right[rightEnd]) || (bottomLeft == bottomRight) {
+		return false
+	}
+
+	// Going up, the left side has to keep turning right and the right side has to keep turning left
+	leftTurn := turn(piece.left[leftEnd - 1], bottomLeft, topLeft)
+	rightTurn := turn(piece.right[rightEnd - 1], bottomRight, topRight)
+	if (leftTurn > 0.0) || (rightTurn < 0.0) {
+		return false
+	}
+
+	// Corners that end up on a straight side aren't corners any more
+	if leftTurn == 0.0 {
+		piece.left[leftEnd] = topLeft
+	} else {
+		piece.left = append(piece.left, topLeft)
+	}
+	if rightTurn == 0.0 {
+		piece.right[rightEnd] = topRight
+	} else {
+		piece.right = append(piece.right, topRight)
+	}
+
+	return true
+}
+
+// Adds the piece as a counter-clockwise ring, going up the right side and back down the left
+func appendPiece(pieces []Ring, piece *convexPiece) []Ring {
+	ring := make(Ring, 0, len(piece.left) + len(piece.right))
+	for _,point := range piece.right {
+		ring = append(ring, point)
+	}
+	for i := len(piece.left) - 1; i >= 0; i-- {
+		// Pieces that come to a point at the top or bottom have the same point on both sides
+		if (len(ring) > 0 && piece.left[i] == ring[len(ring) - 1]) || (i == 0 && piece.left[i] == ring[0]) {
+			continue
+		}
+		ring = append(ring, piece.left[i])
+	}
+
+	return append(pieces, ring)
+}
+
+// How far b is to the left of the line from a to c, as a fraction of the distance from a to c, so it's positive for
+// a left turn.  Corners too close to the line to tell apart from rounding errors (such as across the very thin trapezoids
+// between corners at almost the same height) count as straight
+func turn(a Point, b Point, c Point) float64 {
+	length := math.Hypot(c.X - a.X, c.Y - a.Y)
+	if length == 0.0 {
+		return 0.0
+	}
+
+	if offset := cross(a, b, c) / (length * length); math.Abs(offset) > STRAIGHT_TURN_TOLERANCE {
+		return offset
+	}
+
+	return 0.0
+}
+
+// Twice the signed area of the triangle, which is positive if it turns left at b
+func cross(a Point, b Point, c Point) float64 {
+	return ((b.X - a.X) * (c.Y - a.Y)) - ((b.Y - a.Y) * (c.X - a.X))
+}
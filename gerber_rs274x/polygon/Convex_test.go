@@ -0,0 +1,113 @@
+package polygon
+
+import (
+	"math"
+	"testing"
+)
+
+// A regular polygon with the given number of corners, counter-clockwise
+func regularPolygon(radius float64, corners int) Ring {
+	ring := make(Ring, corners)
+	for i := range ring {
+		angle := 2.0 * math.Pi * float64(i) / float64(corners)
+		ring[i] = Point{radius * math.Cos(angle), radius * math.Sin(angle)}
+	}
+
+	return ring
+}
+
+func TestConvexHull(t *testing.T) {
+	// Points inside the hull, on its edges and repeated are all left out
+	points := []Point{{0, 0}, {1, 0}, {2, 0}, {2, 2}, {1, 1}, {0, 2}, {2, 2}, {0, 1}}
+	hull := ConvexHull(points)
+	if (len(hull) != 4) || (hull.Area() != 4.0) {
+		t.Errorf("Convex hull is %v, expected the corners of a 2 by 2 square", hull)
+	}
+
+	if hull := ConvexHull([]Point{{1, 1}, {1, 1}}); len(hull) != 1 {
+		t.Errorf("Convex hull of a repeated point is %v, expected just the point", hull)
+	}
+}
+
+func TestConvexPieces(t *testing.T) {
+	lShape := Ring{{0, 0}, {2, 0}, {2, 1}, {1, 1}, {1, 2}, {0, 2}}
+	annulus := Polygon{Outer: regularPolygon(2, 32), Holes: []Ring{regularPolygon(1, 16).Reversed()}}
+
+	testCases := []struct {
+		name string
+		polygons []Polygon
+		// Triangles are joined back up into as few pieces as this, and the trapezoids into no more than maxTrapezoids
+		maxPieces, maxTrapezoids int
+	}{
+		{"Square", []Polygon{{Outer: square(0, 0, 1)}}, 1, 1},
+		{"Circle", []Polygon{{Outer: regularPolygon(1, 64)}}, 1, 1},
+		{"L shape", []Polygon{{Outer: lShape}}, 2, 2},
+		{"Separate squares", []Polygon{{Outer: square(0, 0, 1)}, {Outer: square(2, 0, 1)}}, 2, 2},
+		{"Square with a hole", []Polygon{{Outer: square(0, 0, 4), Holes: []Ring{square(1.5, 1.5, 1).Reversed()}}}, 4, 4},
+		// A convex piece can only run along one edge of the hole, since the hole dents into it
+		{"Annulus", []Polygon{annulus}, 16, 64},
+		{"Two holes", []Polygon{{Outer: square(0, 0, 6), Holes: []Ring{square(1, 1, 1).Reversed(), square(4, 4, 1).Reversed()}}}, 8, 8},
+	}
+
+	for _,testCase := range testCases {
+		checkPieces(t, testCase.name, testCase.polygons, ConvexPieces(testCase.polygons), testCase.maxPieces)
+		checkPieces(t, testCase.name + " (trapezoids)", testCase.polygons, trapezoidPieces(testCase.polygons), testCase.maxTrapezoids)
+	}
+}
+
+// Checks that the pieces are convex, counter-clockwise and cover exactly the polygons between them
+func checkPieces(t *testing.T, name string, polygons []Polygon, pieces []Ring, maxPieces int) {
+	t.Helper()
+
+	if len(pieces) > maxPieces {
+		t.Errorf("%s: got %d pieces, expected at most %d", name, len(pieces), maxPieces)
+	}
+
+	area := 0.0
+	var covered []Polygon
+	for _,piece := range pieces {
+		if !isConvex(piece) || (piece.Area() <= 0.0) {
+			t.Errorf("%s: piece %v isn't convex and counter-clockwise", name, piece)
+		}
+		area += piece.Area()
+		covered = append(covered, Polygon{Outer: piece})
+	}
+
+	// The pieces mustn't overlap, so their areas add up to the area of the polygons
+	if math.Abs(area - Area(polygons)) > 1e-9 {
+		t.Errorf("%s: pieces have a total area of %v, expected %v", name, area, Area(polygons))
+	}
+
+	if difference,err := Xor(covered, polygons); err != nil {
+		t.Errorf("%s: unexpected error comparing %v", name, err)
+	} else if Area(difference) > 1e-9 {
+		t.Errorf("%s: pieces differ from the polygons by an area of %v", name, Area(difference))
+	}
+}
+
+func TestSweep(t *testing.T) {
+	unitSquare := []Polygon{{Outer: square(0, 0, 1)}}
+	squareWithHole := []Polygon{{Outer: square(0, 0, 4), Holes: []Ring{square(1.5, 1.5, 1).Reversed()}}}
+
+	testCases := []struct {
+		name string
+		polygons []Polygon
+		path []Point
+		numPolygons, numHoles int
+		area float64
+	}{
+		{"Straight", unitSquare, []Point{{0, 0}, {2, 0}}, 1, 0, 3.0},
+		{"Diagonal", unitSquare, []Point{{0, 0}, {1, 1}}, 1, 0, 3.0},
+		{"Around a corner", unitSquare, []Point{{0, 0}, {2, 0}, {2, 2}}, 1, 0, 5.0},
+		{"Back on itself", unitSquare, []Point{{0, 0}, {2, 0}, {1, 0}}, 1, 0, 3.0},
+		{"Nowhere", unitSquare, []Point{{1, 1}, {1, 1}}, 1, 0, 1.0},
+		// The hole only stays uncovered where it's a hole the whole way along
+		{"Hole partly covered", squareWithHole, []Point{{0, 0}, {0.5, 0}}, 1, 1, (4.5 * 4.0) - 0.5},
+		{"Hole covered", squareWithHole, []Point{{0, 0}, {1, 0}}, 1, 0, 5.0 * 4.0},
+	}
+
+	for _,testCase := range testCases {
+		swept,err := Sweep(testCase.polygons, testCase.path)
+		checkResult(t, testCase.name, swept, err, testCase.numPolygons, testCase.numHoles, testCase.area)
+	}
+}
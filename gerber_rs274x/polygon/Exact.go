@@ -0,0 +1,114 @@
+package polygon
+
+import (
+	"math"
+	"math/bits"
+)
+
+// Coordinates are snapped to a grid of this many points per unit, and all of the decisions about where edges
+// meet are made exactly on the grid, so that rounding can never make the answers inconsistent with each other
+const GRID_SCALE float64 = 1e9
+
+// A point on the grid
+type gridPoint struct {
+	x int64
+	y int64
+}
+
+func toGrid(point Point) gridPoint {
+	return gridPoint{int64(math.Round(point.X * GRID_SCALE)), int64(math.Round(point.Y * GRID_SCALE))}
+}
+
+func fromGrid(point gridPoint) Point {
+	return Point{float64(point.x) / GRID_SCALE, float64(point.y) / GRID_SCALE}
+}
+
+// Orders points by x, then by y
+func (a gridPoint) less(b gridPoint) bool {
+	return (a.x < b.x) || ((a.x == b.x) && (a.y < b.y))
+}
+
+// A signed 128 bit integer, which is enough to hold the product of any two coordinate differences exactly
+type int128 struct {
+	hi int64
+	lo uint64
+}
+
+func mul128(a int64, b int64) int128 {
+	negative := (a < 0) != (b < 0)
+	hi,lo := bits.Mul64(abs64(a), abs64(b))
+	product := int128{int64(hi), lo}
+	if negative {
+		return product.neg()
+	}
+
+	return product
+}
+
+func (a int128) neg() int128 {
+	lo,carry := bits.Add64(^a.lo, 1, 0)
+	return int128{^a.hi + int64(carry), lo}
+}
+
+func (a int128) add(b int128) int128 {
+	lo,carry := bits.Add64(a.lo, b.lo, 0)
+	return int128{a.hi + b.hi + int64(carry), lo}
+}
+
+func (a int128) sub(b int128) int128 {
+	lo,borrow := bits.Sub64(a.lo, b.lo, 0)
+	return int128{a.hi - b.hi - int64(borrow), lo}
+}
+
+func (a int128) sign() int {
+	if a.hi < 0 {
+		return -1
+	} else if (a.hi == 0) && (a.lo == 0) {
+		return 0
+	}
+
+	return 1
+}
+
+func abs64(a int64) uint64 {
+	if a < 0 {
+		return uint64(-a)
+	}
+
+	return uint64(a)
+}
+
+// The sign of the cross product of the vectors (ax, ay) and (bx, by): positive if b is counter-clockwise from a
+func crossSign(ax int64, ay int64, bx int64, by int64) int {
+	return mul128(ax, by).sub(mul128(ay, bx)).sign()
+}
+
+// The sign of the dot product of the vectors (ax, ay) and (bx, by)
+func dotSign(ax int64, ay int64, bx int64, by int64) int {
+	return mul128(ax, bx).add(mul128(ay, by)).sign()
+}
+
+// Positive if c is to the left of the line from a to b, negative if it's to the right, and 0 if it's on the line
+func orientation(a gridPoint, b gridPoint, c gridPoint) int {
+	return crossSign(b.x - a.x, b.y - a.y, c.x - a.x, c.y - a.y)
+}
+
+// Twice the signed area of the ring (exactly)
+func doubleArea(ring []gridPoint) int128 {
+	var area int128
+	for i := range ring {
+		j := (i + 1) % len(ring)
+		area = area.add(mul128(ring[i].x, ring[j].y)).sub(mul128(ring[j].x, ring[i].y))
+	}
+
+	return area
+}
+
+func floorDiv(a int64, b int64) int64 {
+	quotient := a / b
+	if ((a % b) != 0) && ((a < 0) != (b < 0)) {
+		quotient--
+	}
+
+	return quotient
+}
@@ -0,0 +1,173 @@
+package polygon
+
+import "math"
+
+// How the moved edges are joined around the outside of a corner
+type JoinType int
+
+const (
+	JOIN_ROUND JoinType = iota // An arc around the corner, which is exactly what moving the edges by the distance sweeps out
+	JOIN_MITER // The edges are extended until they meet, as long as that's within the miter limit
+	JOIN_SQUARE // The corner is cut off square, the offset distance away from the original corner
+)
+
+const (
+	DEFAULT_MITER_LIMIT float64 = 2.0
+	// Round joins stay within this fraction of the offset distance of the true arc, unless a tolerance is given
+	DEFAULT_OFFSET_TOLERANCE_FRACTION float64 = 0.001
+)
+
+// OffsetOptions controls how the corners are handled when offsetting
+type OffsetOptions struct {
+	Join JoinType
+	// How far a miter join can reach, as a multiple of the offset distance.  Corners that would reach further are
+	// squared off instead.  Defaults to 2
+	MiterLimit float64
+	// The furthest the straight segments that approximate a round join are allowed to stray from the true arc.
+	// Defaults to 0.1% of the offset distance
+	Tolerance float64
+}
+
+// Grows the polygons by the distance, or shrinks them if the distance is negative.  Every edge is moved outwards
+// (away from the filled area) by the distance, with the corners joined as the options ask, and the result is merged,
+// so polygons that grow into each other become one and parts too thin to survive shrinking disappear
+func Offset(polygons []Polygon, distance float64, options OffsetOptions) ([]Polygon, error) {
+	if distance == 0.0 || math.IsNaN(distance) {
+		return Simplify(Rings(polygons), POSITIVE)
+	}
+
+	if options.MiterLimit < 1.0 {
+		options.MiterLimit = DEFAULT_MITER_LIMIT
+	}
+
+	if options.Tolerance <= 0.0 {
+		options.Tolerance = math.Abs(distance) * DEFAULT_OFFSET_TOLERANCE_FRACTION
+	}
+
+	var rings []Ring
+	for _,ring := range Rings(polygons) {
+		if offset := offsetRing(ring, distance, options); len(offset) > 0 {
+			rings = append(rings, offset)
+		}
+	}
+
+	// Where the moved edges fold back over each other, the ring winds the wrong way around (or not at all), so keeping
+	// only what the rings wind around counter-clockwise leaves exactly the offset area
+	return Simplify(rings, POSITIVE)
+}
+
+// Moves every edge of the ring to its right (outwards, since the filled area is on the left) by the distance,
+// and joins up the ends around each corner
+func offsetRing(ring Ring, distance float64, options OffsetOptions) Ring {
+	points := removeDuplicates(ring)
+	if len(points) < 3 {
+		return nil
+	}
+
+	// The unit normal of each edge, pointing to its right
+	normals := make([]Point, len(points))
+	for i,point := range points {
+		next := points[(i + 1) % len(points)]
+		length := math.Hypot(next.X - point.X, next.Y - point.Y)
+		normals[i] = Point{(next.Y - point.Y) / length, -(next.X - point.X) / length}
+	}
+
+	offset := make(Ring, 0, 3 * len(points))
+	for i,point := range points {
+		before := normals[(i + len(points) - 1) % len(points)]
+		after := normals[i]
+
+		sinA := (before.X * after.Y) - (before.Y * after.X)
+		cosA := (before.X * after.X) + (before.Y * after.Y)
+
+		if sinA * distance < 0.0 {
+			// The moved edges overlap on this side of the corner.  Going back through the corner itself makes the overlap
+			// wind the wrong way around, which gets it cleaned up when the rings are merged
+			offset = append(offset, offsetPoint(point, before, distance), point, offsetPoint(point, after, distance))
+			continue
+		}
+
+		// The angle the edges turn through at the corner, in the same direction as the offset
+		angle := math.Atan2(math.Abs(sinA), cosA)
+		if distance < 0.0 {
+			angle = -angle
+		}
+
+		switch options.Join {
+			case JOIN_MITER:
+				if (1.0 + cosA) > (2.0 / (options.MiterLimit * options.MiterLimit)) {
+					// The miter point is where the moved edges meet, on the bisector of the corner
+					scale := distance / (1.0 + cosA)
+					offset = append(offset, Point{point.X + ((before.X + after.X) * scale), point.Y + ((before.Y + after.Y) * scale)})
+				} else {
+					offset = append(offset, squareJoin(point, before, after, distance, angle)...)
+				}
+
+			case JOIN_SQUARE:
+				offset = append(offset, squareJoin(point, before, after, distance, angle)...)
+
+			default:
+				offset = append(offset, roundJoin(point, before, angle, distance, options.Tolerance)...)
+		}
+	}
+
+	return offset
+}
+
+func offsetPoint(point Point, normal Point, distance float64) Point {
+	return Point{point.X + (normal.X * distance), point.Y + (normal.Y * distance)}
+}
+
+// The arc around the corner from the end of the edge before it (which has the given normal), turning through the angle
+func roundJoin(point Point, before Point, angle float64, distance float64, tolerance float64) []Point {
+	// A chord across an angle a of a circle strays r * (1 - cos(a / 2)) from the circle at its middle
+	radius := math.Abs(distance)
+	maxAngle := math.Pi
+	if tolerance < radius {
+		maxAngle = 2.0 * math.Acos(1.0 - (tolerance / radius))
+	}
+	steps := max(1, int(math.Ceil(math.Abs(angle) / maxAngle)))
+
+	arc := make([]Point, 0, steps + 1)
+	for step := 0; step <= steps; step++ {
+		sin,cos := math.Sincos(angle * float64(step) / float64(steps))
+		normal := Point{(before.X * cos) - (before.Y * sin), (before.X * sin) + (before.Y * cos)}
+		arc = append(arc, offsetPoint(point, normal, distance))
+	}
+
+	return arc
+}
+
+// Cuts the corner off across its bisector, the offset distance away from the corner.  The cut meets each moved edge
+// distance * tan(angle / 4) past the end of the edge
+func squareJoin(point Point, before Point, after Point, distance float64, angle float64) []Point {
+	reach := distance * math.Tan(angle / 4.0)
+
+	// The edge directions are the normals turned a quarter turn counter-clockwise
+	start := offsetPoint(point, before, distance)
+	end := offsetPoint(point, after, distance)
+	start.X,start.Y = start.X - (before.Y * reach),start.Y + (before.X * reach)
+	end.X,end.Y = end.X + (after.Y * reach),end.Y - (after.X * reach)
+
+	return []Point{start, end}
+}
+
+// The points of the ring, without any that are the same as the point before them
+func removeDuplicates(ring Ring) Ring {
+	points := make(Ring, 0, len(ring))
+	for _,point := range ring {
+		if math.IsNaN(point.X) || math.IsNaN(point.Y) || math.IsInf(point.X, 0) || math.IsInf(point.Y, 0) {
+			continue
+		}
+
+		if (len(points) == 0) || (point != points[len(points) - 1]) {
+			points = append(points, point)
+		}
+	}
+
+	for (len(points) > 1) && (points[0] == points[len(points) - 1]) {
+		points = points[:len(points) - 1]
+	}
+
+	return points
+}
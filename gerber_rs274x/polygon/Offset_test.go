@@ -0,0 +1,109 @@
+package polygon
+
+import (
+	"math"
+	"testing"
+)
+
+func TestOffset(t *testing.T) {
+	// A square with a square hole in the middle, wound the other way
+	squareWithHole := []Polygon{{Outer: square(0, 0, 4), Holes: []Ring{square(1.5, 1.5, 1).Reversed()}}}
+	// Two squares with a gap of 0.4 between them
+	twoSquares := []Polygon{{Outer: square(0, 0, 1)}, {Outer: square(1.4, 0, 1)}}
+	thinRectangle := []Polygon{{Outer: Ring{{0, 0}, {2, 0}, {2, 0.2}, {0, 0.2}}}}
+
+	testCases := []struct {
+		name string
+		polygons []Polygon
+		distance float64
+		options OffsetOptions
+		numPolygons, numHoles int
+		area float64
+		// The bounds are only checked if there's anything left
+		xMin, xMax, yMin, yMax float64
+		tolerance float64
+	}{
+		// Growing a square sweeps each corner out into a quarter circle, a square or a cut off square
+		{"Round join", []Polygon{{Outer: square(0, 0, 2)}}, 0.5, OffsetOptions{Join: JOIN_ROUND, Tolerance: 1e-7}, 1, 0, 8.0 + (math.Pi * 0.25), -0.5, 2.5, -0.5, 2.5, 1e-5},
+		{"Miter join", []Polygon{{Outer: square(0, 0, 2)}}, 0.5, OffsetOptions{Join: JOIN_MITER}, 1, 0, 9.0, -0.5, 2.5, -0.5, 2.5, 1e-9},
+		{"Square join", []Polygon{{Outer: square(0, 0, 2)}}, 0.5, OffsetOptions{Join: JOIN_SQUARE}, 1, 0, 8.0 + (2.0 * math.Sqrt2) - 2.0, -0.5, 2.5, -0.5, 2.5, 1e-9},
+		// A right angle corner is too sharp for a miter limit under the square root of 2, so it's squared off instead
+		{"Miter over the limit", []Polygon{{Outer: square(0, 0, 2)}}, 0.5, OffsetOptions{Join: JOIN_MITER, MiterLimit: 1.2}, 1, 0, 8.0 + (2.0 * math.Sqrt2) - 2.0, -0.5, 2.5, -0.5, 2.5, 1e-9},
+		// Shrinking never needs any joins on a convex shape, so they're all the same
+		{"Shrink with round join", []Polygon{{Outer: square(0, 0, 2)}}, -0.5, OffsetOptions{Join: JOIN_ROUND}, 1, 0, 1.0, 0.5, 1.5, 0.5, 1.5, 1e-9},
+		{"Shrink with miter join", []Polygon{{Outer: square(0, 0, 2)}}, -0.5, OffsetOptions{Join: JOIN_MITER}, 1, 0, 1.0, 0.5, 1.5, 0.5, 1.5, 1e-9},
+		{"Shrink until it vanishes", []Polygon{{Outer: square(0, 0, 2)}}, -1.5, OffsetOptions{Join: JOIN_MITER}, 0, 0, 0.0, 0, 0, 0, 0, 1e-9},
+		{"Thin part vanishes", thinRectangle, -0.15, OffsetOptions{Join: JOIN_ROUND}, 0, 0, 0.0, 0, 0, 0, 0, 1e-9},
+		// The hole shrinks as the outside grows, until it closes up
+		{"Hole shrinks", squareWithHole, 0.2, OffsetOptions{Join: JOIN_MITER}, 1, 1, (4.4 * 4.4) - (0.6 * 0.6), -0.2, 4.2, -0.2, 4.2, 1e-9},
+		{"Hole grows", squareWithHole, -0.2, OffsetOptions{Join: JOIN_MITER}, 1, 1, (3.6 * 3.6) - (1.4 * 1.4), 0.2, 3.8, 0.2, 3.8, 1e-9},
+		{"Hole closes", squareWithHole, 0.6, OffsetOptions{Join: JOIN_MITER}, 1, 0, 5.2 * 5.2, -0.6, 4.6, -0.6, 4.6, 1e-9},
+		// Shapes that grow into each other are merged
+		{"Shapes merge", twoSquares, 0.25, OffsetOptions{Join: JOIN_MITER}, 1, 0, 2.9 * 1.5, -0.25, 2.65, -0.25, 1.25, 1e-9},
+		{"Shapes stay apart", twoSquares, 0.1, OffsetOptions{Join: JOIN_MITER}, 2, 0, 2.0 * 1.2 * 1.2, -0.1, 2.5, -0.1, 1.1, 1e-9},
+		{"No offset", twoSquares, 0.0, OffsetOptions{}, 2, 0, 2.0, 0.0, 2.4, 0.0, 1.0, 1e-9},
+	}
+
+	for _,testCase := range testCases {
+		offset,err := Offset(testCase.polygons, testCase.distance, testCase.options)
+		if err != nil {
+			t.Errorf("%s: unexpected error %v", testCase.name, err)
+			continue
+		}
+
+		holes := 0
+		xMin,xMax,yMin,yMax := math.Inf(1),math.Inf(-1),math.Inf(1),math.Inf(-1)
+		for _,polygon := range offset {
+			holes += len(polygon.Holes)
+			ringXMin,ringXMax,ringYMin,ringYMax := polygon.Outer.Bounds()
+			xMin,xMax = math.Min(xMin, ringXMin),math.Max(xMax, ringXMax)
+			yMin,yMax = math.Min(yMin, ringYMin),math.Max(yMax, ringYMax)
+		}
+
+		if (len(offset) != testCase.numPolygons) || (holes != testCase.numHoles) || (math.Abs(Area(offset) - testCase.area) > testCase.tolerance) {
+			t.Errorf("%s: got %d polygons with %d holes and area %v, expected %d polygons with %d holes and area %v",
+				testCase.name, len(offset), holes, Area(offset), testCase.numPolygons, testCase.numHoles, testCase.area)
+			continue
+		}
+
+		if len(offset) > 0 {
+			got := []float64{xMin, xMax, yMin, yMax}
+			expected := []float64{testCase.xMin, testCase.xMax, testCase.yMin, testCase.yMax}
+			for index := range got {
+				if math.Abs(got[index] - expected[index]) > testCase.tolerance {
+					t.Errorf("%s: bounds are (xMin, xMax, yMin, yMax) %v, expected %v", testCase.name, got, expected)
+					break
+				}
+			}
+		}
+	}
+}
+
+// Growing and then shrinking a convex shape by the same distance gives it back, to within the tolerance of the round joins
+func TestOffsetGrowAndShrink(t *testing.T) {
+	hexagon := Ring{{2, 0}, {1, 1.7}, {-1, 1.7}, {-2, 0}, {-1, -1.7}, {1, -1.7}}
+	original := []Polygon{{Outer: hexagon}}
+
+	for _,join := range []JoinType{JOIN_ROUND, JOIN_MITER, JOIN_SQUARE} {
+		grown,err := Offset(original, 0.3, OffsetOptions{Join: join})
+		if err != nil {
+			t.Fatalf("Join %d: unexpected error growing %v", join, err)
+		}
+
+		shrunk,err := Offset(grown, -0.3, OffsetOptions{Join: join})
+		if err != nil {
+			t.Fatalf("Join %d: unexpected error shrinking %v", join, err)
+		}
+
+		if Area(grown) <= Area(original) {
+			t.Errorf("Join %d: growing went from an area of %v to %v", join, Area(original), Area(grown))
+		}
+
+		// Anything left over after shrinking back down is what doesn't match the original
+		if difference,err := Xor(shrunk, original); err != nil {
+			t.Errorf("Join %d: unexpected error comparing %v", join, err)
+		} else if Area(difference) > 1e-3 {
+			t.Errorf("Join %d: growing and shrinking changed the area by %v", join, Area(difference))
+		}
+	}
+}
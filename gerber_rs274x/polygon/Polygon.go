@@ -0,0 +1,151 @@
+// Package polygon works with exact polygon geometry (polygons with holes, made up of straight edges),
+// such as the copper shapes extracted from a gerber file
+package polygon
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// A point in the plane, in whatever units the caller is working in
+type Point struct {
+	X float64
+	Y float64
+}
+
+// A closed ring of points.  The last point connects back to the first, so it isn't repeated
+type Ring []Point
+
+// A polygon with holes.  The outer ring is counter-clockwise and the holes are clockwise,
+// so the filled area is always on the left of the edges
+type Polygon struct {
+	Outer Ring
+	Holes []Ring
+}
+
+// How overlapping rings decide what is filled
+type FillRule int
+
+const (
+	EVEN_ODD FillRule = iota // Filled where a point is inside an odd number of rings
+	NON_ZERO // Filled where the rings wind around a point a non-zero number of times
+	POSITIVE // Filled where the rings wind counter-clockwise around a point more times than they wind clockwise
+)
+
+// The signed area of the ring, which is positive if the ring is counter-clockwise
+func (ring Ring) Area() float64 {
+	area := 0.0
+	for i := range ring {
+		j := (i + 1) % len(ring)
+		area += (ring[i].X * ring[j].Y) - (ring[j].X * ring[i].Y)
+	}
+
+	return area / 2.0
+}
+
+// The bounding box of the ring
+func (ring Ring) Bounds() (float64, float64, float64, float64) {
+	xMin,xMax := math.Inf(1),math.Inf(-1)
+	yMin,yMax := math.Inf(1),math.Inf(-1)
+	for _,point := range ring {
+		xMin = math.Min(xMin, point.X)
+		xMax = math.Max(xMax, point.X)
+		yMin = math.Min(yMin, point.Y)
+		yMax = math.Max(yMax, point.Y)
+	}
+
+	return xMin,xMax,yMin,yMax
+}
+
+// Whether the point is inside the ring (points exactly on an edge may go either way)
+func (ring Ring) Contains(point Point) bool {
+	inside := false
+	for i := range ring {
+		j := (i + 1) % len(ring)
+		if (ring[i].Y > point.Y) != (ring[j].Y > point.Y) {
+			crossingX := ring[i].X + ((point.Y - ring[i].Y) * (ring[j].X - ring[i].X) / (ring[j].Y - ring[i].Y))
+			if point.X < crossingX {
+				inside = !inside
+			}
+		}
+	}
+
+	return inside
+}
+
+// The same ring, going the other way around
+func (ring Ring) Reversed() Ring {
+	reversed := make(Ring, len(ring))
+	for i,point := range ring {
+		reversed[len(ring) - 1 - i] = point
+	}
+
+	return reversed
+}
+
+func (ring Ring) String() string {
+	points := make([]string, len(ring))
+	for i,point := range ring {
+		points[i] = fmt.Sprintf("(%v %v)", point.X, point.Y)
+	}
+
+	return fmt.Sprintf("{Ring, Points: %s}", strings.Join(points, " "))
+}
+
+// The filled area of the polygon
+func (polygon Polygon) Area() float64 {
+	area := polygon.Outer.Area()
+	for _,hole := range polygon.Holes {
+		area += hole.Area()
+	}
+
+	return area
+}
+
+// Whether the point is in the filled area of the polygon
+func (polygon Polygon) Contains(point Point) bool {
+	if !polygon.Outer.Contains(point) {
+		return false
+	}
+
+	for _,hole := range polygon.Holes {
+		if hole.Contains(point) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// All of the rings of the polygon, outer ring first
+func (polygon Polygon) Rings() []Ring {
+	rings := make([]Ring, 0, len(polygon.Holes) + 1)
+	rings = append(rings, polygon.Outer)
+
+	return append(rings, polygon.Holes...)
+}
+
+func (polygon Polygon) String() string {
+	return fmt.Sprintf("{Polygon, Outer: %v, Holes: %v}", polygon.Outer, polygon.Holes)
+}
+
+// All of the rings of all of the polygons
+func Rings(polygons []Polygon) []Ring {
+	var rings []Ring
+	for _,polygon := range polygons {
+		rings = append(rings, polygon.Rings()...)
+	}
+
+	return rings
+}
+
+// The total filled area of the polygons (assuming they don't overlap)
+func Area(polygons []Polygon) float64 {
+	area := 0.0
+	for _,polygon := range polygons {
+		area += polygon.Area()
+	}
+
+	return area
+}
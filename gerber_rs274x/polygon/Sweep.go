@@ -0,0 +1,54 @@
+package polygon
+
+// The area swept out by moving the polygons (without turning them) along the path, from its first point to its last.
+// This works for any polygons, holes and all, by breaking them up into convex pieces and sweeping each of those
+func Sweep(polygons []Polygon, path []Point) ([]Polygon, error) {
+	return SweepConvex(ConvexPieces(polygons), path)
+}
+
+// The area swept out by moving the convex pieces along the path, which is the same as Sweep but lets the caller break
+// a shape up into pieces once and sweep it along many paths.  The pieces have to be convex and counter-clockwise,
+// like the ones that come out of ConvexPieces.
+// A convex piece moved along a straight line sweeps out exactly the convex hull of the piece at either end of it,
+// so the swept area is the union of one hull per piece for each segment of the path
+func SweepConvex(pieces []Ring, path []Point) ([]Polygon, error) {
+	if len(pieces) == 0 || len(path) == 0 {
+		return nil,nil
+	}
+
+	var swept []Ring
+	for i := 1; i < len(path); i++ {
+		from,to := path[i - 1],path[i]
+		if from == to {
+			continue
+		}
+
+		for _,piece := range pieces {
+			placements := make([]Point, 0, 2 * len(piece))
+			placements = append(placements, translateRing(piece, from)...)
+			placements = append(placements, translateRing(piece, to)...)
+			if hull := ConvexHull(placements); len(hull) >= 3 {
+				swept = append(swept, hull)
+			}
+		}
+	}
+
+	if swept == nil {
+		// The path doesn't go anywhere, so it's just the pieces where they are
+		for _,piece := range pieces {
+			swept = append(swept, translateRing(piece, path[0]))
+		}
+	}
+
+	// The hulls are all counter-clockwise, so everything that's wound around at all is in the swept area
+	return Simplify(swept, POSITIVE)
+}
+
+func translateRing(ring Ring, offset Point) Ring {
+	translated := make(Ring, len(ring))
+	for i,point := range ring {
+		translated[i] = Point{point.X + offset.X, point.Y + offset.Y}
+	}
+
+	return translated
+}
@@ -0,0 +1,11 @@
+//go:build cairo
+
+package main
+
+import (
+	"gerber_rs274x"
+)
+
+func renderImage(baseName string, blocks []gerber_rs274x.DataBlock, options gerber_rs274x.RenderOptions) error {
+	return gerber_rs274x.GenerateSurfaceWithOptions(baseName + ".png", blocks, options)
+}
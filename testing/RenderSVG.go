@@ -0,0 +1,21 @@
+//go:build !cairo
+
+package main
+
+import (
+	"os"
+	"gerber_rs274x"
+)
+
+func renderImage(baseName string, blocks []gerber_rs274x.DataBlock, options gerber_rs274x.RenderOptions) error {
+	if outFile,err := os.Create(baseName + ".svg"); err != nil {
+		return err
+	} else {
+		if err := gerber_rs274x.RenderSVG(outFile, blocks, options); err != nil {
+			outFile.Close()
+			return err
+		}
+		
+		return outFile.Close()
+	}
+}
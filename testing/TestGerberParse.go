@@ -4,6 +4,7 @@ import (
 	"os"
 	"fmt"
 	"gerber_rs274x"
+	"log/slog"
 	"path/filepath"
 )
 
@@ -13,26 +14,32 @@ func main() {
 		os.Exit(1)
 	}
 	
+	// Send all of the library diagnostics to stderr, since this is a debugging tool
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	
 	if inputFile,err := os.Open(os.Args[1]); err != nil {
 		fmt.Printf("Error opening input file %s: %s\n", os.Args[1], err.Error())
 		os.Exit(2)
 	} else {
 		
-		if parsedFile,err := gerber_rs274x.ParseGerberFile(inputFile); err != nil {
+		if parseResult,err := gerber_rs274x.ParseGerberFileWithOptions(inputFile, gerber_rs274x.ParseOptions{Logger: logger}); err != nil {
 			inputFile.Close()
 			fmt.Printf("Error parsing gerber file: %v\n", err)
 			os.Exit(3)
 		} else {
 			inputFile.Close()
 			
-			if parsedFile == nil {
-			
+			for _,warning := range parseResult.Warnings {
+				fmt.Printf("Warning: %v\n", warning)
 			}
 			
-			outputFileName := filepath.Base(os.Args[1] + ".png")
+			for index,dataBlock := range parseResult.DataBlocks {
+				fmt.Printf("Parsed data block %3d: %v\n", index, dataBlock)
+			}
 			
-			if err := gerber_rs274x.GenerateSurface(outputFileName, parsedFile); err != nil {
-				fmt.Printf("Error generating PNG file: %s\n", err.Error())
+			// The image is a PNG when built with the cairo tag, and an SVG otherwise
+			if err := renderImage(filepath.Base(os.Args[1]), parseResult.DataBlocks, gerber_rs274x.RenderOptions{Logger: logger}); err != nil {
+				fmt.Printf("Error generating image file: %s\n", err.Error())
 				os.Exit(5)
 			}
 		}